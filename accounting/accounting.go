@@ -0,0 +1,407 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package accounting : Aggregates the resource usage of SQL jobs into cost reports.
+//
+// A Report collects the usage counters of finished jobs (bytes and rows read, rows returned, objects
+// skipped and qualified by index management) and groups them by user, by statement fingerprint and by
+// day, together with a cost estimate derived from a price per terabyte scanned. Reports can be written
+// as CSV or JSON.
+//
+// Note that ListSqlJobs only returns the most recent jobs of an instance, so usage over a long period,
+// such as a month, should be collected regularly into the same Report; jobs are only counted once.
+package accounting
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// BytesPerTB is the number of bytes in a terabyte, as used for pricing.
+const BytesPerTB = 1e12
+
+// Grouping : The dimension used to group usage in a report.
+type Grouping string
+
+// Constants associated with Grouping.
+const (
+	Grouping_User        = Grouping("user")
+	Grouping_Fingerprint = Grouping("fingerprint")
+	Grouping_Day         = Grouping("day")
+	Grouping_Job         = Grouping("job")
+)
+
+// Options : The Report options.
+type Options struct {
+	// Only jobs submitted at or after this time are counted. Ignored when zero.
+	Start time.Time
+
+	// Only jobs submitted before this time are counted. Ignored when zero.
+	End time.Time
+
+	// The price of scanning one terabyte (10^12 bytes), used for cost estimates.
+	PricePerTB float64
+
+	// The minimum number of bytes billed per job, if the pricing has one.
+	MinimumBytesPerJob float64
+
+	// The time zone used to assign jobs to days. Defaults to UTC.
+	Location *time.Location
+
+	// The function used to group statements. Defaults to fingerprint.Fingerprint.
+	Fingerprint func(statement string) string
+
+	// The number of GetSqlJob calls made concurrently by Collect. Defaults to 4.
+	Concurrency int
+}
+
+// JobUsage : The resource usage of a single job.
+type JobUsage struct {
+	JobID            string    `json:"job_id"`
+	UserID           string    `json:"user_id"`
+	Status           string    `json:"status"`
+	Fingerprint      string    `json:"fingerprint"`
+	SubmitTime       time.Time `json:"submit_time"`
+	BytesRead        float64   `json:"bytes_read"`
+	RowsRead         float64   `json:"rows_read"`
+	RowsReturned     float64   `json:"rows_returned"`
+	ObjectsSkipped   float64   `json:"objects_skipped"`
+	ObjectsQualified float64   `json:"objects_qualified"`
+	EstimatedCost    float64   `json:"estimated_cost"`
+}
+
+// Usage : The aggregated usage of a group of jobs.
+type Usage struct {
+	Key              string  `json:"key"`
+	Jobs             int     `json:"jobs"`
+	FailedJobs       int     `json:"failed_jobs"`
+	BytesRead        float64 `json:"bytes_read"`
+	RowsRead         float64 `json:"rows_read"`
+	RowsReturned     float64 `json:"rows_returned"`
+	ObjectsSkipped   float64 `json:"objects_skipped"`
+	ObjectsQualified float64 `json:"objects_qualified"`
+	EstimatedCost    float64 `json:"estimated_cost"`
+}
+
+// add accounts for one more job in the group.
+func (usage *Usage) add(job *JobUsage) {
+	usage.Jobs++
+	if job.Status == sqlv2.SqlJobInfoFull_Status_Failed {
+		usage.FailedJobs++
+	}
+	usage.BytesRead += job.BytesRead
+	usage.RowsRead += job.RowsRead
+	usage.RowsReturned += job.RowsReturned
+	usage.ObjectsSkipped += job.ObjectsSkipped
+	usage.ObjectsQualified += job.ObjectsQualified
+	usage.EstimatedCost += job.EstimatedCost
+}
+
+// Report accumulates the usage of finished jobs. It is safe for concurrent use.
+type Report struct {
+	options Options
+
+	mutex sync.Mutex
+	jobs  map[string]*JobUsage
+}
+
+// NewReport : constructs an empty Report.
+func NewReport(options *Options) *Report {
+	report := &Report{jobs: make(map[string]*JobUsage)}
+	if options != nil {
+		report.options = *options
+	}
+	if report.options.Location == nil {
+		report.options.Location = time.UTC
+	}
+	if report.options.Fingerprint == nil {
+		report.options.Fingerprint = fingerprint.Fingerprint
+	}
+	if report.options.Concurrency <= 0 {
+		report.options.Concurrency = 4
+	}
+	return report
+}
+
+// Add records the usage of "job". Jobs that have not finished, that were submitted outside of the
+// report's time range or that were already recorded are ignored. Add returns whether the job was recorded.
+func (report *Report) Add(job *sqlv2.SqlJobInfoFull) bool {
+	if job == nil || job.JobID == nil || job.Status == nil || !sqlv2.IsTerminalJobStatus(*job.Status) {
+		return false
+	}
+	var submitTime time.Time
+	if job.SubmitTime != nil {
+		submitTime = time.Time(*job.SubmitTime)
+	}
+	if !report.inRange(submitTime) {
+		return false
+	}
+
+	usage := &JobUsage{
+		JobID:            *job.JobID,
+		Status:           *job.Status,
+		SubmitTime:       submitTime,
+		BytesRead:        floatValue(job.BytesRead),
+		RowsRead:         floatValue(job.RowsRead),
+		RowsReturned:     floatValue(job.RowsReturned),
+		ObjectsSkipped:   floatValue(job.ObjectsSkipped),
+		ObjectsQualified: floatValue(job.ObjectsQualified),
+	}
+	if job.UserID != nil {
+		usage.UserID = *job.UserID
+	}
+	if job.Statement != nil {
+		usage.Fingerprint = report.options.Fingerprint(*job.Statement)
+	}
+	usage.EstimatedCost = report.EstimateCost(usage.BytesRead)
+
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+	if _, exists := report.jobs[usage.JobID]; exists {
+		return false
+	}
+	report.jobs[usage.JobID] = usage
+	return true
+}
+
+// EstimateCost returns the estimated cost of a job that scanned "bytesRead" bytes.
+func (report *Report) EstimateCost(bytesRead float64) float64 {
+	if bytesRead < report.options.MinimumBytesPerJob {
+		bytesRead = report.options.MinimumBytesPerJob
+	}
+	return bytesRead / BytesPerTB * report.options.PricePerTB
+}
+
+// Collect lists the jobs of the instance and records the usage of every finished job submitted within the
+// report's time range. It returns the number of jobs that were recorded.
+func (report *Report) Collect(ctx context.Context, sql *sqlv2.SqlV2) (int, error) {
+	list, _, err := sql.ListSqlJobsWithContext(ctx, &sqlv2.ListSqlJobsOptions{})
+	if err != nil {
+		return 0, err
+	}
+
+	var jobIDs []string
+	report.mutex.Lock()
+	for _, job := range list.Jobs {
+		if job.JobID == nil || job.Status == nil || !sqlv2.IsTerminalJobStatus(*job.Status) {
+			continue
+		}
+		if job.SubmitTime != nil && !report.inRange(time.Time(*job.SubmitTime)) {
+			continue
+		}
+		if _, exists := report.jobs[*job.JobID]; exists {
+			continue
+		}
+		jobIDs = append(jobIDs, *job.JobID)
+	}
+	report.mutex.Unlock()
+
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+	var firstErr error
+	added := 0
+	jobIDChannel := make(chan string)
+	for i := 0; i < report.options.Concurrency; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for jobID := range jobIDChannel {
+				job, _, err := sql.GetSqlJobWithContext(ctx, sql.NewGetSqlJobOptions(jobID))
+				mutex.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else if report.Add(job) {
+					added++
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, jobID := range jobIDs {
+		jobIDChannel <- jobID
+	}
+	close(jobIDChannel)
+	waitGroup.Wait()
+
+	return added, firstErr
+}
+
+// Jobs returns the usage of every recorded job, ordered by submit time.
+func (report *Report) Jobs() []JobUsage {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	jobs := make([]JobUsage, 0, len(report.jobs))
+	for _, job := range report.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].SubmitTime.Equal(jobs[j].SubmitTime) {
+			return jobs[i].SubmitTime.Before(jobs[j].SubmitTime)
+		}
+		return jobs[i].JobID < jobs[j].JobID
+	})
+	return jobs
+}
+
+// Group aggregates the recorded jobs by "grouping", ordered by key.
+func (report *Report) Group(grouping Grouping) ([]Usage, error) {
+	var keyOf func(job *JobUsage) string
+	switch grouping {
+	case Grouping_User:
+		keyOf = func(job *JobUsage) string { return job.UserID }
+	case Grouping_Fingerprint:
+		keyOf = func(job *JobUsage) string { return job.Fingerprint }
+	case Grouping_Day:
+		keyOf = func(job *JobUsage) string {
+			return job.SubmitTime.In(report.options.Location).Format("2006-01-02")
+		}
+	case Grouping_Job:
+		keyOf = func(job *JobUsage) string { return job.JobID }
+	default:
+		return nil, fmt.Errorf("unsupported grouping: %s", grouping)
+	}
+
+	jobs := report.Jobs()
+	groups := make(map[string]*Usage)
+	for i := range jobs {
+		key := keyOf(&jobs[i])
+		usage, exists := groups[key]
+		if !exists {
+			usage = &Usage{Key: key}
+			groups[key] = usage
+		}
+		usage.add(&jobs[i])
+	}
+
+	result := make([]Usage, 0, len(groups))
+	for _, usage := range groups {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
+
+// Total aggregates all the recorded jobs.
+func (report *Report) Total() Usage {
+	jobs := report.Jobs()
+	total := Usage{Key: "total"}
+	for i := range jobs {
+		total.add(&jobs[i])
+	}
+	return total
+}
+
+// WriteCSV writes the usage grouped by "grouping" as CSV, with a header row.
+func (report *Report) WriteCSV(writer io.Writer, grouping Grouping) error {
+	groups, err := report.Group(grouping)
+	if err != nil {
+		return err
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	err = csvWriter.Write([]string{string(grouping), "jobs", "failed_jobs", "bytes_read", "rows_read",
+		"rows_returned", "objects_skipped", "objects_qualified", "estimated_cost"})
+	if err != nil {
+		return err
+	}
+	for _, usage := range groups {
+		err = csvWriter.Write([]string{
+			usage.Key,
+			strconv.Itoa(usage.Jobs),
+			strconv.Itoa(usage.FailedJobs),
+			formatFloat(usage.BytesRead),
+			formatFloat(usage.RowsRead),
+			formatFloat(usage.RowsReturned),
+			formatFloat(usage.ObjectsSkipped),
+			formatFloat(usage.ObjectsQualified),
+			strconv.FormatFloat(usage.EstimatedCost, 'f', 4, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// reportDocument : The JSON form of a report.
+type reportDocument struct {
+	Start         *time.Time `json:"start,omitempty"`
+	End           *time.Time `json:"end,omitempty"`
+	PricePerTB    float64    `json:"price_per_tb"`
+	Total         Usage      `json:"total"`
+	ByUser        []Usage    `json:"by_user"`
+	ByFingerprint []Usage    `json:"by_fingerprint"`
+	ByDay         []Usage    `json:"by_day"`
+	Jobs          []JobUsage `json:"jobs"`
+}
+
+// WriteJSON writes the whole report, including every grouping and the usage of each job, as JSON.
+func (report *Report) WriteJSON(writer io.Writer) error {
+	document := reportDocument{
+		PricePerTB: report.options.PricePerTB,
+		Total:      report.Total(),
+		Jobs:       report.Jobs(),
+	}
+	if !report.options.Start.IsZero() {
+		document.Start = &report.options.Start
+	}
+	if !report.options.End.IsZero() {
+		document.End = &report.options.End
+	}
+	document.ByUser, _ = report.Group(Grouping_User)
+	document.ByFingerprint, _ = report.Group(Grouping_Fingerprint)
+	document.ByDay, _ = report.Group(Grouping_Day)
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&document)
+}
+
+// inRange returns true if a job submitted at "submitTime" belongs to the report's time range.
+func (report *Report) inRange(submitTime time.Time) bool {
+	if !report.options.Start.IsZero() && submitTime.Before(report.options.Start) {
+		return false
+	}
+	if !report.options.End.IsZero() && !submitTime.Before(report.options.End) {
+		return false
+	}
+	return true
+}
+
+func floatValue(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,170 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package accounting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func job(jobID string, user string, status string, submitTime string, statement string, bytesRead float64) *sqlv2.SqlJobInfoFull {
+	dateTime, _ := core.ParseDateTime(submitTime)
+	return &sqlv2.SqlJobInfoFull{
+		JobID:        core.StringPtr(jobID),
+		UserID:       core.StringPtr(user),
+		Status:       core.StringPtr(status),
+		SubmitTime:   &dateTime,
+		Statement:    core.StringPtr(statement),
+		BytesRead:    core.Float64Ptr(bytesRead),
+		RowsRead:     core.Float64Ptr(bytesRead / 100),
+		RowsReturned: core.Float64Ptr(1),
+	}
+}
+
+func testReport() *Report {
+	report := NewReport(&Options{
+		Start:      time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:        time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+		PricePerTB: 5,
+	})
+	report.Add(job("j1", "alice", "completed", "2022-03-01T10:00:00.000Z", "SELECT * FROM t", 2e12))
+	report.Add(job("j2", "bob", "failed", "2022-03-01T11:00:00.000Z", "SELECT *\n  FROM t", 1e12))
+	report.Add(job("j3", "alice", "completed", "2022-03-02T09:00:00.000Z", "SELECT a FROM t", 1e11))
+	return report
+}
+
+func TestAdd(t *testing.T) {
+	report := testReport()
+	assert.False(t, report.Add(job("j1", "alice", "completed", "2022-03-01T10:00:00.000Z", "SELECT 1", 1)))
+	assert.False(t, report.Add(job("j4", "alice", "running", "2022-03-01T10:00:00.000Z", "SELECT 1", 1)))
+	assert.False(t, report.Add(job("j5", "alice", "completed", "2022-04-01T00:00:00.000Z", "SELECT 1", 1)))
+	assert.False(t, report.Add(job("j6", "alice", "completed", "2022-02-28T23:59:59.000Z", "SELECT 1", 1)))
+	assert.False(t, report.Add(nil))
+	assert.Len(t, report.Jobs(), 3)
+}
+
+func TestGroup(t *testing.T) {
+	report := testReport()
+
+	byUser, err := report.Group(Grouping_User)
+	require.Nil(t, err)
+	require.Len(t, byUser, 2)
+	assert.Equal(t, "alice", byUser[0].Key)
+	assert.Equal(t, 2, byUser[0].Jobs)
+	assert.Equal(t, 2.1e12, byUser[0].BytesRead)
+	assert.InDelta(t, 10.5, byUser[0].EstimatedCost, 1e-9)
+	assert.Equal(t, 1, byUser[1].FailedJobs)
+
+	byFingerprint, err := report.Group(Grouping_Fingerprint)
+	require.Nil(t, err)
+	require.Len(t, byFingerprint, 2)
+
+	byDay, err := report.Group(Grouping_Day)
+	require.Nil(t, err)
+	require.Len(t, byDay, 2)
+	assert.Equal(t, "2022-03-01", byDay[0].Key)
+	assert.Equal(t, 2, byDay[0].Jobs)
+	assert.InDelta(t, 15.0, byDay[0].EstimatedCost, 1e-9)
+
+	_, err = report.Group(Grouping("bogus"))
+	assert.NotNil(t, err)
+
+	total := report.Total()
+	assert.Equal(t, 3, total.Jobs)
+	assert.Equal(t, 3.1e12, total.BytesRead)
+}
+
+func TestMinimumBytesPerJob(t *testing.T) {
+	report := NewReport(&Options{PricePerTB: 5, MinimumBytesPerJob: 1e7})
+	assert.InDelta(t, 5e-5, report.EstimateCost(0), 1e-12)
+	assert.InDelta(t, 5, report.EstimateCost(1e12), 1e-12)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buffer bytes.Buffer
+	require.Nil(t, testReport().WriteCSV(&buffer, Grouping_Day))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "day,jobs,failed_jobs,bytes_read,rows_read,rows_returned,objects_skipped,objects_qualified,estimated_cost", lines[0])
+	assert.Equal(t, "2022-03-01,2,1,3000000000000,30000000000,2,0,0,15.0000", lines[1])
+	assert.Equal(t, "2022-03-02,1,0,100000000000,1000000000,1,0,0,0.5000", lines[2])
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	require.Nil(t, testReport().WriteJSON(&buffer))
+
+	var document map[string]json.RawMessage
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &document))
+	for _, key := range []string{"start", "end", "price_per_tb", "total", "by_user", "by_fingerprint", "by_day", "jobs"} {
+		assert.Contains(t, document, key)
+	}
+	var jobs []JobUsage
+	require.Nil(t, json.Unmarshal(document["jobs"], &jobs))
+	require.Len(t, jobs, 3)
+	assert.Equal(t, "j1", jobs[0].JobID)
+	assert.InDelta(t, 10.0, jobs[0].EstimatedCost, 1e-9)
+}
+
+func TestCollect(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-type", "application/json")
+		res.WriteHeader(200)
+		if req.URL.EscapedPath() == "/sql_jobs" {
+			fmt.Fprint(res, `{"jobs": [
+				{"job_id": "j1", "status": "completed", "submit_time": "2022-03-01T10:00:00.000Z"},
+				{"job_id": "j2", "status": "running", "submit_time": "2022-03-01T10:00:00.000Z"},
+				{"job_id": "j3", "status": "failed", "submit_time": "2022-02-01T10:00:00.000Z"},
+				{"job_id": "j4", "status": "failed", "submit_time": "2022-03-05T10:00:00.000Z"}]}`)
+			return
+		}
+		jobID := strings.TrimPrefix(req.URL.EscapedPath(), "/sql_jobs/")
+		fmt.Fprintf(res, `{"job_id": "%s", "status": "completed", "user_id": "alice", "submit_time": "2022-03-01T10:00:00.000Z", "statement": "SELECT 1", "bytes_read": 1000}`, jobID)
+	}))
+	defer testServer.Close()
+	sqlService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           testServer.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+	})
+	require.Nil(t, err)
+
+	report := NewReport(&Options{
+		Start: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	added, err := report.Collect(context.Background(), sqlService)
+	require.Nil(t, err)
+	assert.Equal(t, 2, added)
+
+	added, err = report.Collect(context.Background(), sqlService)
+	require.Nil(t, err)
+	assert.Equal(t, 0, added)
+	assert.Equal(t, 2000.0, report.Total().BytesRead)
+}
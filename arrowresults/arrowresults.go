@@ -0,0 +1,202 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package arrowresults : Reading the results of SQL jobs as Apache Arrow record batches.
+//
+// A RecordReader implements array.RecordReader over the result objects of a job, so the results can be
+// processed with columnar compute without converting them row by row. The schema of the records is:
+//
+//   - for Parquet results, the schema of the first result object, mapped to Arrow types;
+//   - for CSV and JSON results, the schema of Options.Columns if set, for example the ColumnInformation of a
+//     catalog table returned by GetTable;
+//   - otherwise, strings for CSV results, and the types of the values of the first batch for JSON results.
+//
+// The partition columns of the results written PARTITIONED BY are string columns that follow the columns
+// of the data.
+//
+// ExportResults writes the results to a local file in any export format, Parquet included: ExportParquet
+// writes the records to a Parquet file, keeping the types of their columns. GetResultSchema returns the
+// columns of the results with their SQL types.
+package arrowresults
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+)
+
+// DefaultBatchRows is the number of rows of the records when none is configured.
+const DefaultBatchRows = 4096
+
+// Options : The NewRecordReader options.
+type Options struct {
+	// The format of the results, one of the results.Format_ constants. Defaults to the ResultsetFormat of
+	// the job.
+	Format string
+
+	// The columns of CSV and JSON results, for example the columns of the catalog table they were read
+	// from. Ignored for Parquet results.
+	Columns []sqlv2.ColumnInformation
+
+	// The maximum number of rows of the records. Defaults to DefaultBatchRows.
+	BatchRows int
+
+	// The size of the ranged GET requests of CSV and JSON results. Defaults to results.DefaultChunkSize.
+	ChunkSize int64
+
+	// The allocator of the records. Defaults to memory.NewGoAllocator().
+	Allocator memory.Allocator
+}
+
+// source appends the rows of the results to the builders of the records.
+type source interface {
+	// next appends at most "rows" rows and returns how many, or io.EOF after the last row.
+	next(builder *array.RecordBuilder, rows int) (int, error)
+
+	close()
+}
+
+// RecordReader reads the results of a job as records. It isn't safe for concurrent use.
+type RecordReader struct {
+	refCount  int64
+	schema    *arrow.Schema
+	source    source
+	builder   *array.RecordBuilder
+	batchRows int
+	record    array.Record
+	err       error
+}
+
+var _ array.RecordReader = (*RecordReader)(nil)
+
+// NewRecordReader returns a RecordReader of the results of "job", which must have completed. For Parquet
+// results, the footer of the first result object is read to learn the schema; for CSV and JSON results
+// without Options.Columns, the first batch is read.
+func NewRecordReader(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, options *Options) (*RecordReader, error) {
+	err := core.ValidateNotNil(storage, "storage cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(job, "job cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &Options{}
+	}
+	if job.ResultsetLocation == nil {
+		return nil, fmt.Errorf("the job has no resultset location")
+	}
+
+	reader := &RecordReader{refCount: 1, batchRows: options.BatchRows}
+	if reader.batchRows <= 0 {
+		reader.batchRows = DefaultBatchRows
+	}
+	format := strings.ToLower(options.Format)
+	if format == "" && job.ResultsetFormat != nil {
+		format = strings.ToLower(*job.ResultsetFormat)
+	}
+	if format == results.Format_Parquet {
+		reader.schema, reader.source, err = newParquetSource(ctx, storage, *job.ResultsetLocation)
+	} else {
+		reader.schema, reader.source, err = newRowSource(ctx, storage, job, format, options, reader.batchRows)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	allocator := options.Allocator
+	if allocator == nil {
+		allocator = memory.NewGoAllocator()
+	}
+	reader.builder = array.NewRecordBuilder(allocator, reader.schema)
+	return reader, nil
+}
+
+// Retain increases the reference count of the reader.
+func (reader *RecordReader) Retain() {
+	atomic.AddInt64(&reader.refCount, 1)
+}
+
+// Release decreases the reference count of the reader, and releases its resources when it reaches 0.
+func (reader *RecordReader) Release() {
+	if atomic.AddInt64(&reader.refCount, -1) == 0 {
+		reader.Close()
+	}
+}
+
+// Schema returns the schema of the records.
+func (reader *RecordReader) Schema() *arrow.Schema {
+	return reader.schema
+}
+
+// Next reads the next record, and returns false after the last record or on an error, see Err. The
+// previous record is released.
+func (reader *RecordReader) Next() bool {
+	if reader.record != nil {
+		reader.record.Release()
+		reader.record = nil
+	}
+	if reader.err != nil || reader.source == nil {
+		return false
+	}
+	rows, err := reader.source.next(reader.builder, reader.batchRows)
+	if err != nil && err != io.EOF {
+		reader.err = err
+		// Discard the rows of the failed batch.
+		reader.builder.NewRecord().Release()
+		return false
+	}
+	if rows == 0 {
+		return false
+	}
+	reader.record = reader.builder.NewRecord()
+	return true
+}
+
+// Record returns the current record. It is only valid until the next call of Next, unless retained.
+func (reader *RecordReader) Record() array.Record {
+	return reader.record
+}
+
+// Err returns the error that ended the reading, if any.
+func (reader *RecordReader) Err() error {
+	return reader.err
+}
+
+// Close releases the current record and the connections of the reader.
+func (reader *RecordReader) Close() error {
+	if reader.record != nil {
+		reader.record.Release()
+		reader.record = nil
+	}
+	if reader.source != nil {
+		reader.source.close()
+		reader.source = nil
+		reader.builder.Release()
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/compress"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T) (*costest.Server, *cos.Client) {
+	server := costest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := cos.NewClient(&cos.Options{Authenticator: &core.NoAuthAuthenticator{}, EndpointURL: server.URL})
+	require.Nil(t, err)
+	return server, client
+}
+
+func newJob(format string) *sqlv2.SqlJobInfoFull {
+	return &sqlv2.SqlJobInfoFull{
+		JobID:             core.StringPtr("job1"),
+		ResultsetLocation: core.StringPtr("cos://us-geo/bucket/results/jobid=job1"),
+		ResultsetFormat:   core.StringPtr(format),
+	}
+}
+
+// parquetData returns a Parquet file of string columns, in row groups of 2 rows.
+func parquetData(t *testing.T, columns []string, rows [][]*string) []byte {
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = arrow.Field{Name: column, Type: arrow.BinaryTypes.String, Nullable: true}
+	}
+	schema := arrow.NewSchema(fields, nil)
+	var buffer bytes.Buffer
+	writer, err := pqarrow.NewFileWriter(schema, &buffer,
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy), parquet.WithMaxRowGroupLength(2)),
+		pqarrow.DefaultWriterProps())
+	require.Nil(t, err)
+	builder := array.NewRecordBuilder(memory.NewGoAllocator(), schema)
+	defer builder.Release()
+	for _, row := range rows {
+		for i, value := range row {
+			if value == nil {
+				builder.Field(i).AppendNull()
+			} else {
+				builder.Field(i).(*array.StringBuilder).Append(*value)
+			}
+		}
+	}
+	record := builder.NewRecord()
+	defer record.Release()
+	if len(rows) > 0 {
+		require.Nil(t, writer.Write(record))
+	}
+	require.Nil(t, writer.Close())
+	return buffer.Bytes()
+}
+
+// readRecords returns the number of rows of each record, and the values of each column as strings, with
+// "null" for the nulls.
+func readRecords(t *testing.T, reader *RecordReader) ([]int64, [][]string) {
+	var sizes []int64
+	values := make([][]string, len(reader.Schema().Fields()))
+	for reader.Next() {
+		record := reader.Record()
+		sizes = append(sizes, record.NumRows())
+		for i, column := range record.Columns() {
+			for j := 0; j < column.Len(); j++ {
+				values[i] = append(values[i], valueString(column, j))
+			}
+		}
+	}
+	require.Nil(t, reader.Err())
+	return sizes, values
+}
+
+func valueString(column array.Interface, i int) string {
+	if column.IsNull(i) {
+		return "null"
+	}
+	switch column := column.(type) {
+	case *array.String:
+		return column.Value(i)
+	case *array.Int32:
+		return strconv.FormatInt(int64(column.Value(i)), 10)
+	case *array.Int64:
+		return strconv.FormatInt(column.Value(i), 10)
+	case *array.Float64:
+		return strconv.FormatFloat(column.Value(i), 'g', -1, 64)
+	case *array.Boolean:
+		return strconv.FormatBool(column.Value(i))
+	case *array.Date32:
+		return time.Unix(int64(column.Value(i))*24*60*60, 0).UTC().Format("2006-01-02")
+	case *array.Timestamp:
+		return time.Unix(0, int64(column.Value(i))*1000).UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	case *array.Decimal128:
+		return column.Value(i).BigInt().String()
+	}
+	return column.DataType().Name()
+}
+
+func TestReadCSV(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.csv", []byte("id,amount,day,at,name\n1,12.345,2022-01-31,2022-01-31 10:00:00.5,alice\n2,,,,\n"))
+	server.PutObject("bucket", "results/jobid=job1/part-00001.csv", []byte("id,name,amount\n3,carol,-0.005\n"))
+
+	reader, err := NewRecordReader(context.Background(), storage, newJob("csv"), &Options{BatchRows: 2})
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, []string{"id", "amount", "day", "at", "name"}, fieldNames(reader.Schema()))
+	for _, field := range reader.Schema().Fields() {
+		assert.Equal(t, arrow.BinaryTypes.String, field.Type)
+	}
+	sizes, values := readRecords(t, reader)
+	assert.Equal(t, []int64{2, 1}, sizes)
+	assert.Equal(t, []string{"1", "2", "3"}, values[0])
+	assert.Equal(t, []string{"12.345", "", "-0.005"}, values[1])
+	assert.Equal(t, []string{"alice", "", "carol"}, values[4])
+	assert.False(t, reader.Next())
+
+	// With the columns of the catalog table, the values are typed.
+	columns := []sqlv2.ColumnInformation{
+		{Name: core.StringPtr("id"), Type: core.StringPtr("int"), Nullable: core.BoolPtr(false)},
+		{Name: core.StringPtr("amount"), Type: core.StringPtr("decimal(10,2)")},
+		{Name: core.StringPtr("day"), Type: core.StringPtr("date")},
+		{Name: core.StringPtr("at"), Type: core.StringPtr("timestamp")},
+		{Name: core.StringPtr("name"), Type: core.StringPtr("string")},
+	}
+	reader, err = NewRecordReader(context.Background(), storage, newJob("csv"), &Options{Columns: columns, Allocator: memory.NewGoAllocator()})
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, arrow.PrimitiveTypes.Int32, reader.Schema().Field(0).Type)
+	assert.False(t, reader.Schema().Field(0).Nullable)
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 10, Scale: 2}, reader.Schema().Field(1).Type)
+	sizes, values = readRecords(t, reader)
+	assert.Equal(t, []int64{3}, sizes)
+	assert.Equal(t, []string{"1", "2", "3"}, values[0])
+	assert.Equal(t, []string{"1235", "null", "-1"}, values[1])
+	assert.Equal(t, []string{"2022-01-31", "null", "null"}, values[2])
+	assert.Equal(t, []string{"2022-01-31T10:00:00.500000Z", "null", "null"}, values[3])
+	assert.Equal(t, []string{"alice", "", "carol"}, values[4])
+}
+
+func TestReadJSON(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.json", []byte(
+		`{"id":1,"score":1,"ok":true,"tags":["a"]}`+"\n"+`{"id":2,"score":0.5,"ok":null}`+"\n"))
+
+	reader, err := NewRecordReader(context.Background(), storage, newJob("json"), nil)
+	require.Nil(t, err)
+	defer reader.Release()
+	schema := reader.Schema()
+	assert.Equal(t, []string{"id", "score", "ok", "tags"}, fieldNames(schema))
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, schema.Field(0).Type)
+	assert.Equal(t, arrow.PrimitiveTypes.Float64, schema.Field(1).Type)
+	assert.Equal(t, arrow.FixedWidthTypes.Boolean, schema.Field(2).Type)
+	assert.Equal(t, arrow.BinaryTypes.String, schema.Field(3).Type)
+	_, values := readRecords(t, reader)
+	assert.Equal(t, []string{"1", "2"}, values[0])
+	assert.Equal(t, []string{"1", "0.5"}, values[1])
+	assert.Equal(t, []string{"true", "null"}, values[2])
+	assert.Equal(t, []string{`["a"]`, "null"}, values[3])
+}
+
+func TestReadParquet(t *testing.T) {
+	server, storage := newStorage(t)
+	alice, bob := "alice", "bob"
+	one, two, three := "1", "2", "3"
+	server.PutObject("bucket", "results/jobid=job1/country=DE/part-00000.snappy.parquet",
+		parquetData(t, []string{"id", "name"}, [][]*string{{&one, &alice}, {&two, nil}, {&three, &bob}}))
+	server.PutObject("bucket", "results/jobid=job1/country=__HIVE_DEFAULT_PARTITION__/part-00001.snappy.parquet",
+		parquetData(t, []string{"name"}, [][]*string{{&alice}}))
+
+	reader, err := NewRecordReader(context.Background(), storage, newJob("parquet"), &Options{BatchRows: 3})
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, []string{"id", "name", "country"}, fieldNames(reader.Schema()))
+	sizes, values := readRecords(t, reader)
+	assert.Equal(t, []int64{3, 1}, sizes)
+	assert.Equal(t, []string{"1", "2", "3", "null"}, values[0])
+	assert.Equal(t, []string{"alice", "null", "bob", "alice"}, values[1])
+	assert.Equal(t, []string{"DE", "DE", "DE", "null"}, values[2])
+
+	// A file without row groups has no records.
+	server.PutObject("bucket", "results/jobid=job2/part-00000.parquet", parquetData(t, []string{"id"}, nil))
+	job := newJob("parquet")
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	reader, err = NewRecordReader(context.Background(), storage, job, nil)
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, []string{"id"}, fieldNames(reader.Schema()))
+	sizes, _ = readRecords(t, reader)
+	assert.Empty(t, sizes)
+}
+
+func TestRecordReaderErrors(t *testing.T) {
+	server, storage := newStorage(t)
+
+	_, err := NewRecordReader(context.Background(), nil, newJob("csv"), nil)
+	assert.NotNil(t, err)
+	_, err = NewRecordReader(context.Background(), storage, &sqlv2.SqlJobInfoFull{}, nil)
+	assert.NotNil(t, err)
+	_, err = NewRecordReader(context.Background(), storage, newJob("csv"), &Options{Columns: []sqlv2.ColumnInformation{{Type: core.StringPtr("int")}}})
+	assert.NotNil(t, err)
+
+	server.PutObject("bucket", "results/jobid=job1/part-00000.csv", []byte("id\n1\nx\n"))
+	reader, err := NewRecordReader(context.Background(), storage, newJob("csv"), &Options{
+		Columns: []sqlv2.ColumnInformation{{Name: core.StringPtr("id"), Type: core.StringPtr("bigint")}},
+	})
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.False(t, reader.Next())
+	assert.Contains(t, reader.Err().Error(), "column id")
+
+	server.PutObject("bucket", "results/jobid=job2/part-00000.parquet", []byte("PAR1 not parquet"))
+	job := newJob("parquet")
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	_, err = NewRecordReader(context.Background(), storage, job, nil)
+	assert.NotNil(t, err)
+}
+
+func fieldNames(schema *arrow.Schema) []string {
+	var names []string
+	for _, field := range schema.Fields() {
+		names = append(names, field.Name)
+	}
+	return names
+}
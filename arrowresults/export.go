@@ -0,0 +1,226 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/compress"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+)
+
+// ExportFormat_Parquet is the format of the Parquet files written by ExportResults, along with the
+// results.ExportFormat_ formats.
+const ExportFormat_Parquet = "parquet"
+
+// DefaultRowGroupRows is the number of rows of the row groups written by ExportParquet when none is
+// configured.
+const DefaultRowGroupRows = 100000
+
+// ExportOptions : The ExportParquet options.
+type ExportOptions struct {
+	// The format of the results, one of the results.Format_ constants. Defaults to the ResultsetFormat of
+	// the job.
+	Format string
+
+	// The columns of CSV and JSON results with their SQL types, see Options.Columns. Defaults to the columns
+	// returned by results.GetResultSchema, whose types are inferred from the first rows: a later value that
+	// doesn't have the type of its column fails the export. Ignored for Parquet results.
+	ResultColumns []sqlv2.ColumnInformation
+
+	// The columns to export, in this order, including partition columns. The columns missing from the
+	// results are exported as null strings. All of them if empty.
+	Columns []string
+
+	// The maximum number of rows to export. All of them if 0.
+	Limit int64
+
+	// The number of rows of the row groups, which are buffered in memory. Defaults to DefaultRowGroupRows.
+	RowGroupRows int64
+
+	// The size of the ranged GET requests of CSV and JSON results. Defaults to results.DefaultChunkSize.
+	ChunkSize int64
+
+	// If set, called with the number of rows exported and the number of rows expected, RowsReturned
+	// bounded by Limit or 0 if unknown, every ProgressRows rows and once the export is complete.
+	Progress func(rows int64, total int64)
+
+	// The number of rows between two calls of Progress. Defaults to results.DefaultProgressRows.
+	ProgressRows int64
+}
+
+// ExportResults writes the results of "job", which must have completed, to "dst" in "format": Parquet with
+// ExportParquet, or one of the results.ExportFormat_ formats with results.ExportResults. It returns the
+// number of rows written. Options.Concurrency is ignored for Parquet.
+func ExportResults(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, dst io.Writer, format string, options *results.ExportOptions) (int64, error) {
+	if strings.ToLower(format) != ExportFormat_Parquet {
+		return results.ExportResults(ctx, storage, job, dst, format, options)
+	}
+	if options == nil {
+		options = &results.ExportOptions{}
+	}
+	return ExportParquet(ctx, storage, job, dst, &ExportOptions{
+		Format:       options.Format,
+		Columns:      options.Columns,
+		Limit:        options.Limit,
+		ChunkSize:    options.ChunkSize,
+		Progress:     options.Progress,
+		ProgressRows: options.ProgressRows,
+	})
+}
+
+// ExportParquet writes the results of "job", which must have completed, to "dst" as a snappy-compressed
+// Parquet file, in the order of the result objects. The columns have the Arrow types of the records of a
+// RecordReader, see the package documentation. It returns the number of rows written.
+func ExportParquet(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, dst io.Writer, options *ExportOptions) (int64, error) {
+	err := core.ValidateNotNil(job, "job cannot be nil")
+	if err != nil {
+		return 0, err
+	}
+	if options == nil {
+		options = &ExportOptions{}
+	}
+	format := strings.ToLower(options.Format)
+	if format == "" && job.ResultsetFormat != nil {
+		format = strings.ToLower(*job.ResultsetFormat)
+	}
+	columns := options.ResultColumns
+	if len(columns) == 0 && format != results.Format_Parquet {
+		columns, err = results.GetResultSchema(ctx, storage, job, &results.SchemaOptions{Format: format, ChunkSize: options.ChunkSize})
+		if err != nil {
+			return 0, err
+		}
+	}
+	reader, err := NewRecordReader(ctx, storage, job, &Options{Format: format, Columns: columns, ChunkSize: options.ChunkSize})
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Release()
+
+	projection := newProjection(reader.Schema(), options.Columns)
+	rowGroupRows := options.RowGroupRows
+	if rowGroupRows <= 0 {
+		rowGroupRows = DefaultRowGroupRows
+	}
+	writer, err := pqarrow.NewFileWriter(projection.schema, dst,
+		parquet.NewWriterProperties(parquet.WithCompression(compress.Codecs.Snappy), parquet.WithMaxRowGroupLength(rowGroupRows)),
+		pqarrow.DefaultWriterProps())
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	if job.RowsReturned != nil {
+		total = int64(*job.RowsReturned)
+	}
+	if options.Limit > 0 && (total == 0 || total > options.Limit) {
+		total = options.Limit
+	}
+	progressRows := options.ProgressRows
+	if progressRows <= 0 {
+		progressRows = results.DefaultProgressRows
+	}
+	var rows int64
+	for (options.Limit <= 0 || rows < options.Limit) && reader.Next() {
+		record := projection.record(reader.Record())
+		if options.Limit > 0 && rows+record.NumRows() > options.Limit {
+			sliced := record.NewSlice(0, options.Limit-rows)
+			record.Release()
+			record = sliced
+		}
+		previous := rows
+		rows += record.NumRows()
+		err = writer.WriteBuffered(record)
+		record.Release()
+		if err != nil {
+			return previous, err
+		}
+		if options.Progress != nil && rows/progressRows > previous/progressRows {
+			options.Progress(rows, total)
+		}
+	}
+	if err = reader.Err(); err != nil {
+		writer.Close()
+		return rows, err
+	}
+	if err = writer.Close(); err != nil {
+		return rows, err
+	}
+	if options.Progress != nil {
+		options.Progress(rows, total)
+	}
+	return rows, nil
+}
+
+// projection selects the exported columns of the records.
+type projection struct {
+	schema *arrow.Schema
+
+	// The index of each exported column in the records, or -1. Nil when every column is exported.
+	indexes []int
+}
+
+func newProjection(schema *arrow.Schema, columns []string) *projection {
+	if len(columns) == 0 {
+		return &projection{schema: schema}
+	}
+	projection := &projection{indexes: make([]int, len(columns))}
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		projection.indexes[i] = -1
+		fields[i] = arrow.Field{Name: column, Type: arrow.BinaryTypes.String, Nullable: true}
+		if indexes := schema.FieldIndices(column); len(indexes) > 0 {
+			projection.indexes[i] = indexes[0]
+			fields[i] = schema.Field(indexes[0])
+		}
+	}
+	projection.schema = arrow.NewSchema(fields, nil)
+	return projection
+}
+
+// record returns the exported columns of "record", which must be released.
+func (projection *projection) record(record array.Record) array.Record {
+	if projection.indexes == nil {
+		record.Retain()
+		return record
+	}
+	columns := make([]array.Interface, len(projection.indexes))
+	for i, index := range projection.indexes {
+		if index >= 0 {
+			columns[i] = record.Column(index)
+			continue
+		}
+		builder := array.NewStringBuilder(memory.DefaultAllocator)
+		for j := int64(0); j < record.NumRows(); j++ {
+			builder.AppendNull()
+		}
+		columns[i] = builder.NewArray()
+		builder.Release()
+		defer columns[i].Release()
+	}
+	return array.NewRecord(projection.schema, columns, record.NumRows())
+}
@@ -0,0 +1,118 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportParquet(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/country=DE/part-00000.csv", []byte("id,amount,day\n1,1.5,2022-01-31\n2,,2022-02-01\n"))
+	server.PutObject("bucket", "results/jobid=job1/country=FR/part-00001.csv", []byte("id,amount,day\n3,2,\n"))
+	job := newJob("csv")
+	job.RowsReturned = core.Float64Ptr(3)
+
+	var file bytes.Buffer
+	var progress [][2]int64
+	rows, err := ExportParquet(context.Background(), storage, job, &file, &ExportOptions{
+		Columns:      []string{"country", "id", "missing", "day"},
+		Limit:        2,
+		RowGroupRows: 1,
+		Progress:     func(rows int64, total int64) { progress = append(progress, [2]int64{rows, total}) },
+		ProgressRows: 1,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), rows)
+	assert.Equal(t, [][2]int64{{2, 2}, {2, 2}}, progress)
+
+	// The file is read back with the types inferred from the CSV results.
+	server.PutObject("bucket", "results/jobid=job2/part-00000.parquet", file.Bytes())
+	job = newJob("parquet")
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	reader, err := NewRecordReader(context.Background(), storage, job, nil)
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, []string{"country", "id", "missing", "day"}, fieldNames(reader.Schema()))
+	var types []arrow.Type
+	for _, field := range reader.Schema().Fields() {
+		types = append(types, field.Type.ID())
+	}
+	assert.Equal(t, []arrow.Type{arrow.STRING, arrow.INT64, arrow.STRING, arrow.DATE32}, types)
+	_, values := readRecords(t, reader)
+	assert.Equal(t, [][]string{{"DE", "DE"}, {"1", "2"}, {"null", "null"}, {"2022-01-31", "2022-02-01"}}, values)
+
+	// The given columns of the results override the inferred ones.
+	file.Reset()
+	rows, err = ExportParquet(context.Background(), storage, newJob("csv"), &file, &ExportOptions{
+		ResultColumns: []sqlv2.ColumnInformation{
+			{Name: core.StringPtr("id"), Type: core.StringPtr("int")},
+			{Name: core.StringPtr("amount"), Type: core.StringPtr("decimal(10,2)")},
+			{Name: core.StringPtr("day"), Type: core.StringPtr("string")},
+		},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), rows)
+	server.PutObject("bucket", "results/jobid=job2/part-00000.parquet", file.Bytes())
+	reader, err = NewRecordReader(context.Background(), storage, job, nil)
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 10, Scale: 2}, reader.Schema().Field(1).Type)
+	_, values = readRecords(t, reader)
+	assert.Equal(t, []string{"150", "null", "200"}, values[1])
+	assert.Equal(t, []string{"2022-01-31", "2022-02-01", ""}, values[2])
+	assert.Equal(t, []string{"DE", "DE", "FR"}, values[3])
+
+	_, err = ExportParquet(context.Background(), storage, nil, &file, nil)
+	assert.NotNil(t, err)
+}
+
+func TestExportResults(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.csv", []byte("id,name\n1,alice\n2,bob\n"))
+
+	var file bytes.Buffer
+	rows, err := ExportResults(context.Background(), storage, newJob("csv"), &file, results.ExportFormat_JSONLines, &results.ExportOptions{Limit: 1})
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), rows)
+	assert.Equal(t, "{\"id\":\"1\",\"name\":\"alice\"}\n", file.String())
+
+	file.Reset()
+	rows, err = ExportResults(context.Background(), storage, newJob("csv"), &file, "PARQUET", &results.ExportOptions{Columns: []string{"id"}})
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), rows)
+	server.PutObject("bucket", "results/jobid=job2/part-00000.parquet", file.Bytes())
+	job := newJob("parquet")
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	reader, err := NewRecordReader(context.Background(), storage, job, nil)
+	require.Nil(t, err)
+	defer reader.Release()
+	assert.Equal(t, []string{"id"}, fieldNames(reader.Schema()))
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, reader.Schema().Field(0).Type)
+
+	_, err = ExportResults(context.Background(), storage, newJob("csv"), &file, "xlsx", nil)
+	assert.NotNil(t, err)
+}
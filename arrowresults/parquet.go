@@ -0,0 +1,399 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/decimal128"
+	"github.com/apache/arrow/go/v7/arrow/memory"
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/file"
+)
+
+// parquetSource reads Parquet results, one row group at a time.
+type parquetSource struct {
+	ctx     context.Context
+	storage *cos.Client
+	parts   []results.Part
+	fields  []arrow.Field
+
+	// The number of fields read from the data: the others are partition columns.
+	dataFields int
+
+	// The part being read, its file, and the row group being read with its column readers, by field.
+	partIndex int
+	file      *file.Reader
+	rowGroup  int
+	columns   []file.ColumnChunkReader
+	remaining int64
+}
+
+// newParquetSource returns the source of Parquet results. The schema is the schema of the first part.
+func newParquetSource(ctx context.Context, storage *cos.Client, resultsetLocation string) (*arrow.Schema, source, error) {
+	parts, err := results.ListParts(ctx, storage, resultsetLocation)
+	if err != nil {
+		return nil, nil, err
+	}
+	source := &parquetSource{ctx: ctx, storage: storage, parts: parts, partIndex: -1}
+	if len(parts) == 0 {
+		return arrow.NewSchema(nil, nil), source, nil
+	}
+
+	if err = source.open(0); err != nil {
+		return nil, nil, err
+	}
+	fileSchema := source.file.MetaData().Schema
+	for i := 0; i < fileSchema.NumColumns(); i++ {
+		column := fileSchema.Column(i)
+		dataType, err := parquetType(column)
+		if err != nil {
+			source.close()
+			return nil, nil, source.partError(err)
+		}
+		source.fields = append(source.fields, arrow.Field{Name: column.Name(), Type: dataType, Nullable: column.MaxDefinitionLevel() > 0})
+	}
+	source.dataFields = len(source.fields)
+	for _, partitionValue := range parts[0].Partition {
+		source.fields = append(source.fields, arrow.Field{Name: partitionValue.Column, Type: arrow.BinaryTypes.String, Nullable: true})
+	}
+	return arrow.NewSchema(source.fields, nil), source, nil
+}
+
+// open opens the part at "index".
+func (source *parquetSource) open(index int) error {
+	source.closeFile()
+	source.partIndex = index
+	part := &source.parts[index]
+	reader, err := file.NewParquetReader(source.storage.NewObjectReader(source.ctx, part.Location, part.Size))
+	if err != nil {
+		return source.partError(err)
+	}
+	source.file = reader
+	source.rowGroup = -1
+	source.remaining = 0
+	return nil
+}
+
+// nextRowGroup starts reading the next row group of the file, and returns false if there are none.
+func (source *parquetSource) nextRowGroup() (bool, error) {
+	source.rowGroup++
+	if source.rowGroup >= source.file.NumRowGroups() {
+		return false, nil
+	}
+
+	rowGroup := source.file.RowGroup(source.rowGroup)
+	fileSchema := source.file.MetaData().Schema
+	source.columns = make([]file.ColumnChunkReader, source.dataFields)
+	for i := 0; i < source.dataFields; i++ {
+		index := fileSchema.ColumnIndexByName(source.fields[i].Name)
+		if index < 0 {
+			continue
+		}
+		// The page readers are created here rather than with rowGroup.Column, which panics on their errors.
+		if index >= rowGroup.NumColumns() {
+			return false, source.partError(fmt.Errorf("row group %d has %d columns", source.rowGroup, rowGroup.NumColumns()))
+		}
+		pageReader, err := rowGroup.GetColumnPageReader(index)
+		if err != nil {
+			return false, source.partError(err)
+		}
+		source.columns[i] = file.NewColumnReader(fileSchema.Column(index), pageReader, memory.DefaultAllocator)
+	}
+	source.remaining = rowGroup.NumRows()
+	return true, nil
+}
+
+func (source *parquetSource) next(builder *array.RecordBuilder, count int) (int, error) {
+	n := 0
+	for n < count {
+		if source.file == nil || source.remaining == 0 {
+			if source.file != nil {
+				ok, err := source.nextRowGroup()
+				if err != nil {
+					return n, err
+				}
+				if ok {
+					continue
+				}
+			}
+			if source.partIndex+1 >= len(source.parts) {
+				source.closeFile()
+				if n == 0 {
+					return 0, io.EOF
+				}
+				return n, nil
+			}
+			if err := source.open(source.partIndex + 1); err != nil {
+				return n, err
+			}
+			continue
+		}
+
+		rows := int64(count - n)
+		if rows > source.remaining {
+			rows = source.remaining
+		}
+		for i, column := range source.columns {
+			if column == nil {
+				for j := int64(0); j < rows; j++ {
+					builder.Field(i).AppendNull()
+				}
+				continue
+			}
+			if err := readColumn(column, builder.Field(i), rows); err != nil {
+				return n, source.partError(fmt.Errorf("column %s: %w", source.fields[i].Name, err))
+			}
+		}
+		source.appendPartition(builder, rows)
+		source.remaining -= rows
+		n += int(rows)
+	}
+	return n, nil
+}
+
+// appendPartition appends the partition values of the part to "rows" rows.
+func (source *parquetSource) appendPartition(builder *array.RecordBuilder, rows int64) {
+	partition := source.parts[source.partIndex].Partition
+	for i := source.dataFields; i < len(source.fields); i++ {
+		var value *string
+		for _, partitionValue := range partition {
+			if partitionValue.Column == source.fields[i].Name {
+				value = partitionValue.Value
+			}
+		}
+		fieldBuilder := builder.Field(i).(*array.StringBuilder)
+		for j := int64(0); j < rows; j++ {
+			if value == nil {
+				fieldBuilder.AppendNull()
+			} else {
+				fieldBuilder.Append(*value)
+			}
+		}
+	}
+}
+
+func (source *parquetSource) partError(err error) error {
+	return fmt.Errorf("%s: %w", source.parts[source.partIndex].Location.String(), err)
+}
+
+func (source *parquetSource) closeFile() {
+	if source.file != nil {
+		source.file.Close()
+		source.file = nil
+		source.columns = nil
+	}
+}
+
+func (source *parquetSource) close() {
+	source.closeFile()
+}
+
+// readColumn reads "rows" values of a column chunk and appends them to "builder".
+func readColumn(column file.ColumnChunkReader, builder array.Builder, rows int64) error {
+	levels := make([]int16, rows)
+	var values interface{}
+	var read func(offset int64, valueOffset int) (int64, int, error)
+	switch column := column.(type) {
+	case *file.BooleanColumnChunkReader:
+		typed := make([]bool, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.Int32ColumnChunkReader:
+		typed := make([]int32, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.Int64ColumnChunkReader:
+		typed := make([]int64, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.Int96ColumnChunkReader:
+		typed := make([]parquet.Int96, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.Float32ColumnChunkReader:
+		typed := make([]float32, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.Float64ColumnChunkReader:
+		typed := make([]float64, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.ByteArrayColumnChunkReader:
+		typed := make([]parquet.ByteArray, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	case *file.FixedLenByteArrayColumnChunkReader:
+		typed := make([]parquet.FixedLenByteArray, rows)
+		values = typed
+		read = func(offset int64, valueOffset int) (int64, int, error) {
+			return column.ReadBatch(rows-offset, typed[valueOffset:], levels[offset:], nil)
+		}
+	default:
+		return fmt.Errorf("unsupported column reader %T", column)
+	}
+
+	var offset int64
+	valueCount := 0
+	for offset < rows {
+		total, valuesRead, err := read(offset, valueCount)
+		if err != nil {
+			return err
+		}
+		if total == 0 {
+			return fmt.Errorf("%d values instead of %d", offset, rows)
+		}
+		offset += total
+		valueCount += valuesRead
+	}
+
+	maxLevel := column.Descriptor().MaxDefinitionLevel()
+	valueIndex := 0
+	for _, level := range levels {
+		if level < maxLevel {
+			builder.AppendNull()
+			continue
+		}
+		if err := appendParquetValue(builder, values, valueIndex); err != nil {
+			return err
+		}
+		valueIndex++
+	}
+	return nil
+}
+
+// appendParquetValue appends the value at "index" of the decoded "values" of a column.
+func appendParquetValue(builder array.Builder, values interface{}, index int) error {
+	switch values := values.(type) {
+	case []bool:
+		if builder, ok := builder.(*array.BooleanBuilder); ok {
+			builder.Append(values[index])
+			return nil
+		}
+	case []int32:
+		value := values[index]
+		switch builder := builder.(type) {
+		case *array.Int8Builder:
+			builder.Append(int8(value))
+			return nil
+		case *array.Int16Builder:
+			builder.Append(int16(value))
+			return nil
+		case *array.Int32Builder:
+			builder.Append(value)
+			return nil
+		case *array.Uint8Builder:
+			builder.Append(uint8(value))
+			return nil
+		case *array.Uint16Builder:
+			builder.Append(uint16(value))
+			return nil
+		case *array.Uint32Builder:
+			builder.Append(uint32(value))
+			return nil
+		case *array.Date32Builder:
+			builder.Append(arrow.Date32(value))
+			return nil
+		case *array.Decimal128Builder:
+			builder.Append(decimal128.FromI64(int64(value)))
+			return nil
+		}
+	case []int64:
+		value := values[index]
+		switch builder := builder.(type) {
+		case *array.Int64Builder:
+			builder.Append(value)
+			return nil
+		case *array.Uint64Builder:
+			builder.Append(uint64(value))
+			return nil
+		case *array.TimestampBuilder:
+			builder.Append(arrow.Timestamp(value))
+			return nil
+		case *array.Decimal128Builder:
+			builder.Append(decimal128.FromI64(value))
+			return nil
+		}
+	case []parquet.Int96:
+		if builder, ok := builder.(*array.TimestampBuilder); ok {
+			builder.Append(arrow.Timestamp(values[index].ToTime().UnixNano()))
+			return nil
+		}
+	case []float32:
+		if builder, ok := builder.(*array.Float32Builder); ok {
+			builder.Append(values[index])
+			return nil
+		}
+	case []float64:
+		if builder, ok := builder.(*array.Float64Builder); ok {
+			builder.Append(values[index])
+			return nil
+		}
+	case []parquet.ByteArray:
+		switch builder := builder.(type) {
+		case *array.StringBuilder:
+			builder.Append(string(values[index]))
+			return nil
+		case *array.BinaryBuilder:
+			builder.Append(values[index])
+			return nil
+		case *array.Decimal128Builder:
+			builder.Append(decimalFromBytes(values[index]))
+			return nil
+		}
+	case []parquet.FixedLenByteArray:
+		switch builder := builder.(type) {
+		case *array.FixedSizeBinaryBuilder:
+			builder.Append(values[index])
+			return nil
+		case *array.Decimal128Builder:
+			builder.Append(decimalFromBytes(values[index]))
+			return nil
+		}
+	}
+	return fmt.Errorf("the type of the column differs from the first result object")
+}
+
+// decimalFromBytes decodes the big-endian two's complement unscaled value of a decimal.
+func decimalFromBytes(data []byte) decimal128.Num {
+	value := new(big.Int).SetBytes(data)
+	if len(data) > 0 && data[0]&0x80 != 0 {
+		value.Sub(value, new(big.Int).Lsh(big.NewInt(1), uint(len(data)*8)))
+	}
+	return decimal128.FromBigInt(value)
+}
@@ -0,0 +1,302 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/arrow/array"
+	"github.com/apache/arrow/go/v7/arrow/decimal128"
+)
+
+// timestampLayouts are the layouts of the timestamps of CSV and JSON results.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// rowSource reads CSV and JSON results with a results.Reader.
+type rowSource struct {
+	reader *results.Reader
+	fields []arrow.Field
+
+	// The first rows, read to infer the schema.
+	pending [][]interface{}
+
+	// The index in the values of the rows of each field, or -1, for the columns of the reader when mapped.
+	mapping       []int
+	mappedColumns int
+}
+
+// newRowSource returns the source of CSV or JSON results, "format" being the resolved format.
+func newRowSource(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, format string, options *Options, batchRows int) (*arrow.Schema, source, error) {
+	reader, err := results.NewReader(ctx, storage, job, &results.ReaderOptions{Format: format, ChunkSize: options.ChunkSize})
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := &rowSource{reader: reader}
+
+	if len(options.Columns) > 0 {
+		schema, err := SchemaFromColumns(options.Columns)
+		if err != nil {
+			reader.Close()
+			return nil, nil, err
+		}
+		rows.fields = schema.Fields()
+		if parts := reader.Parts(); len(parts) > 0 {
+			for _, partitionValue := range parts[0].Partition {
+				if !schema.HasField(partitionValue.Column) {
+					rows.fields = append(rows.fields, arrow.Field{Name: partitionValue.Column, Type: arrow.BinaryTypes.String, Nullable: true})
+				}
+			}
+		}
+		return arrow.NewSchema(rows.fields, nil), rows, nil
+	}
+
+	for len(rows.pending) < batchRows {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			reader.Close()
+			return nil, nil, err
+		}
+		rows.pending = append(rows.pending, row)
+	}
+	for i, column := range reader.Columns() {
+		field := arrow.Field{Name: column, Type: arrow.BinaryTypes.String, Nullable: true}
+		if format == results.Format_JSON {
+			values := make([]interface{}, 0, len(rows.pending))
+			for _, row := range rows.pending {
+				if i < len(row) {
+					values = append(values, row[i])
+				}
+			}
+			field.Type = inferType(values)
+		}
+		rows.fields = append(rows.fields, field)
+	}
+	return arrow.NewSchema(rows.fields, nil), rows, nil
+}
+
+func (rows *rowSource) next(builder *array.RecordBuilder, count int) (int, error) {
+	n := 0
+	for n < count {
+		var row []interface{}
+		if len(rows.pending) > 0 {
+			row, rows.pending[0] = rows.pending[0], nil
+			rows.pending = rows.pending[1:]
+		} else {
+			var err error
+			row, err = rows.reader.Next()
+			if err == io.EOF {
+				if n == 0 {
+					return 0, io.EOF
+				}
+				break
+			}
+			if err != nil {
+				return n, err
+			}
+		}
+
+		if columns := rows.reader.Columns(); len(columns) != rows.mappedColumns {
+			rows.mapping = make([]int, len(rows.fields))
+			for i, field := range rows.fields {
+				rows.mapping[i] = -1
+				for j, column := range columns {
+					if column == field.Name {
+						rows.mapping[i] = j
+						break
+					}
+				}
+			}
+			rows.mappedColumns = len(columns)
+		}
+		for i, index := range rows.mapping {
+			var value interface{}
+			if index >= 0 && index < len(row) {
+				value = row[index]
+			}
+			if err := appendValue(builder.Field(i), rows.fields[i].Type, value); err != nil {
+				return n, fmt.Errorf("column %s: %w", rows.fields[i].Name, err)
+			}
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (rows *rowSource) close() {
+	rows.reader.Close()
+}
+
+// appendValue appends a value of CSV or JSON results to a builder of "dataType": a string, a json.Number, a
+// bool, a JSON array or object, or nil. The empty strings of CSV results are nulls, except in string and
+// binary columns.
+func appendValue(builder array.Builder, dataType arrow.DataType, value interface{}) error {
+	if value == nil {
+		builder.AppendNull()
+		return nil
+	}
+	var text string
+	switch value := value.(type) {
+	case string:
+		text = value
+	case json.Number:
+		text = value.String()
+	case bool:
+		if builder, ok := builder.(*array.BooleanBuilder); ok {
+			builder.Append(value)
+			return nil
+		}
+		text = strconv.FormatBool(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		text = string(encoded)
+	}
+
+	switch builder := builder.(type) {
+	case *array.StringBuilder:
+		builder.Append(text)
+		return nil
+	case *array.BinaryBuilder:
+		builder.Append([]byte(text))
+		return nil
+	}
+	if text == "" {
+		builder.AppendNull()
+		return nil
+	}
+
+	switch builder := builder.(type) {
+	case *array.BooleanBuilder:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		builder.Append(parsed)
+	case *array.Int8Builder:
+		parsed, err := strconv.ParseInt(text, 10, 8)
+		if err != nil {
+			return err
+		}
+		builder.Append(int8(parsed))
+	case *array.Int16Builder:
+		parsed, err := strconv.ParseInt(text, 10, 16)
+		if err != nil {
+			return err
+		}
+		builder.Append(int16(parsed))
+	case *array.Int32Builder:
+		parsed, err := strconv.ParseInt(text, 10, 32)
+		if err != nil {
+			return err
+		}
+		builder.Append(int32(parsed))
+	case *array.Int64Builder:
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		builder.Append(parsed)
+	case *array.Float32Builder:
+		parsed, err := strconv.ParseFloat(text, 32)
+		if err != nil {
+			return err
+		}
+		builder.Append(float32(parsed))
+	case *array.Float64Builder:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+		builder.Append(parsed)
+	case *array.Date32Builder:
+		parsed, err := parseTimestamp(text)
+		if err != nil {
+			return err
+		}
+		builder.Append(arrow.Date32(floorDiv(parsed.Unix(), 24*60*60)))
+	case *array.TimestampBuilder:
+		parsed, err := parseTimestamp(text)
+		if err != nil {
+			return err
+		}
+		builder.Append(arrow.Timestamp(parsed.UnixNano() / 1000))
+	case *array.Decimal128Builder:
+		parsed, err := parseDecimal(text, dataType.(*arrow.Decimal128Type).Scale)
+		if err != nil {
+			return err
+		}
+		builder.Append(parsed)
+	default:
+		return fmt.Errorf("unsupported builder %T", builder)
+	}
+	return nil
+}
+
+// parseTimestamp parses a timestamp or a date, in UTC unless it has a time zone.
+func parseTimestamp(text string) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", text)
+}
+
+// parseDecimal parses a decimal number into its unscaled value with "scale", rounding half away from
+// zero.
+func parseDecimal(text string, scale int32) (decimal128.Num, error) {
+	value, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return decimal128.Num{}, fmt.Errorf("invalid decimal %q", text)
+	}
+	value.Mul(value, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	quotient, remainder := new(big.Int).QuoRem(value.Num(), value.Denom(), new(big.Int))
+	if remainder.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(value.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(value.Sign())))
+	}
+	return decimal128.FromBigInt(quotient), nil
+}
+
+// floorDiv divides rounding towards negative infinity, so that dates before 1970 are right.
+func floorDiv(a int64, b int64) int64 {
+	quotient := a / b
+	if a%b != 0 && a < 0 {
+		quotient--
+	}
+	return quotient
+}
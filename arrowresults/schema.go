@@ -0,0 +1,285 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/schema"
+)
+
+// decimalPattern matches the decimal SQL types, with their optional precision and scale.
+var decimalPattern = regexp.MustCompile(`^(?:decimal|numeric|dec)\s*(?:\(\s*(\d+)\s*(?:,\s*(\d+)\s*)?\))?$`)
+
+// DataType returns the Arrow type of a SQL type, such as the Type of the ColumnInformation of a catalog
+// table. The complex types, like array<int>, and the unknown types are strings: their values are read as
+// JSON text.
+func DataType(sqlType string) arrow.DataType {
+	sqlType = strings.ToLower(strings.TrimSpace(sqlType))
+	switch sqlType {
+	case "boolean":
+		return arrow.FixedWidthTypes.Boolean
+	case "tinyint", "byte":
+		return arrow.PrimitiveTypes.Int8
+	case "smallint", "short":
+		return arrow.PrimitiveTypes.Int16
+	case "int", "integer":
+		return arrow.PrimitiveTypes.Int32
+	case "bigint", "long":
+		return arrow.PrimitiveTypes.Int64
+	case "float", "real":
+		return arrow.PrimitiveTypes.Float32
+	case "double", "double precision":
+		return arrow.PrimitiveTypes.Float64
+	case "binary":
+		return arrow.BinaryTypes.Binary
+	case "date":
+		return arrow.FixedWidthTypes.Date32
+	case "timestamp":
+		return arrow.FixedWidthTypes.Timestamp_us
+	}
+	if match := decimalPattern.FindStringSubmatch(sqlType); match != nil {
+		decimalType := &arrow.Decimal128Type{Precision: 10}
+		if match[1] != "" {
+			precision, _ := strconv.Atoi(match[1])
+			decimalType.Precision = int32(precision)
+		}
+		if match[2] != "" {
+			scale, _ := strconv.Atoi(match[2])
+			decimalType.Scale = int32(scale)
+		}
+		return decimalType
+	}
+	return arrow.BinaryTypes.String
+}
+
+// SchemaFromColumns returns the Arrow schema of "columns", for example the columns of a catalog table
+// returned by GetTable. The columns whose Nullable isn't set are nullable.
+func SchemaFromColumns(columns []sqlv2.ColumnInformation) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, len(columns))
+	seen := make(map[string]bool)
+	for i, column := range columns {
+		if column.Name == nil || *column.Name == "" {
+			return nil, fmt.Errorf("column %d has no name", i)
+		}
+		if seen[*column.Name] {
+			return nil, fmt.Errorf("duplicate column %q", *column.Name)
+		}
+		seen[*column.Name] = true
+		fields[i] = arrow.Field{Name: *column.Name, Type: arrow.BinaryTypes.String, Nullable: true}
+		if column.Type != nil {
+			fields[i].Type = DataType(*column.Type)
+		}
+		if column.Nullable != nil {
+			fields[i].Nullable = *column.Nullable
+		}
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+// SQLType returns the SQL type of an Arrow type, the inverse of DataType. The unsigned integers have the
+// smallest signed type that holds their values, and the types without SQL equivalent are strings.
+func SQLType(dataType arrow.DataType) string {
+	switch dataType := dataType.(type) {
+	case *arrow.Decimal128Type:
+		return fmt.Sprintf("decimal(%d,%d)", dataType.Precision, dataType.Scale)
+	case *arrow.TimestampType:
+		return "timestamp"
+	}
+	switch dataType.ID() {
+	case arrow.BOOL:
+		return "boolean"
+	case arrow.INT8:
+		return "tinyint"
+	case arrow.INT16, arrow.UINT8:
+		return "smallint"
+	case arrow.INT32, arrow.UINT16:
+		return "int"
+	case arrow.INT64, arrow.UINT32:
+		return "bigint"
+	case arrow.UINT64:
+		return "decimal(20,0)"
+	case arrow.FLOAT32:
+		return "float"
+	case arrow.FLOAT64:
+		return "double"
+	case arrow.BINARY, arrow.FIXED_SIZE_BINARY:
+		return "binary"
+	case arrow.DATE32:
+		return "date"
+	}
+	return "string"
+}
+
+// GetResultSchema returns the columns of the results of "job", which must have completed, with their SQL
+// types as in the ColumnInformation of catalog tables, see results.GetResultSchema. The schema of Parquet
+// results is the schema of the records of a RecordReader, mapped back to SQL types with SQLType: the
+// columns are nullable unless declared required, and the results with nested columns are rejected. The
+// schema of CSV and JSON results is returned by results.GetResultSchema.
+func GetResultSchema(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, options *results.SchemaOptions) ([]sqlv2.ColumnInformation, error) {
+	err := core.ValidateNotNil(storage, "storage cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(job, "job cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &results.SchemaOptions{}
+	}
+	format := strings.ToLower(options.Format)
+	if format == "" && job.ResultsetFormat != nil {
+		format = strings.ToLower(*job.ResultsetFormat)
+	}
+	if format != results.Format_Parquet {
+		return results.GetResultSchema(ctx, storage, job, options)
+	}
+	if job.ResultsetLocation == nil {
+		return nil, fmt.Errorf("the job has no resultset location")
+	}
+
+	arrowSchema, source, err := newParquetSource(ctx, storage, *job.ResultsetLocation)
+	if err != nil {
+		return nil, err
+	}
+	source.close()
+	columns := make([]sqlv2.ColumnInformation, len(arrowSchema.Fields()))
+	for i, field := range arrowSchema.Fields() {
+		columns[i] = sqlv2.ColumnInformation{
+			Name:     core.StringPtr(field.Name),
+			Type:     core.StringPtr(SQLType(field.Type)),
+			Nullable: core.BoolPtr(field.Nullable),
+		}
+	}
+	return columns, nil
+}
+
+// inferType returns the Arrow type of the JSON values of a column: integers, numbers, booleans, or else
+// strings.
+func inferType(values []interface{}) arrow.DataType {
+	var dataType arrow.DataType
+	for _, value := range values {
+		var valueType arrow.DataType
+		switch value := value.(type) {
+		case nil:
+			continue
+		case json.Number:
+			valueType = arrow.PrimitiveTypes.Float64
+			if _, err := value.Int64(); err == nil {
+				valueType = arrow.PrimitiveTypes.Int64
+			}
+		case bool:
+			valueType = arrow.FixedWidthTypes.Boolean
+		default:
+			return arrow.BinaryTypes.String
+		}
+		switch {
+		case dataType == nil || dataType == valueType:
+			dataType = valueType
+		case dataType.ID() == arrow.INT64 && valueType.ID() == arrow.FLOAT64 || dataType.ID() == arrow.FLOAT64 && valueType.ID() == arrow.INT64:
+			dataType = arrow.PrimitiveTypes.Float64
+		default:
+			return arrow.BinaryTypes.String
+		}
+	}
+	if dataType == nil {
+		return arrow.BinaryTypes.String
+	}
+	return dataType
+}
+
+// parquetType returns the Arrow type of a column of a Parquet file, from its physical and logical types.
+func parquetType(column *schema.Column) (arrow.DataType, error) {
+	if column.MaxRepetitionLevel() > 0 || strings.Contains(column.Path(), ".") {
+		return nil, fmt.Errorf("unsupported nested column %s", column.Path())
+	}
+	logicalType := column.LogicalType()
+	if decimalType, ok := logicalType.(*schema.DecimalLogicalType); ok {
+		return &arrow.Decimal128Type{Precision: decimalType.Precision(), Scale: decimalType.Scale()}, nil
+	}
+	switch column.PhysicalType() {
+	case parquet.Types.Boolean:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case parquet.Types.Int32:
+		switch logicalType := logicalType.(type) {
+		case schema.DateLogicalType:
+			return arrow.FixedWidthTypes.Date32, nil
+		case *schema.IntLogicalType:
+			switch {
+			case logicalType.BitWidth() == 8 && logicalType.IsSigned():
+				return arrow.PrimitiveTypes.Int8, nil
+			case logicalType.BitWidth() == 16 && logicalType.IsSigned():
+				return arrow.PrimitiveTypes.Int16, nil
+			case logicalType.BitWidth() == 8:
+				return arrow.PrimitiveTypes.Uint8, nil
+			case logicalType.BitWidth() == 16:
+				return arrow.PrimitiveTypes.Uint16, nil
+			case !logicalType.IsSigned():
+				return arrow.PrimitiveTypes.Uint32, nil
+			}
+		}
+		return arrow.PrimitiveTypes.Int32, nil
+	case parquet.Types.Int64:
+		switch logicalType := logicalType.(type) {
+		case *schema.TimestampLogicalType:
+			timestampType := &arrow.TimestampType{Unit: arrow.Microsecond}
+			switch logicalType.TimeUnit() {
+			case schema.TimeUnitMillis:
+				timestampType.Unit = arrow.Millisecond
+			case schema.TimeUnitNanos:
+				timestampType.Unit = arrow.Nanosecond
+			}
+			if logicalType.IsAdjustedToUTC() {
+				timestampType.TimeZone = "UTC"
+			}
+			return timestampType, nil
+		case *schema.IntLogicalType:
+			if !logicalType.IsSigned() {
+				return arrow.PrimitiveTypes.Uint64, nil
+			}
+		}
+		return arrow.PrimitiveTypes.Int64, nil
+	case parquet.Types.Int96:
+		// The legacy timestamps written by Spark.
+		return arrow.FixedWidthTypes.Timestamp_ns, nil
+	case parquet.Types.Float:
+		return arrow.PrimitiveTypes.Float32, nil
+	case parquet.Types.Double:
+		return arrow.PrimitiveTypes.Float64, nil
+	case parquet.Types.ByteArray:
+		switch logicalType.(type) {
+		case schema.StringLogicalType, schema.EnumLogicalType, schema.JSONLogicalType:
+			return arrow.BinaryTypes.String, nil
+		}
+		return arrow.BinaryTypes.Binary, nil
+	case parquet.Types.FixedLenByteArray:
+		return &arrow.FixedSizeBinaryType{ByteWidth: column.TypeLength()}, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s of column %s", column.PhysicalType(), column.Path())
+}
@@ -0,0 +1,164 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package arrowresults
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/apache/arrow/go/v7/arrow"
+	"github.com/apache/arrow/go/v7/parquet"
+	"github.com/apache/arrow/go/v7/parquet/pqarrow"
+	"github.com/apache/arrow/go/v7/parquet/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataType(t *testing.T) {
+	assert.Equal(t, arrow.PrimitiveTypes.Int32, DataType("INT"))
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, DataType(" bigint "))
+	assert.Equal(t, arrow.FixedWidthTypes.Date32, DataType("date"))
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 10, Scale: 0}, DataType("decimal"))
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 38, Scale: 4}, DataType("decimal(38, 4)"))
+	assert.Equal(t, &arrow.Decimal128Type{Precision: 5, Scale: 0}, DataType("numeric(5)"))
+	assert.Equal(t, arrow.BinaryTypes.String, DataType("array<int>"))
+	assert.Equal(t, arrow.BinaryTypes.String, DataType("varchar(10)"))
+}
+
+func TestSchemaFromColumns(t *testing.T) {
+	schema, err := SchemaFromColumns([]sqlv2.ColumnInformation{
+		{Name: core.StringPtr("id"), Type: core.StringPtr("bigint"), Nullable: core.BoolPtr(false)},
+		{Name: core.StringPtr("name")},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}, schema.Fields())
+
+	_, err = SchemaFromColumns([]sqlv2.ColumnInformation{{Name: core.StringPtr("id")}, {Name: core.StringPtr("id")}})
+	assert.NotNil(t, err)
+	_, err = SchemaFromColumns([]sqlv2.ColumnInformation{{Type: core.StringPtr("int")}})
+	assert.NotNil(t, err)
+}
+
+func TestSQLType(t *testing.T) {
+	for _, sqlType := range []string{"boolean", "tinyint", "smallint", "int", "bigint", "float", "double", "binary", "date", "timestamp", "decimal(12,2)", "string"} {
+		assert.Equal(t, sqlType, SQLType(DataType(sqlType)))
+	}
+	assert.Equal(t, "smallint", SQLType(arrow.PrimitiveTypes.Uint8))
+	assert.Equal(t, "bigint", SQLType(arrow.PrimitiveTypes.Uint32))
+	assert.Equal(t, "decimal(20,0)", SQLType(arrow.PrimitiveTypes.Uint64))
+	assert.Equal(t, "timestamp", SQLType(&arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}))
+	assert.Equal(t, "binary", SQLType(&arrow.FixedSizeBinaryType{ByteWidth: 16}))
+	assert.Equal(t, "string", SQLType(arrow.ListOf(arrow.PrimitiveTypes.Int32)))
+}
+
+func TestResultSchema(t *testing.T) {
+	server, storage := newStorage(t)
+	fields := []arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "amount", Type: &arrow.Decimal128Type{Precision: 12, Scale: 2}, Nullable: true},
+		{Name: "day", Type: arrow.FixedWidthTypes.Date32, Nullable: true},
+		{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	}
+	var file bytes.Buffer
+	writer, err := pqarrow.NewFileWriter(arrow.NewSchema(fields, nil), &file, nil, pqarrow.DefaultWriterProps())
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+	server.PutObject("bucket", "results/jobid=job1/region=EU/part-00000.snappy.parquet", file.Bytes())
+
+	columns, err := GetResultSchema(context.Background(), storage, newJob("parquet"), nil)
+	require.Nil(t, err)
+	data, err := json.Marshal(columns)
+	require.Nil(t, err)
+	assert.Equal(t, `[{"name":"id","type":"bigint","nullable":false},{"name":"amount","type":"decimal(12,2)","nullable":true},`+
+		`{"name":"day","type":"date","nullable":true},{"name":"name","type":"string","nullable":true},`+
+		`{"name":"region","type":"string","nullable":true}]`, string(data))
+
+	// The schema of CSV results is inferred by the results package.
+	server.PutObject("bucket", "results/jobid=job2/region=EU/part-00000.csv", []byte("id,ok\n1,true\n"))
+	job := newJob("parquet")
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	columns, err = GetResultSchema(context.Background(), storage, job, &results.SchemaOptions{Format: "csv"})
+	require.Nil(t, err)
+	data, err = json.Marshal(columns)
+	require.Nil(t, err)
+	assert.Equal(t, `[{"name":"id","type":"bigint","nullable":true},{"name":"ok","type":"boolean","nullable":true},`+
+		`{"name":"region","type":"string","nullable":true}]`, string(data))
+
+	// The errors of the Parquet reader are returned.
+	server.PutObject("bucket", "results/jobid=job3/part-00000.parquet", []byte("PAR1 not parquet"))
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job3")
+	_, err = GetResultSchema(context.Background(), storage, job, nil)
+	assert.NotNil(t, err)
+	_, err = GetResultSchema(context.Background(), nil, job, nil)
+	assert.NotNil(t, err)
+}
+
+func TestInferType(t *testing.T) {
+	assert.Equal(t, arrow.PrimitiveTypes.Int64, inferType([]interface{}{json.Number("1"), nil, json.Number("-2")}))
+	assert.Equal(t, arrow.PrimitiveTypes.Float64, inferType([]interface{}{json.Number("1"), json.Number("2.5")}))
+	assert.Equal(t, arrow.FixedWidthTypes.Boolean, inferType([]interface{}{true, false}))
+	assert.Equal(t, arrow.BinaryTypes.String, inferType([]interface{}{true, json.Number("1")}))
+	assert.Equal(t, arrow.BinaryTypes.String, inferType([]interface{}{map[string]interface{}{}}))
+	assert.Equal(t, arrow.BinaryTypes.String, inferType([]interface{}{nil}))
+}
+
+func TestParquetType(t *testing.T) {
+	column := func(logicalType schema.LogicalType, physicalType parquet.Type, length int) *schema.Column {
+		node, err := schema.NewPrimitiveNodeLogical("c", parquet.Repetitions.Optional, logicalType, physicalType, length, -1)
+		require.Nil(t, err)
+		return schema.NewColumn(node, 1, 0)
+	}
+	tests := []struct {
+		column   *schema.Column
+		expected arrow.DataType
+	}{
+		{column(schema.NoLogicalType{}, parquet.Types.Boolean, -1), arrow.FixedWidthTypes.Boolean},
+		{column(schema.NewIntLogicalType(16, true), parquet.Types.Int32, -1), arrow.PrimitiveTypes.Int16},
+		{column(schema.DateLogicalType{}, parquet.Types.Int32, -1), arrow.FixedWidthTypes.Date32},
+		{column(schema.NewDecimalLogicalType(9, 2), parquet.Types.Int32, -1), &arrow.Decimal128Type{Precision: 9, Scale: 2}},
+		{column(schema.NewTimestampLogicalType(true, schema.TimeUnitMillis), parquet.Types.Int64, -1), &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+		{column(schema.NoLogicalType{}, parquet.Types.Int96, -1), arrow.FixedWidthTypes.Timestamp_ns},
+		{column(schema.NoLogicalType{}, parquet.Types.Double, -1), arrow.PrimitiveTypes.Float64},
+		{column(schema.StringLogicalType{}, parquet.Types.ByteArray, -1), arrow.BinaryTypes.String},
+		{column(schema.NoLogicalType{}, parquet.Types.ByteArray, -1), arrow.BinaryTypes.Binary},
+		{column(schema.NoLogicalType{}, parquet.Types.FixedLenByteArray, 16), &arrow.FixedSizeBinaryType{ByteWidth: 16}},
+	}
+	for _, test := range tests {
+		dataType, err := parquetType(test.column)
+		require.Nil(t, err)
+		assert.Equal(t, test.expected, dataType)
+	}
+
+	node, err := schema.NewPrimitiveNode("c", parquet.Repetitions.Repeated, parquet.Types.Int32, -1, -1)
+	require.Nil(t, err)
+	_, err = parquetType(schema.NewColumn(node, 1, 1))
+	assert.NotNil(t, err)
+}
+
+func TestDecimalFromBytes(t *testing.T) {
+	assert.Equal(t, "-1", decimalFromBytes([]byte{0xff, 0xff}).BigInt().String())
+	assert.Equal(t, "255", decimalFromBytes([]byte{0x00, 0xff}).BigInt().String())
+	assert.Equal(t, "0", decimalFromBytes(nil).BigInt().String())
+}
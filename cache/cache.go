@@ -0,0 +1,350 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cache : An opt-in result cache in front of SubmitSqlJob.
+//
+// When the same statement is submitted again while the result of a previous, completed job is still
+// fresh, the Cache returns that job instead of running a new one, so its ResultsetLocation can be
+// reused. Freshness is bounded by a TTL and, optionally, by checking that the Cloud Object Storage
+// objects read by the statement still have the same ETag and modification time.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// DefaultMaxEntries is the number of statements remembered by a Cache when none is configured.
+const DefaultMaxEntries = 1000
+
+// HeaderCache is the header of the responses of the submissions answered with a previous job, set to
+// "hit". The responses of the service don't have it.
+const HeaderCache = "X-Sql-Query-Cache"
+
+// Options : The Cache options.
+type Options struct {
+	// How long the result of a completed job can be reused, counted from the job's end time.
+	TTL time.Duration `validate:"required"`
+
+	// Check that the objects read by the statement haven't changed before reusing a result.
+	// Requires Storage.
+	CheckInputs bool
+
+	// The client used to check the input objects.
+	Storage *cos.Client
+
+	// How statements are normalized before they are compared. Defaults to a Normalizer that keeps literals
+	// and paths, so that only statements that read the same data and produce the same result match.
+	Normalizer *fingerprint.Normalizer
+
+	// The maximum number of statements remembered. Defaults to DefaultMaxEntries.
+	MaxEntries int
+}
+
+// Stats : Counters of a Cache.
+type Stats struct {
+	// The number of submissions answered with a previous job.
+	Hits uint64
+
+	// The number of submissions that ran a new job.
+	Misses uint64
+}
+
+// entry : What the cache remembers about the last job submitted for a statement.
+type entry struct {
+	jobID       string
+	instanceCrn *string
+	inputs      string
+	submitted   time.Time
+	completedAt time.Time
+	job         *sqlv2.SqlJobInfoFull
+}
+
+// Cache wraps the SubmitSqlJob operation of a sqlv2.SqlV2 with a result cache. It is safe for concurrent use.
+type Cache struct {
+	hits   uint64
+	misses uint64
+
+	sql        *sqlv2.SqlV2
+	options    Options
+	normalizer fingerprint.Normalizer
+
+	mutex   sync.Mutex
+	entries map[string]*entry
+}
+
+// New : constructs a Cache in front of "sql".
+func New(sql *sqlv2.SqlV2, options *Options) (*Cache, error) {
+	err := core.ValidateNotNil(options, "options cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateStruct(options, "options")
+	if err != nil {
+		return nil, err
+	}
+	if options.CheckInputs && options.Storage == nil {
+		return nil, fmt.Errorf("a storage client is required to check the inputs of statements")
+	}
+
+	cache := &Cache{
+		sql:        sql,
+		options:    *options,
+		normalizer: fingerprint.Normalizer{KeepLiterals: true, KeepPaths: true},
+		entries:    make(map[string]*entry),
+	}
+	if options.Normalizer != nil {
+		cache.normalizer = *options.Normalizer
+	}
+	if cache.options.MaxEntries <= 0 {
+		cache.options.MaxEntries = DefaultMaxEntries
+	}
+	return cache, nil
+}
+
+// SubmitSqlJob : Run an SQL job, or reuse the result of a previous one
+// See SubmitSqlJobWithContext.
+func (cache *Cache) SubmitSqlJob(submitSqlJobOptions *sqlv2.SubmitSqlJobOptions) (result *sqlv2.SqlJobInfoShort, response *core.DetailedResponse, err error) {
+	return cache.SubmitSqlJobWithContext(context.Background(), submitSqlJobOptions)
+}
+
+// SubmitSqlJobWithContext returns the last completed job that was submitted through the cache with the
+// same statement and resultset target if it is still fresh. Otherwise, it submits a new job. On a cache
+// hit, the result describes the previous job, whose ResultsetLocation can be retrieved with GetSqlJob, and
+// the response has the status 200 OK and the HeaderCache header, rather than the 201 Created of a new job.
+func (cache *Cache) SubmitSqlJobWithContext(ctx context.Context, submitSqlJobOptions *sqlv2.SubmitSqlJobOptions) (result *sqlv2.SqlJobInfoShort, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(submitSqlJobOptions, "submitSqlJobOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(submitSqlJobOptions, "submitSqlJobOptions")
+	if err != nil {
+		return
+	}
+
+	key := cache.key(submitSqlJobOptions)
+	var inputs string
+	if cache.options.CheckInputs {
+		inputs, err = cache.inputsDigest(ctx, *submitSqlJobOptions.Statement)
+		if err != nil {
+			return
+		}
+	}
+
+	if cached := cache.lookup(ctx, key, inputs); cached != nil {
+		job := cached.job
+		atomic.AddUint64(&cache.hits, 1)
+		result = &sqlv2.SqlJobInfoShort{
+			JobID:      job.JobID,
+			Status:     job.Status,
+			UserID:     job.UserID,
+			SubmitTime: job.SubmitTime,
+			HasHints:   core.BoolPtr(len(job.Hints) > 0),
+		}
+		response = &core.DetailedResponse{
+			StatusCode: http.StatusOK,
+			Headers:    http.Header{HeaderCache: []string{"hit"}},
+			Result:     result,
+		}
+		return
+	}
+
+	atomic.AddUint64(&cache.misses, 1)
+	result, response, err = cache.sql.SubmitSqlJobWithContext(ctx, submitSqlJobOptions)
+	if err != nil || result.JobID == nil {
+		return
+	}
+	cache.store(key, &entry{
+		jobID:       *result.JobID,
+		instanceCrn: submitSqlJobOptions.InstanceCrn,
+		inputs:      inputs,
+		submitted:   time.Now(),
+	})
+	return
+}
+
+// Stats returns the hit and miss counters.
+func (cache *Cache) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&cache.hits),
+		Misses: atomic.LoadUint64(&cache.misses),
+	}
+}
+
+// Invalidate forgets the job remembered for "statement" and "resultsetTarget" (which may be empty).
+func (cache *Cache) Invalidate(statement string, resultsetTarget string) {
+	options := &sqlv2.SubmitSqlJobOptions{Statement: &statement}
+	if resultsetTarget != "" {
+		options.ResultsetTarget = &resultsetTarget
+	}
+	key := cache.key(options)
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.entries, key)
+}
+
+// Purge forgets every remembered job.
+func (cache *Cache) Purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries = make(map[string]*entry)
+}
+
+// key returns the cache key of a submission: the fingerprint of its statement, its resultset target and
+// its instance override.
+func (cache *Cache) key(submitSqlJobOptions *sqlv2.SubmitSqlJobOptions) string {
+	key := cache.normalizer.Fingerprint(*submitSqlJobOptions.Statement)
+	if submitSqlJobOptions.ResultsetTarget != nil {
+		key += " " + *submitSqlJobOptions.ResultsetTarget
+	}
+	if submitSqlJobOptions.InstanceCrn != nil {
+		key += " " + *submitSqlJobOptions.InstanceCrn
+	}
+	return key
+}
+
+// lookup returns a copy of the entry of "key" if its job completed recently enough and read the same
+// inputs.
+func (cache *Cache) lookup(ctx context.Context, key string, inputs string) *entry {
+	cache.mutex.Lock()
+	cached, ok := cache.entries[key]
+	var current entry
+	if ok {
+		current = *cached
+	}
+	cache.mutex.Unlock()
+	if !ok || current.inputs != inputs {
+		return nil
+	}
+
+	if current.job == nil {
+		getSqlJobOptions := cache.sql.NewGetSqlJobOptions(current.jobID)
+		getSqlJobOptions.InstanceCrn = current.instanceCrn
+		job, _, err := cache.sql.GetSqlJobWithContext(ctx, getSqlJobOptions)
+		if err != nil || job.Status == nil {
+			return nil
+		}
+		switch *job.Status {
+		case sqlv2.SqlJobInfoFull_Status_Completed:
+			current.job = job
+			current.completedAt = time.Now()
+			if job.EndTime != nil {
+				current.completedAt = time.Time(*job.EndTime)
+			}
+			cache.mutex.Lock()
+			if cached == cache.entries[key] {
+				cached.job = current.job
+				cached.completedAt = current.completedAt
+			}
+			cache.mutex.Unlock()
+		case sqlv2.SqlJobInfoFull_Status_Failed:
+			cache.remove(key, cached)
+			return nil
+		default:
+			return nil
+		}
+	}
+
+	if time.Since(current.completedAt) > cache.options.TTL {
+		cache.remove(key, cached)
+		return nil
+	}
+	return &current
+}
+
+// store remembers a job, evicting the oldest entries when the cache is full.
+func (cache *Cache) store(key string, newEntry *entry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.entries[key] = newEntry
+	for len(cache.entries) > cache.options.MaxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, e := range cache.entries {
+			if oldestKey == "" || e.submitted.Before(oldest) {
+				oldestKey, oldest = k, e.submitted
+			}
+		}
+		delete(cache.entries, oldestKey)
+	}
+}
+
+// remove forgets the entry of "key" if it hasn't been replaced in the meantime.
+func (cache *Cache) remove(key string, removed *entry) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.entries[key] == removed {
+		delete(cache.entries, key)
+	}
+}
+
+// inputsDigest lists the objects read by "statement" and returns a digest of their keys, ETags and
+// modification times.
+func (cache *Cache) inputsDigest(ctx context.Context, statement string) (string, error) {
+	var lines []string
+	for _, uri := range InputURIs(statement) {
+		location, err := cos.ParseURI(uri)
+		if err != nil {
+			return "", err
+		}
+		if i := strings.IndexAny(location.Key, "*?"); i >= 0 {
+			location.Key = location.Key[:i]
+		}
+		objects, err := cache.options.Storage.ListObjects(ctx, location)
+		if err != nil {
+			return "", err
+		}
+		for _, object := range objects {
+			lines = append(lines, fmt.Sprintf("%s/%s/%s %s %d %d", location.Endpoint, location.Bucket,
+				object.Key, object.ETag, object.Size, object.LastModified.Unix()))
+		}
+	}
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// InputURIs returns the cos:// URIs read by "statement", that is all the URIs except the target of an
+// INTO clause. Tables of the catalog are not included.
+func InputURIs(statement string) []string {
+	var uris []string
+	var previous string
+	for _, token := range fingerprint.Tokenize(statement) {
+		switch token.Kind {
+		case fingerprint.TokenKind_Whitespace, fingerprint.TokenKind_Comment:
+			continue
+		case fingerprint.TokenKind_URI:
+			if !strings.EqualFold(previous, "into") {
+				uris = append(uris, token.Text)
+			}
+		}
+		previous = token.Text
+	}
+	return uris
+}
@@ -0,0 +1,175 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockService simulates the SQL Query service: every submitted job ends with "status" at "endTime".
+type mockService struct {
+	server    *httptest.Server
+	mutex     sync.Mutex
+	submitted int
+	status    string
+	endTime   time.Time
+}
+
+func newMockService(t *testing.T) (*mockService, *sqlv2.SqlV2) {
+	mock := &mockService{status: "completed", endTime: time.Now()}
+	mock.server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mock.mutex.Lock()
+		defer mock.mutex.Unlock()
+		res.Header().Set("Content-type", "application/json")
+		if req.Method == http.MethodPost {
+			mock.submitted++
+			res.WriteHeader(201)
+			fmt.Fprintf(res, `{"job_id": "job%d", "status": "queued"}`, mock.submitted)
+			return
+		}
+		jobID := strings.TrimPrefix(req.URL.EscapedPath(), "/sql_jobs/")
+		res.WriteHeader(200)
+		fmt.Fprintf(res, `{"job_id": "%s", "status": "%s", "user_id": "user", "submit_time": "2022-01-01T12:00:00.000Z", "end_time": "%s", "statement": "SELECT 1", "resultset_location": "cos://us-geo/results/jobid=%s"}`,
+			jobID, mock.status, mock.endTime.UTC().Format("2006-01-02T15:04:05.000Z"), jobID)
+	}))
+	sqlService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           mock.server.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+	})
+	require.Nil(t, err)
+	return mock, sqlService
+}
+
+func submit(t *testing.T, cache *Cache, statement string) string {
+	result, _, err := cache.SubmitSqlJob(&sqlv2.SubmitSqlJobOptions{Statement: core.StringPtr(statement)})
+	require.Nil(t, err)
+	return *result.JobID
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, nil)
+	assert.NotNil(t, err)
+	_, err = New(nil, &Options{})
+	assert.NotNil(t, err)
+	_, err = New(nil, &Options{TTL: time.Hour, CheckInputs: true})
+	assert.NotNil(t, err)
+}
+
+func TestHitsAndMisses(t *testing.T) {
+	mock, sqlService := newMockService(t)
+	defer mock.server.Close()
+	cache, err := New(sqlService, &Options{TTL: time.Hour})
+	require.Nil(t, err)
+
+	assert.Equal(t, "job1", submit(t, cache, "SELECT * FROM cos://us-geo/b/t.csv WHERE a = 1"))
+	assert.Equal(t, "job1", submit(t, cache, "select *\nfrom cos://us-geo/b/t.csv where a = 1 -- again"))
+	assert.Equal(t, "job2", submit(t, cache, "SELECT * FROM cos://us-geo/b/t.csv WHERE a = 2"))
+	assert.Equal(t, Stats{Hits: 1, Misses: 2}, cache.Stats())
+
+	result, response, err := cache.SubmitSqlJob(&sqlv2.SubmitSqlJobOptions{
+		Statement:       core.StringPtr("SELECT * FROM cos://us-geo/b/t.csv WHERE a = 1"),
+		ResultsetTarget: core.StringPtr("cos://us-geo/other/"),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "job3", *result.JobID)
+
+	// A hit has a response marked as cached.
+	result, response, err = cache.SubmitSqlJob(&sqlv2.SubmitSqlJobOptions{
+		Statement:       core.StringPtr("SELECT * FROM cos://us-geo/b/t.csv WHERE a = 1"),
+		ResultsetTarget: core.StringPtr("cos://us-geo/other/"),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, 200, response.StatusCode)
+	assert.Equal(t, "hit", response.Headers.Get(HeaderCache))
+	assert.Same(t, result, response.Result)
+	assert.Equal(t, "job3", *result.JobID)
+	assert.Equal(t, "user", *result.UserID)
+
+	cache.Invalidate("SELECT * FROM cos://us-geo/b/t.csv WHERE a = 1", "")
+	assert.Equal(t, "job4", submit(t, cache, "SELECT * FROM cos://us-geo/b/t.csv WHERE a = 1"))
+	cache.Purge()
+	assert.Equal(t, "job5", submit(t, cache, "SELECT * FROM cos://us-geo/b/t.csv WHERE a = 1"))
+}
+
+func TestExpiredAndFailedJobs(t *testing.T) {
+	mock, sqlService := newMockService(t)
+	defer mock.server.Close()
+	cache, err := New(sqlService, &Options{TTL: time.Minute})
+	require.Nil(t, err)
+
+	mock.endTime = time.Now().Add(-2 * time.Minute)
+	assert.Equal(t, "job1", submit(t, cache, "SELECT 1"))
+	assert.Equal(t, "job2", submit(t, cache, "SELECT 1"))
+
+	mock.endTime = time.Now()
+	mock.status = "failed"
+	assert.Equal(t, "job3", submit(t, cache, "SELECT 1"))
+
+	mock.status = "running"
+	assert.Equal(t, "job4", submit(t, cache, "SELECT 1"))
+	assert.Equal(t, Stats{Misses: 4}, cache.Stats())
+}
+
+func TestCheckInputs(t *testing.T) {
+	mock, sqlService := newMockService(t)
+	defer mock.server.Close()
+	storage := costest.NewServer()
+	defer storage.Close()
+	storage.PutObject("bucket", "sales/part-0.csv", []byte("a\n1\n"))
+	storage.PutObject("bucket", "sales/part-1.csv", []byte("a\n2\n"))
+	client, err := cos.NewClient(&cos.Options{Authenticator: &core.NoAuthAuthenticator{}, EndpointURL: storage.URL})
+	require.Nil(t, err)
+	cache, err := New(sqlService, &Options{TTL: time.Hour, CheckInputs: true, Storage: client})
+	require.Nil(t, err)
+
+	statement := "SELECT * FROM cos://us-geo/bucket/sales/ STORED AS CSV INTO cos://us-geo/bucket/results/"
+	assert.Equal(t, "job1", submit(t, cache, statement))
+	assert.Equal(t, "job1", submit(t, cache, statement))
+
+	storage.PutObject("bucket", "sales/part-1.csv", []byte("a\n3\n"))
+	assert.Equal(t, "job2", submit(t, cache, statement))
+	assert.Equal(t, "job2", submit(t, cache, statement))
+
+	storage.PutObject("bucket", "results/ignored.csv", []byte("x"))
+	assert.Equal(t, "job2", submit(t, cache, statement))
+	assert.Equal(t, Stats{Hits: 3, Misses: 2}, cache.Stats())
+
+	_, _, err = cache.SubmitSqlJob(&sqlv2.SubmitSqlJobOptions{Statement: core.StringPtr("SELECT * FROM cos://us-geo/missing/")})
+	assert.True(t, cos.IsNotFound(err))
+}
+
+func TestInputURIs(t *testing.T) {
+	uris := InputURIs("SELECT * FROM cos://us-geo/b/a.csv a JOIN cos://eu-de/c/d/ STORED AS PARQUET b ON a.x = b.x " +
+		"-- cos://us-geo/b/comment\nINTO cos://us-geo/b/results/ STORED AS CSV")
+	assert.Equal(t, []string{"cos://us-geo/b/a.csv", "cos://eu-de/c/d/"}, uris)
+	assert.Empty(t, InputURIs("SELECT * FROM my_table"))
+}
@@ -0,0 +1,449 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package catalog : An in-process cache of the catalog tables.
+//
+// A Cache wraps the ListTables and GetTable operations of a sqlv2.SqlV2. Their results are kept for a TTL,
+// and concurrent lookups of the same table or list share a single call to the service. The entries of the
+// tables changed by DDL statements are invalidated explicitly, for example from the events of a
+// sqlv2.Watcher, and the cache can be saved to a file so that a new process starts with it.
+//
+// FindTables filters the tables of a SqlV2 or of a Cache locally, by name, kind and columns.
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// DefaultMaxEntries is the number of tables and lists remembered by a Cache when none is configured.
+const DefaultMaxEntries = 10000
+
+// fileVersion is the version of the format of the files written by Save. Version 1 didn't key the entries of
+// the default instance by its CRN.
+const fileVersion = 2
+
+// Options : The Cache options.
+type Options struct {
+	// How long the columns of a table returned by GetTable are reused.
+	TTL time.Duration `validate:"required"`
+
+	// How long the tables returned by ListTables are reused. Defaults to TTL.
+	ListTTL time.Duration
+
+	// The maximum number of tables and lists remembered. Defaults to DefaultMaxEntries.
+	MaxEntries int
+
+	// The file the cache is loaded from by New, if it exists, and written to by Save.
+	Path string
+}
+
+// Stats : Counters of a Cache.
+type Stats struct {
+	// The number of lookups answered from the cache.
+	Hits uint64
+
+	// The number of lookups that called the service.
+	Misses uint64
+
+	// The number of lookups that waited for the same call of another lookup.
+	Shared uint64
+}
+
+// entry : A table or a list remembered by the cache.
+type entry struct {
+	Table   *sqlv2.TableInformation `json:"table,omitempty"`
+	List    *sqlv2.TableList        `json:"list,omitempty"`
+	Expires time.Time               `json:"expires"`
+}
+
+// cacheFile : The content of the file written by Save.
+type cacheFile struct {
+	Version int               `json:"version"`
+	Tables  map[string]*entry `json:"tables"`
+	Lists   map[string]*entry `json:"lists"`
+}
+
+// call : A call to the service shared by concurrent lookups.
+type call struct {
+	done     chan struct{}
+	entry    *entry
+	response *core.DetailedResponse
+	err      error
+}
+
+// Cache wraps the ListTables and GetTable operations of a sqlv2.SqlV2 with a cache. It is safe for
+// concurrent use.
+type Cache struct {
+	hits   uint64
+	misses uint64
+	shared uint64
+
+	sql     *sqlv2.SqlV2
+	options Options
+
+	mutex  sync.Mutex
+	tables map[string]*entry
+	lists  map[string]*entry
+	calls  map[string]*call
+
+	// Incremented by every invalidation, so that the results of the calls started before aren't stored.
+	generation uint64
+}
+
+// New : constructs a Cache in front of "sql". If Options.Path is set and the file exists, the entries that
+// haven't expired are loaded from it.
+func New(sql *sqlv2.SqlV2, options *Options) (*Cache, error) {
+	err := core.ValidateNotNil(sql, "sql cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(options, "options cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateStruct(options, "options")
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &Cache{
+		sql:     sql,
+		options: *options,
+		tables:  make(map[string]*entry),
+		lists:   make(map[string]*entry),
+		calls:   make(map[string]*call),
+	}
+	if cache.options.ListTTL <= 0 {
+		cache.options.ListTTL = cache.options.TTL
+	}
+	if cache.options.MaxEntries <= 0 {
+		cache.options.MaxEntries = DefaultMaxEntries
+	}
+	if cache.options.Path != "" {
+		if err = cache.load(); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+// ListTables : List the tables of the catalog, from the cache if possible
+// See ListTablesWithContext.
+func (cache *Cache) ListTables(listTablesOptions *sqlv2.ListTablesOptions) (result *sqlv2.TableList, response *core.DetailedResponse, err error) {
+	return cache.ListTablesWithContext(context.Background(), listTablesOptions)
+}
+
+// ListTablesWithContext returns the tables listed with the same options less than ListTTL ago, or lists
+// them. On a cache hit, and to the lookups that shared the call of another, the returned response is nil.
+// The result is shared and must not be modified.
+func (cache *Cache) ListTablesWithContext(ctx context.Context, listTablesOptions *sqlv2.ListTablesOptions) (result *sqlv2.TableList, response *core.DetailedResponse, err error) {
+	if listTablesOptions == nil {
+		listTablesOptions = cache.sql.NewListTablesOptions()
+	}
+	key := listKey(cache.instanceCrn(listTablesOptions.InstanceCrn), listTablesOptions)
+	found, response, err := cache.lookup(ctx, cache.lists, "list "+key, key, cache.options.ListTTL, func() (*entry, *core.DetailedResponse, error) {
+		list, response, err := cache.sql.ListTablesWithContext(ctx, listTablesOptions)
+		return &entry{List: list}, response, err
+	})
+	if err != nil {
+		return nil, response, err
+	}
+	return found.List, response, nil
+}
+
+// GetTable : Get the columns of a table of the catalog, from the cache if possible
+// See GetTableWithContext.
+func (cache *Cache) GetTable(getTableOptions *sqlv2.GetTableOptions) (result *sqlv2.TableInformation, response *core.DetailedResponse, err error) {
+	return cache.GetTableWithContext(context.Background(), getTableOptions)
+}
+
+// GetTableWithContext returns the table fetched less than TTL ago, or fetches it. The names of tables are
+// case insensitive. On a cache hit, and to the lookups that shared the call of another, the returned
+// response is nil. The errors, such as the tables not found, aren't cached. The result is shared and must
+// not be modified.
+func (cache *Cache) GetTableWithContext(ctx context.Context, getTableOptions *sqlv2.GetTableOptions) (result *sqlv2.TableInformation, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(getTableOptions, "getTableOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(getTableOptions, "getTableOptions")
+	if err != nil {
+		return
+	}
+	key := tableKey(cache.instanceCrn(getTableOptions.InstanceCrn), *getTableOptions.TableName)
+	found, response, err := cache.lookup(ctx, cache.tables, "table "+key, key, cache.options.TTL, func() (*entry, *core.DetailedResponse, error) {
+		table, response, err := cache.sql.GetTableWithContext(ctx, getTableOptions)
+		return &entry{Table: table}, response, err
+	})
+	if err != nil {
+		return nil, response, err
+	}
+	return found.Table, response, nil
+}
+
+// lookup returns the entry of "key" in "entries" if it hasn't expired. Otherwise, it calls "fetch" unless
+// another lookup is already doing it, and stores the result for "ttl". "callKey" identifies the call among
+// those of the tables and lists.
+func (cache *Cache) lookup(ctx context.Context, entries map[string]*entry, callKey string, key string, ttl time.Duration,
+	fetch func() (*entry, *core.DetailedResponse, error)) (*entry, *core.DetailedResponse, error) {
+	for {
+		cache.mutex.Lock()
+		if found, ok := entries[key]; ok && time.Now().Before(found.Expires) {
+			cache.mutex.Unlock()
+			atomic.AddUint64(&cache.hits, 1)
+			return found, nil, nil
+		}
+		if current, ok := cache.calls[callKey]; ok {
+			cache.mutex.Unlock()
+			atomic.AddUint64(&cache.shared, 1)
+			select {
+			case <-current.done:
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+			if current.err != nil && isContextError(current.err) && ctx.Err() == nil {
+				// The lookup that made the call was canceled, but this one wasn't: try again.
+				continue
+			}
+			return current.entry, nil, current.err
+		}
+		current := &call{done: make(chan struct{})}
+		cache.calls[callKey] = current
+		generation := cache.generation
+		cache.mutex.Unlock()
+
+		atomic.AddUint64(&cache.misses, 1)
+		current.entry, current.response, current.err = fetch()
+		cache.mutex.Lock()
+		if cache.calls[callKey] == current {
+			delete(cache.calls, callKey)
+		}
+		if current.err == nil && generation == cache.generation {
+			current.entry.Expires = time.Now().Add(ttl)
+			entries[key] = current.entry
+			cache.evict()
+		}
+		cache.mutex.Unlock()
+		close(current.done)
+		return current.entry, current.response, current.err
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// evict removes the expired entries, and then the entries that expire first, when the cache is full. The
+// mutex must be held.
+func (cache *Cache) evict() {
+	if len(cache.tables)+len(cache.lists) <= cache.options.MaxEntries {
+		return
+	}
+	now := time.Now()
+	for _, entries := range []map[string]*entry{cache.tables, cache.lists} {
+		for key, e := range entries {
+			if !now.Before(e.Expires) {
+				delete(entries, key)
+			}
+		}
+	}
+	for len(cache.tables)+len(cache.lists) > cache.options.MaxEntries {
+		var oldestEntries map[string]*entry
+		var oldestKey string
+		var oldest time.Time
+		for _, entries := range []map[string]*entry{cache.tables, cache.lists} {
+			for key, e := range entries {
+				if oldestEntries == nil || e.Expires.Before(oldest) {
+					oldestEntries, oldestKey, oldest = entries, key, e.Expires
+				}
+			}
+		}
+		delete(oldestEntries, oldestKey)
+	}
+}
+
+// Stats returns the hit, miss and shared lookup counters.
+func (cache *Cache) Stats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&cache.hits),
+		Misses: atomic.LoadUint64(&cache.misses),
+		Shared: atomic.LoadUint64(&cache.shared),
+	}
+}
+
+// Invalidate forgets the table "tableName" of every instance, and every list of tables, after the table
+// was created, altered or dropped. A name qualified by a database also forgets the unqualified name.
+func (cache *Cache) Invalidate(tableName string) {
+	names := map[string]bool{strings.ToLower(tableName): true}
+	if i := strings.LastIndexByte(tableName, '.'); i >= 0 {
+		names[strings.ToLower(tableName[i+1:])] = true
+	}
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	for key := range cache.tables {
+		if names[key[strings.IndexByte(key, 0)+1:]] {
+			delete(cache.tables, key)
+		}
+	}
+	cache.lists = make(map[string]*entry)
+	cache.calls = make(map[string]*call)
+	cache.generation++
+}
+
+// InvalidateStatement invalidates the tables created, altered or dropped by "statement", see DDLTables,
+// and returns whether it is such a DDL statement.
+func (cache *Cache) InvalidateStatement(statement string) bool {
+	tables, ok := DDLTables(statement)
+	for _, table := range tables {
+		cache.Invalidate(table)
+	}
+	return ok
+}
+
+// OnJobEvent invalidates the tables changed by the DDL statements of the completed jobs. It can be
+// registered with the OnEvent method of a sqlv2.Watcher.
+func (cache *Cache) OnJobEvent(event sqlv2.JobEvent) {
+	if event.Type == sqlv2.JobEventType_Completed && event.Job != nil && event.Job.Statement != nil {
+		cache.InvalidateStatement(*event.Job.Statement)
+	}
+}
+
+// Purge forgets every table and list.
+func (cache *Cache) Purge() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.tables = make(map[string]*entry)
+	cache.lists = make(map[string]*entry)
+	cache.calls = make(map[string]*call)
+	cache.generation++
+}
+
+// Save writes the entries that haven't expired to Options.Path. The file is replaced atomically.
+func (cache *Cache) Save() error {
+	if cache.options.Path == "" {
+		return fmt.Errorf("the cache has no path")
+	}
+	file := cacheFile{Version: fileVersion, Tables: make(map[string]*entry), Lists: make(map[string]*entry)}
+	now := time.Now()
+	cache.mutex.Lock()
+	for key, e := range cache.tables {
+		if now.Before(e.Expires) {
+			file.Tables[key] = e
+		}
+	}
+	for key, e := range cache.lists {
+		if now.Before(e.Expires) {
+			file.Lists[key] = e
+		}
+	}
+	data, err := json.Marshal(&file)
+	cache.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	temporary, err := ioutil.TempFile(filepath.Dir(cache.options.Path), filepath.Base(cache.options.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = temporary.Write(data)
+	if closeErr := temporary.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary.Name(), cache.options.Path)
+	}
+	if err != nil {
+		os.Remove(temporary.Name())
+	}
+	return err
+}
+
+// load reads the entries that haven't expired from Options.Path, if it exists.
+func (cache *Cache) load() error {
+	data, err := ioutil.ReadFile(cache.options.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var file cacheFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("invalid catalog cache file %s: %w", cache.options.Path, err)
+	}
+	if file.Version != fileVersion {
+		return nil
+	}
+	now := time.Now()
+	for key, e := range file.Tables {
+		if e != nil && e.Table != nil && now.Before(e.Expires) {
+			cache.tables[key] = e
+		}
+	}
+	for key, e := range file.Lists {
+		if e != nil && e.List != nil && now.Before(e.Expires) {
+			cache.lists[key] = e
+		}
+	}
+	cache.evict()
+	return nil
+}
+
+// instanceCrn returns the CRN of the instance a call is sent to: "override" if set, otherwise the current
+// InstanceCrn of the client. The entries are keyed by it, so that they aren't returned to a client, or
+// loaded by one, that uses another instance.
+func (cache *Cache) instanceCrn(override *string) string {
+	if override != nil {
+		return *override
+	}
+	if cache.sql.InstanceCrn != nil {
+		return *cache.sql.InstanceCrn
+	}
+	return ""
+}
+
+// tableKey returns the cache key of a table: the CRN of its instance and its lower-case name, separated by
+// a NUL character.
+func tableKey(instanceCrn string, tableName string) string {
+	return instanceCrn + "\x00" + strings.ToLower(tableName)
+}
+
+// listKey returns the cache key of a list of tables: the CRN of its instance, its name pattern and type.
+func listKey(instanceCrn string, listTablesOptions *sqlv2.ListTablesOptions) string {
+	key := tableKey(instanceCrn, "")
+	if listTablesOptions.NamePattern != nil {
+		key += *listTablesOptions.NamePattern
+	}
+	key += "\x00"
+	if listTablesOptions.Type != nil {
+		key += strings.ToLower(*listTablesOptions.Type)
+	}
+	return key
+}
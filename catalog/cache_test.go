@@ -0,0 +1,266 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testService is a fake service with the tables sales and costs. Its responses wait for release when set.
+type testService struct {
+	mutex   sync.Mutex
+	calls   map[string]int
+	release chan struct{}
+}
+
+func (service *testService) count(path string) int {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	return service.calls[path]
+}
+
+func newTestService(t *testing.T, service *testService) *sqlv2.SqlV2 {
+	service.calls = make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		service.mutex.Lock()
+		service.calls[req.URL.Path]++
+		release := service.release
+		service.mutex.Unlock()
+		if release != nil {
+			<-release
+		}
+
+		res.Header().Set("Content-type", "application/json")
+		switch name := strings.TrimPrefix(req.URL.Path, "/tables/"); {
+		case req.URL.Path == "/tables":
+			_, _ = res.Write([]byte(`{"tables":["costs","sales"],"tables_metadata":[{"name":"costs","type":"TABLE"},{"name":"sales","type":"VIEW"}]}`))
+		case name == "sales" || name == "costs":
+			_, _ = fmt.Fprintf(res, `{"name":"%s","type":"TABLE","columns":[{"name":"id","type":"int"}]}`, name)
+		default:
+			res.WriteHeader(http.StatusNotFound)
+			_, _ = res.Write([]byte(`{"errors":[{"code":"not_found","message":"table not found"}]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	sql, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           server.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"),
+	})
+	require.Nil(t, err)
+	return sql
+}
+
+func TestGetTable(t *testing.T) {
+	service := &testService{}
+	sql := newTestService(t, service)
+	cache, err := New(sql, &Options{TTL: time.Hour})
+	require.Nil(t, err)
+
+	table, response, err := cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	assert.NotNil(t, response)
+	assert.Equal(t, "sales", *table.Name)
+	cached, response, err := cache.GetTable(sql.NewGetTableOptions("SALES"))
+	require.Nil(t, err)
+	assert.Nil(t, response)
+	assert.Same(t, table, cached)
+	assert.Equal(t, 1, service.count("/tables/sales"))
+
+	// Another instance has other tables.
+	_, _, err = cache.GetTable(sql.NewGetTableOptions("sales").SetInstanceCrn("crn:v1:bluemix:public:sql-query:us-south:a/123:other::"))
+	require.Nil(t, err)
+	assert.Equal(t, 2, service.count("/tables/sales"))
+
+	// The errors aren't cached.
+	for i := 0; i < 2; i++ {
+		_, _, err = cache.GetTable(sql.NewGetTableOptions("missing"))
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 2, service.count("/tables/missing"))
+	_, _, err = cache.GetTable(nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, Stats{Hits: 1, Misses: 4}, cache.Stats())
+}
+
+func TestTTL(t *testing.T) {
+	service := &testService{}
+	sql := newTestService(t, service)
+	cache, err := New(sql, &Options{TTL: 20 * time.Millisecond, ListTTL: time.Hour, MaxEntries: 2})
+	require.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, _, err = cache.GetTable(sql.NewGetTableOptions("sales"))
+		require.Nil(t, err)
+		_, _, err = cache.ListTables(nil)
+		require.Nil(t, err)
+	}
+	assert.Equal(t, 1, service.count("/tables/sales"))
+	assert.Equal(t, 1, service.count("/tables"))
+
+	time.Sleep(30 * time.Millisecond)
+	_, _, err = cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	_, _, err = cache.ListTables(nil)
+	require.Nil(t, err)
+	assert.Equal(t, 2, service.count("/tables/sales"))
+	assert.Equal(t, 1, service.count("/tables"))
+
+	// The lists are cached by name pattern and type.
+	_, _, err = cache.ListTables(sql.NewListTablesOptions().SetType("view"))
+	require.Nil(t, err)
+	assert.Equal(t, 2, service.count("/tables"))
+
+	// The entry that expires first is evicted when the cache is full.
+	_, _, err = cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	assert.Equal(t, 3, service.count("/tables/sales"))
+}
+
+func TestSharedLookups(t *testing.T) {
+	service := &testService{release: make(chan struct{})}
+	sql := newTestService(t, service)
+	cache, err := New(sql, &Options{TTL: time.Hour})
+	require.Nil(t, err)
+
+	var wait sync.WaitGroup
+	tables := make([]*sqlv2.TableInformation, 10)
+	for i := range tables {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			tables[i], _, _ = cache.GetTable(sql.NewGetTableOptions("sales"))
+		}(i)
+	}
+	require.Eventually(t, func() bool { return cache.Stats().Shared == 9 }, time.Second, time.Millisecond)
+
+	// A canceled lookup doesn't wait for the shared call.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = cache.GetTableWithContext(ctx, sql.NewGetTableOptions("sales"))
+	assert.Equal(t, context.Canceled, err)
+
+	close(service.release)
+	wait.Wait()
+	assert.Equal(t, 1, service.count("/tables/sales"))
+	for _, table := range tables {
+		require.NotNil(t, table)
+		assert.Same(t, tables[0], table)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	service := &testService{}
+	sql := newTestService(t, service)
+	cache, err := New(sql, &Options{TTL: time.Hour})
+	require.Nil(t, err)
+	lookup := func() {
+		for _, name := range []string{"sales", "costs"} {
+			_, _, err := cache.GetTable(sql.NewGetTableOptions(name))
+			require.Nil(t, err)
+		}
+		_, _, err := cache.ListTables(nil)
+		require.Nil(t, err)
+	}
+
+	lookup()
+	cache.Invalidate("db.Sales")
+	lookup()
+	assert.Equal(t, 2, service.count("/tables/sales"))
+	assert.Equal(t, 1, service.count("/tables/costs"))
+	assert.Equal(t, 2, service.count("/tables"))
+
+	assert.False(t, cache.InvalidateStatement("SELECT * FROM sales"))
+	cache.OnJobEvent(sqlv2.JobEvent{Type: sqlv2.JobEventType_Failed, Job: &sqlv2.SqlJobInfoFull{Statement: core.StringPtr("DROP TABLE costs")}})
+	lookup()
+	assert.Equal(t, 1, service.count("/tables/costs"))
+	assert.Equal(t, 2, service.count("/tables"))
+
+	cache.OnJobEvent(sqlv2.JobEvent{Type: sqlv2.JobEventType_Completed, Job: &sqlv2.SqlJobInfoFull{Statement: core.StringPtr("ALTER TABLE costs RENAME TO old_costs")}})
+	lookup()
+	assert.Equal(t, 2, service.count("/tables/sales"))
+	assert.Equal(t, 2, service.count("/tables/costs"))
+	assert.Equal(t, 3, service.count("/tables"))
+
+	cache.Purge()
+	lookup()
+	assert.Equal(t, 3, service.count("/tables/sales"))
+}
+
+func TestSave(t *testing.T) {
+	service := &testService{}
+	sql := newTestService(t, service)
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	cache, err := New(sql, &Options{TTL: time.Hour, Path: path})
+	require.Nil(t, err)
+	_, _, err = cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	_, _, err = cache.ListTables(nil)
+	require.Nil(t, err)
+	require.Nil(t, cache.Save())
+
+	// A new cache starts with the saved entries.
+	cache, err = New(sql, &Options{TTL: time.Hour, Path: path})
+	require.Nil(t, err)
+	table, _, err := cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	assert.Equal(t, "int", *table.Columns[0].Type)
+	list, _, err := cache.ListTables(nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"costs", "sales"}, list.Tables)
+	assert.Equal(t, 1, service.count("/tables/sales"))
+	assert.Equal(t, 1, service.count("/tables"))
+	assert.Equal(t, Stats{Hits: 2}, cache.Stats())
+
+	// The saved entries belong to the instance of the client that saved them, and a live cache follows the
+	// instance of its client.
+	sql.InstanceCrn = core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:other::")
+	_, _, err = cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	assert.Equal(t, 2, service.count("/tables/sales"))
+	cache, err = New(sql, &Options{TTL: time.Hour, Path: path})
+	require.Nil(t, err)
+	_, _, err = cache.ListTables(nil)
+	require.Nil(t, err)
+	assert.Equal(t, 2, service.count("/tables"))
+
+	_, err = New(sql, &Options{TTL: time.Hour, Path: filepath.Join(t.TempDir(), "missing.json")})
+	assert.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = New(sql, &Options{TTL: time.Hour, Path: path})
+	assert.NotNil(t, err)
+	cache, err = New(sql, &Options{TTL: time.Hour})
+	require.Nil(t, err)
+	assert.NotNil(t, cache.Save())
+	_, err = New(sql, &Options{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,97 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package catalog
+
+import (
+	"strings"
+
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+)
+
+// DDLTables returns the names of the tables and views created, altered, dropped or repaired by
+// "statement", and whether it is such a DDL statement: CREATE, DROP, ALTER or MSCK REPAIR followed by
+// TABLE or VIEW. The target of ALTER ... RENAME TO is included. The names are lower case, with the
+// backquotes removed.
+func DDLTables(statement string) ([]string, bool) {
+	var words []fingerprint.Token
+	for _, token := range fingerprint.Tokenize(statement) {
+		if token.Kind != fingerprint.TokenKind_Whitespace && token.Kind != fingerprint.TokenKind_Comment {
+			words = append(words, token)
+		}
+	}
+	if len(words) == 0 {
+		return nil, false
+	}
+	switch strings.ToUpper(words[0].Text) {
+	case "CREATE", "DROP", "ALTER", "MSCK":
+	default:
+		return nil, false
+	}
+
+	// Skip the modifiers, like OR REPLACE, EXTERNAL or TEMPORARY, up to TABLE or VIEW.
+	i := 1
+	for i < len(words) && words[i].Kind == fingerprint.TokenKind_Word && !isTableKeyword(words[i].Text) {
+		i++
+	}
+	if i >= len(words) || !isTableKeyword(words[i].Text) {
+		return nil, false
+	}
+	i++
+	for _, keyword := range []string{"IF", "NOT", "EXISTS"} {
+		if i < len(words) && strings.EqualFold(words[i].Text, keyword) {
+			i++
+		}
+	}
+
+	var tables []string
+	name, i := tableName(words, i)
+	if name != "" {
+		tables = append(tables, name)
+	}
+	for ; i+2 < len(words); i++ {
+		if strings.EqualFold(words[i].Text, "RENAME") && strings.EqualFold(words[i+1].Text, "TO") {
+			if renamed, _ := tableName(words, i+2); renamed != "" {
+				tables = append(tables, renamed)
+			}
+			break
+		}
+	}
+	return tables, true
+}
+
+func isTableKeyword(text string) bool {
+	return strings.EqualFold(text, "TABLE") || strings.EqualFold(text, "VIEW")
+}
+
+// tableName returns the possibly qualified name of a table starting at words[i], and the index of the word
+// after it.
+func tableName(words []fingerprint.Token, i int) (string, int) {
+	var parts []string
+	for i < len(words) {
+		word := words[i]
+		if word.Kind != fingerprint.TokenKind_Word && word.Kind != fingerprint.TokenKind_Identifier {
+			break
+		}
+		parts = append(parts, strings.ToLower(strings.Trim(word.Text, "`")))
+		i++
+		if i >= len(words) || words[i].Text != "." {
+			break
+		}
+		i++
+	}
+	return strings.Join(parts, "."), i
+}
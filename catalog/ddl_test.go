@@ -0,0 +1,47 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDDLTables(t *testing.T) {
+	tests := []struct {
+		statement string
+		tables    []string
+		ddl       bool
+	}{
+		{"CREATE TABLE IF NOT EXISTS `Sales` (id int) USING parquet LOCATION cos://us-geo/bucket/sales/", []string{"sales"}, true},
+		{"create or replace view db.v1 as select 1", []string{"db.v1"}, true},
+		{"/* cleanup */ DROP TABLE IF EXISTS sales", []string{"sales"}, true},
+		{"ALTER TABLE sales RENAME TO `old_sales`", []string{"sales", "old_sales"}, true},
+		{"ALTER TABLE sales ADD PARTITION (day='2022-01-31')", []string{"sales"}, true},
+		{"MSCK REPAIR TABLE sales", []string{"sales"}, true},
+		{"SELECT * FROM sales INTO cos://us-geo/bucket/result/", nil, false},
+		{"DESCRIBE TABLE sales", nil, false},
+		{"CREATE FUNCTION f AS 'x'", nil, false},
+		{"", nil, false},
+	}
+	for _, test := range tests {
+		tables, ddl := DDLTables(test.statement)
+		assert.Equal(t, test.tables, tables, test.statement)
+		assert.Equal(t, test.ddl, ddl, test.statement)
+	}
+}
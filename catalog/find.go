@@ -0,0 +1,315 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// DefaultConcurrency is the number of tables fetched at the same time by FindTables when none is configured.
+const DefaultConcurrency = 4
+
+// Constants associated with the FindOptions.Kind property.
+const (
+	Kind_Table = "table"
+	Kind_View  = "view"
+)
+
+// Catalog : The catalog operations used by FindTables, implemented by sqlv2.SqlV2 and Cache.
+type Catalog interface {
+	ListTablesWithContext(ctx context.Context, listTablesOptions *sqlv2.ListTablesOptions) (*sqlv2.TableList, *core.DetailedResponse, error)
+	GetTableWithContext(ctx context.Context, getTableOptions *sqlv2.GetTableOptions) (*sqlv2.TableInformation, *core.DetailedResponse, error)
+}
+
+// FindOptions : The FindTables options. The tables returned match every filter set.
+type FindOptions struct {
+	// A table name pattern, see sqlv2.ValidateNamePattern. It is sent to the service to narrow the listing,
+	// and evaluated again on the tables listed.
+	NamePattern string
+
+	// A regular expression the table names must match.
+	NameRegexp *regexp.Regexp
+
+	// The kind of the tables, Kind_Table or Kind_View. It is sent to the service to narrow the listing, and
+	// checked again on the tables listed.
+	Kind string
+
+	// A name pattern, with the syntax of NamePattern, that a column of the tables must match.
+	ColumnNamePattern string
+
+	// A type that a column of the tables must have, like "int", "decimal" or "array". The parameters of the
+	// types are ignored: "decimal" matches "decimal(10,2)". With ColumnNamePattern, the same column must
+	// match both.
+	ColumnType string
+
+	// Whether the columns of the tables are returned when there is no column filter.
+	IncludeColumns bool
+
+	// The maximum number of tables fetched with GetTable at the same time. Defaults to DefaultConcurrency.
+	Concurrency int
+
+	// The cloud resource name (CRN) of the SQL query service instance to use instead of the default instance
+	// of the client.
+	InstanceCrn *string
+}
+
+// found : A table fetched by a TableIterator, nil if filtered out.
+type found struct {
+	table *sqlv2.TableInformation
+	err   error
+}
+
+// TableIterator returns the tables found by FindTables, in the order of the listing. Next isn't safe for
+// concurrent use.
+type TableIterator struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// The tables selected from the listing and, when the columns are fetched, the result of each table.
+	tables  []*sqlv2.TableInformation
+	results []chan found
+
+	current int
+	err     error
+}
+
+// FindTables lists the tables of "catalog" and returns those matching the filters of "options". When
+// columns are filtered or included, the tables are fetched with GetTable, several at the same time, and the
+// tables dropped since the listing are skipped. The TableIterator must be closed to stop fetching before
+// reading every table.
+func FindTables(ctx context.Context, catalog Catalog, options *FindOptions) (*TableIterator, error) {
+	err := core.ValidateNotNil(catalog, "catalog cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &FindOptions{}
+	}
+	listTablesOptions := &sqlv2.ListTablesOptions{InstanceCrn: options.InstanceCrn}
+	if options.NamePattern != "" {
+		if err = sqlv2.ValidateNamePattern(options.NamePattern); err != nil {
+			return nil, err
+		}
+		listTablesOptions.NamePattern = core.StringPtr(options.NamePattern)
+	}
+	if options.ColumnNamePattern != "" {
+		if err = sqlv2.ValidateNamePattern(options.ColumnNamePattern); err != nil {
+			return nil, err
+		}
+	}
+	kind := strings.ToLower(options.Kind)
+	switch kind {
+	case "":
+	case Kind_Table, Kind_View:
+		listTablesOptions.Type = core.StringPtr(kind)
+	default:
+		return nil, fmt.Errorf("invalid table kind %q: must be %q or %q", options.Kind, Kind_Table, Kind_View)
+	}
+
+	list, _, err := catalog.ListTablesWithContext(ctx, listTablesOptions)
+	if err != nil {
+		return nil, err
+	}
+	iterator := &TableIterator{}
+	for _, table := range listedTables(list) {
+		if matchName(table, options) && (kind == "" || table.Type == nil || strings.EqualFold(*table.Type, kind)) {
+			iterator.tables = append(iterator.tables, table)
+		}
+	}
+	iterator.ctx, iterator.cancel = context.WithCancel(ctx)
+	if options.ColumnNamePattern == "" && options.ColumnType == "" && !options.IncludeColumns {
+		return iterator, nil
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	iterator.results = make([]chan found, len(iterator.tables))
+	for i := range iterator.results {
+		iterator.results[i] = make(chan found, 1)
+	}
+
+	// The tables are started in order, so that the table being returned is always fetched.
+	iterator.wg.Add(1)
+	go func() {
+		defer iterator.wg.Done()
+		var workers sync.WaitGroup
+		slots := make(chan struct{}, concurrency)
+		for i := range iterator.tables {
+			select {
+			case slots <- struct{}{}:
+			case <-iterator.ctx.Done():
+			}
+			if iterator.ctx.Err() != nil {
+				break
+			}
+			workers.Add(1)
+			go func(index int) {
+				defer workers.Done()
+				iterator.results[index] <- iterator.fetch(catalog, iterator.tables[index], kind, options)
+				<-slots
+			}(i)
+		}
+		workers.Wait()
+	}()
+	return iterator, nil
+}
+
+// listedTables returns the tables of "list" with their type, in the order of the listing. The tables of
+// unknown type have a nil Type.
+func listedTables(list *sqlv2.TableList) []*sqlv2.TableInformation {
+	if list == nil {
+		return nil
+	}
+	types := make(map[string]*string)
+	for _, metadata := range list.TablesMetadata {
+		if metadata.Name != nil {
+			types[*metadata.Name] = metadata.Type
+		}
+	}
+	var tables []*sqlv2.TableInformation
+	listed := make(map[string]bool)
+	for _, name := range list.Tables {
+		tables = append(tables, &sqlv2.TableInformation{Name: core.StringPtr(name), Type: types[name]})
+		listed[name] = true
+	}
+	// The tables only described by their metadata.
+	for _, metadata := range list.TablesMetadata {
+		if metadata.Name != nil && !listed[*metadata.Name] {
+			tables = append(tables, &sqlv2.TableInformation{Name: metadata.Name, Type: metadata.Type})
+			listed[*metadata.Name] = true
+		}
+	}
+	return tables
+}
+
+// matchName reports whether the name of "table" matches the name filters of "options".
+func matchName(table *sqlv2.TableInformation, options *FindOptions) bool {
+	if options.NamePattern != "" && !sqlv2.MatchNamePattern(options.NamePattern, *table.Name) {
+		return false
+	}
+	return options.NameRegexp == nil || options.NameRegexp.MatchString(*table.Name)
+}
+
+// fetch gets the columns of "listed" and returns it if it matches the kind and column filters.
+func (iterator *TableIterator) fetch(catalog Catalog, listed *sqlv2.TableInformation, kind string, options *FindOptions) found {
+	table, _, err := catalog.GetTableWithContext(iterator.ctx, &sqlv2.GetTableOptions{
+		TableName:   listed.Name,
+		InstanceCrn: options.InstanceCrn,
+	})
+	if err != nil {
+		var notFound *sqlv2.ErrNotFound
+		if errors.As(err, &notFound) {
+			return found{}
+		}
+		return found{err: fmt.Errorf("table %s: %w", *listed.Name, err)}
+	}
+
+	// The result of a Cache is shared, so it is copied before completing it with the listing.
+	enriched := *table
+	if listed.Type != nil {
+		enriched.Type = listed.Type
+	}
+	if enriched.Name == nil {
+		enriched.Name = listed.Name
+	}
+	if kind != "" && enriched.Type != nil && !strings.EqualFold(*enriched.Type, kind) {
+		return found{}
+	}
+	if (options.ColumnNamePattern != "" || options.ColumnType != "") && !matchColumns(enriched.Columns, options) {
+		return found{}
+	}
+	return found{table: &enriched}
+}
+
+// matchColumns reports whether one of "columns" matches both column filters of "options".
+func matchColumns(columns []sqlv2.ColumnInformation, options *FindOptions) bool {
+	for _, column := range columns {
+		if options.ColumnNamePattern != "" && (column.Name == nil || !sqlv2.MatchNamePattern(options.ColumnNamePattern, *column.Name)) {
+			continue
+		}
+		if options.ColumnType != "" && (column.Type == nil || !strings.EqualFold(baseType(*column.Type), strings.TrimSpace(options.ColumnType))) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// baseType returns a SQL type without its parameters, like "decimal" for "decimal(10,2)" or "array" for
+// "array<int>".
+func baseType(sqlType string) string {
+	if i := strings.IndexAny(sqlType, "(<"); i >= 0 {
+		sqlType = sqlType[:i]
+	}
+	return strings.TrimSpace(sqlType)
+}
+
+// Len returns the number of tables matching the name and kind filters, which is an upper bound of the
+// number of tables returned when the columns are filtered.
+func (iterator *TableIterator) Len() int {
+	return len(iterator.tables)
+}
+
+// Next returns the next table. It returns io.EOF after the last table, and the first error of GetTable,
+// after which the TableIterator stops.
+func (iterator *TableIterator) Next() (*sqlv2.TableInformation, error) {
+	if iterator.err != nil {
+		return nil, iterator.err
+	}
+	for iterator.current < len(iterator.tables) {
+		index := iterator.current
+		iterator.current++
+		if iterator.results == nil {
+			return iterator.tables[index], nil
+		}
+
+		var result found
+		select {
+		case result = <-iterator.results[index]:
+		case <-iterator.ctx.Done():
+			result.err = iterator.ctx.Err()
+		}
+		if result.err != nil {
+			iterator.err = result.err
+			iterator.cancel()
+			return nil, result.err
+		}
+		if result.table != nil {
+			return result.table, nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// Close stops fetching and waits for the fetching goroutines to end.
+func (iterator *TableIterator) Close() error {
+	iterator.cancel()
+	iterator.wg.Wait()
+	return nil
+}
@@ -0,0 +1,216 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package catalog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCatalog is a fake catalog. The tables listed without columns are missing, as if dropped since the
+// listing, and GetTable fails on the tables of failing.
+type testCatalog struct {
+	list    *sqlv2.TableList
+	columns map[string]map[string]string
+	failing map[string]bool
+
+	mutex   sync.Mutex
+	fetched []string
+	active  int
+	peak    int
+}
+
+func newTestCatalog() *testCatalog {
+	testCatalog := &testCatalog{
+		list: &sqlv2.TableList{},
+		columns: map[string]map[string]string{
+			"sales_2021":  {"id": "int", "amount": "decimal(10,2)"},
+			"sales_2022":  {"id": "bigint", "amount": "decimal(12,2)", "tags": "array<string>"},
+			"costs":       {"id": "int", "amount": "double"},
+			"sales_eu":    {"id": "int", "region": "string"},
+			"sales_views": {"region": "string"},
+		},
+		failing: make(map[string]bool),
+	}
+	for _, table := range []struct{ name, kind string }{
+		{"costs", "TABLE"}, {"sales_2021", "TABLE"}, {"sales_2022", "TABLE"}, {"sales_dropped", "TABLE"},
+		{"sales_eu", "VIEW"}, {"sales_views", "VIEW"},
+	} {
+		testCatalog.list.Tables = append(testCatalog.list.Tables, table.name)
+		testCatalog.list.TablesMetadata = append(testCatalog.list.TablesMetadata,
+			sqlv2.TableMetadata{Name: core.StringPtr(table.name), Type: core.StringPtr(table.kind)})
+	}
+	return testCatalog
+}
+
+func (testCatalog *testCatalog) ListTablesWithContext(ctx context.Context, listTablesOptions *sqlv2.ListTablesOptions) (*sqlv2.TableList, *core.DetailedResponse, error) {
+	return testCatalog.list, nil, nil
+}
+
+func (testCatalog *testCatalog) GetTableWithContext(ctx context.Context, getTableOptions *sqlv2.GetTableOptions) (*sqlv2.TableInformation, *core.DetailedResponse, error) {
+	name := *getTableOptions.TableName
+	testCatalog.mutex.Lock()
+	testCatalog.fetched = append(testCatalog.fetched, name)
+	testCatalog.active++
+	if testCatalog.active > testCatalog.peak {
+		testCatalog.peak = testCatalog.active
+	}
+	testCatalog.mutex.Unlock()
+	time.Sleep(5 * time.Millisecond)
+	defer func() {
+		testCatalog.mutex.Lock()
+		testCatalog.active--
+		testCatalog.mutex.Unlock()
+	}()
+
+	if testCatalog.failing[name] {
+		return nil, nil, &sqlv2.ErrServiceUnavailable{APIError: sqlv2.APIError{StatusCode: http.StatusServiceUnavailable, Message: "unavailable"}}
+	}
+	columns, ok := testCatalog.columns[name]
+	if !ok {
+		return nil, nil, &sqlv2.ErrNotFound{APIError: sqlv2.APIError{StatusCode: http.StatusNotFound, Message: "table not found"}}
+	}
+	table := &sqlv2.TableInformation{Name: core.StringPtr(name)}
+	for _, column := range []string{"id", "amount", "region", "tags"} {
+		if columnType, ok := columns[column]; ok {
+			table.Columns = append(table.Columns, sqlv2.ColumnInformation{Name: core.StringPtr(column), Type: core.StringPtr(columnType)})
+		}
+	}
+	return table, nil, nil
+}
+
+// findNames returns the names of the tables found with "options".
+func findNames(t *testing.T, catalog Catalog, options *FindOptions) []string {
+	iterator, err := FindTables(context.Background(), catalog, options)
+	require.Nil(t, err)
+	defer iterator.Close()
+	var names []string
+	for {
+		table, err := iterator.Next()
+		if err == io.EOF {
+			return names
+		}
+		require.Nil(t, err)
+		names = append(names, *table.Name)
+	}
+}
+
+func TestFindByName(t *testing.T) {
+	testCatalog := newTestCatalog()
+	assert.Equal(t, []string{"costs", "sales_2021", "sales_2022", "sales_dropped", "sales_eu", "sales_views"}, findNames(t, testCatalog, nil))
+	assert.Equal(t, []string{"costs", "sales_2022"}, findNames(t, testCatalog, &FindOptions{NamePattern: "COSTS | *_2022"}))
+	assert.Equal(t, []string{"sales_2021", "sales_2022"}, findNames(t, testCatalog, &FindOptions{NameRegexp: regexp.MustCompile(`_\d+$`)}))
+	assert.Equal(t, []string{"sales_eu", "sales_views"}, findNames(t, testCatalog, &FindOptions{Kind: "View"}))
+	assert.Equal(t, []string{"sales_2021", "sales_2022", "sales_dropped"}, findNames(t, testCatalog, &FindOptions{NamePattern: "sales*", Kind: Kind_Table}))
+	assert.Empty(t, testCatalog.fetched)
+
+	for _, options := range []*FindOptions{{NamePattern: "sales%"}, {ColumnNamePattern: "id|"}, {Kind: "index"}} {
+		_, err := FindTables(context.Background(), testCatalog, options)
+		assert.NotNil(t, err)
+	}
+	_, err := FindTables(context.Background(), nil, nil)
+	assert.NotNil(t, err)
+}
+
+func TestFindByColumns(t *testing.T) {
+	testCatalog := newTestCatalog()
+	assert.Equal(t, []string{"sales_2021", "sales_2022"}, findNames(t, testCatalog, &FindOptions{ColumnType: "decimal"}))
+	assert.Equal(t, []string{"sales_eu", "sales_views"}, findNames(t, testCatalog, &FindOptions{ColumnNamePattern: "reg*"}))
+	assert.Equal(t, []string{"costs", "sales_2021", "sales_eu"}, findNames(t, testCatalog, &FindOptions{ColumnNamePattern: "ID", ColumnType: "int"}))
+	assert.Equal(t, []string{"sales_2022"}, findNames(t, testCatalog, &FindOptions{ColumnType: "ARRAY", Kind: Kind_Table}))
+	assert.Empty(t, findNames(t, testCatalog, &FindOptions{ColumnNamePattern: "region", ColumnType: "int"}))
+
+	// The tables are enriched with their kind, and fetched concurrently.
+	testCatalog = newTestCatalog()
+	iterator, err := FindTables(context.Background(), testCatalog, &FindOptions{IncludeColumns: true, Concurrency: 2})
+	require.Nil(t, err)
+	defer iterator.Close()
+	assert.Equal(t, 6, iterator.Len())
+	table, err := iterator.Next()
+	require.Nil(t, err)
+	assert.Equal(t, "costs", *table.Name)
+	assert.Equal(t, "TABLE", *table.Type)
+	assert.Len(t, table.Columns, 2)
+	var names []string
+	for {
+		table, err = iterator.Next()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		names = append(names, *table.Name+" "+*table.Type)
+	}
+	assert.Equal(t, []string{"sales_2021 TABLE", "sales_2022 TABLE", "sales_eu VIEW", "sales_views VIEW"}, names)
+	assert.Len(t, testCatalog.fetched, 6)
+	assert.Equal(t, 2, testCatalog.peak)
+}
+
+func TestFindErrors(t *testing.T) {
+	testCatalog := newTestCatalog()
+	testCatalog.failing["sales_2022"] = true
+	iterator, err := FindTables(context.Background(), testCatalog, &FindOptions{ColumnType: "int"})
+	require.Nil(t, err)
+	defer iterator.Close()
+	table, err := iterator.Next()
+	require.Nil(t, err)
+	assert.Equal(t, "costs", *table.Name)
+	_, err = iterator.Next()
+	require.Nil(t, err)
+	_, err = iterator.Next()
+	var unavailable *sqlv2.ErrServiceUnavailable
+	assert.True(t, errors.As(err, &unavailable))
+	assert.EqualError(t, err, "table sales_2022: unavailable")
+	_, err = iterator.Next()
+	assert.Equal(t, unavailable, errors.Unwrap(err))
+
+	// Closing stops fetching before every table is read.
+	iterator, err = FindTables(context.Background(), newTestCatalog(), &FindOptions{IncludeColumns: true, Concurrency: 1})
+	require.Nil(t, err)
+	_, err = iterator.Next()
+	require.Nil(t, err)
+	assert.Nil(t, iterator.Close())
+}
+
+func TestFindWithCache(t *testing.T) {
+	service := &testService{}
+	sql := newTestService(t, service)
+	assert.Equal(t, []string{"sales"}, findNames(t, sql, &FindOptions{Kind: Kind_View, IncludeColumns: true}))
+
+	cache, err := New(sql, &Options{TTL: time.Hour})
+	require.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, []string{"costs", "sales"}, findNames(t, cache, &FindOptions{ColumnNamePattern: "id"}))
+	}
+	assert.Equal(t, 2, service.count("/tables/sales"))
+	assert.Equal(t, 1, service.count("/tables/costs"))
+
+	// The cached tables aren't modified by the enrichment.
+	table, _, err := cache.GetTable(sql.NewGetTableOptions("sales"))
+	require.Nil(t, err)
+	assert.Equal(t, "TABLE", *table.Type)
+}
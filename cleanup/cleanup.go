@@ -0,0 +1,374 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cleanup : Deletion of the result objects of old jobs.
+//
+// The results of completed jobs stay under their ResultsetLocation until they are deleted. A Cleaner lists
+// the jobs that ended before a retention period, resolves their result objects and deletes them through the
+// S3-compatible API of Cloud Object Storage, unless they are protected by a label. Only the locations specific
+// to a job, named after its ID like the default jobid=<id> directories, are deleted: the locations written by
+// several jobs, or shared with a job submitted after the retention period, are kept.
+package cleanup
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// The actions taken for a job, see JobResult.Action.
+const (
+	Action_Deleted     = "deleted"
+	Action_WouldDelete = "would delete"
+	Action_Protected   = "protected"
+	Action_Shared      = "shared"
+	Action_NoResults   = "no results"
+	Action_Failed      = "failed"
+)
+
+// Options : The Cleaner options.
+type Options struct {
+	// The client used to list and delete the result objects.
+	Storage *cos.Client `validate:"required"`
+
+	// How long the results of a job are kept, counted from the job's end time.
+	Retention time.Duration `validate:"required"`
+
+	// The results of jobs with one of these labels are kept.
+	ProtectLabels []string
+
+	// Returns the labels of a job. Defaults to ResultsetLocationLabels.
+	Labels func(job *sqlv2.SqlJobInfoFull) []string
+
+	// Report the objects that would be deleted without deleting them.
+	DryRun bool
+}
+
+// Cleaner deletes the result objects of the jobs of an instance that are older than a retention period.
+type Cleaner struct {
+	sql       *sqlv2.SqlV2
+	options   Options
+	protected map[string]bool
+}
+
+// JobResult : What a Cleaner did with the results of a job.
+type JobResult struct {
+	// The ID of the job.
+	JobID string
+
+	// When the job ended.
+	EndTime time.Time
+
+	// The location of the results of the job.
+	ResultsetLocation string
+
+	// One of the Action_ constants.
+	Action string
+
+	// The number and total size of the result objects deleted, or that would be deleted in a dry run.
+	Objects int
+	Bytes   int64
+
+	// Why the action failed.
+	Err error
+}
+
+// Report : The result of a Cleaner run.
+type Report struct {
+	// Whether the run was a dry run.
+	DryRun bool
+
+	// The jobs that ended before Cutoff were cleaned up.
+	Cutoff time.Time
+
+	// The jobs that ended before Cutoff, in the order of ListSqlJobs.
+	Jobs []JobResult
+}
+
+// New constructs a Cleaner of the jobs of the default instance of "sql".
+func New(sql *sqlv2.SqlV2, options *Options) (*Cleaner, error) {
+	err := core.ValidateNotNil(sql, "sql cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(options, "options cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateStruct(options, "options")
+	if err != nil {
+		return nil, err
+	}
+
+	cleaner := &Cleaner{
+		sql:       sql,
+		options:   *options,
+		protected: make(map[string]bool, len(options.ProtectLabels)),
+	}
+	for _, label := range options.ProtectLabels {
+		cleaner.protected[label] = true
+	}
+	if cleaner.options.Labels == nil {
+		cleaner.options.Labels = ResultsetLocationLabels
+	}
+	return cleaner, nil
+}
+
+// ResultsetLocationLabels returns the path segments of the resultset location of a job, which include the
+// label of jobs submitted with a default resultset target like cos://us-geo/bucket/{{.JobLabel}}/.
+func ResultsetLocationLabels(job *sqlv2.SqlJobInfoFull) []string {
+	if job.ResultsetLocation == nil {
+		return nil
+	}
+	location, err := cos.ParseURI(*job.ResultsetLocation)
+	if err != nil {
+		return nil
+	}
+	var labels []string
+	for _, segment := range strings.Split(location.Key, "/") {
+		if segment != "" {
+			labels = append(labels, segment)
+		}
+	}
+	return labels
+}
+
+// Run cleans up the results of the completed and failed jobs that ended before the retention period. It
+// only fails if the jobs, or the locations of the jobs submitted after the retention period, can't be read:
+// the failures of single jobs are reported with Action_Failed.
+func (cleaner *Cleaner) Run(ctx context.Context) (*Report, error) {
+	report := &Report{
+		DryRun: cleaner.options.DryRun,
+		Cutoff: time.Now().Add(-cleaner.options.Retention),
+	}
+	list, _, err := cleaner.sql.ListSqlJobsWithContext(ctx, cleaner.sql.NewListSqlJobsOptions())
+	if err != nil {
+		return nil, err
+	}
+	recent, err := cleaner.recentLocations(ctx, list, report.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range list.Jobs {
+		if !expired(job, report.Cutoff) {
+			continue
+		}
+		if job.JobID == nil {
+			report.Jobs = append(report.Jobs, JobResult{Action: Action_Failed, Err: fmt.Errorf("the job has no ID")})
+			continue
+		}
+		if result := cleaner.cleanJob(ctx, *job.JobID, report.Cutoff, recent); result != nil {
+			report.Jobs = append(report.Jobs, *result)
+		}
+	}
+	return report, nil
+}
+
+// expired reports whether "job" is a completed or failed job submitted before the cutoff, whose results may
+// be cleaned up. A job submitted after the cutoff ended after it too.
+func expired(job sqlv2.SqlJobInfoShort, cutoff time.Time) bool {
+	if job.Status == nil || (*job.Status != sqlv2.SqlJobInfoShort_Status_Completed && *job.Status != sqlv2.SqlJobInfoShort_Status_Failed) {
+		return false
+	}
+	return job.SubmitTime != nil && time.Time(*job.SubmitTime).Before(cutoff)
+}
+
+// recentLocations returns the resultset locations of the jobs submitted after the cutoff, or still running,
+// whose results must be kept.
+func (cleaner *Cleaner) recentLocations(ctx context.Context, list *sqlv2.SqlJobInfoList, cutoff time.Time) ([]*cos.Location, error) {
+	var locations []*cos.Location
+	for _, job := range list.Jobs {
+		if job.JobID == nil || expired(job, cutoff) {
+			continue
+		}
+		info, _, err := cleaner.sql.GetSqlJobWithContext(ctx, cleaner.sql.NewGetSqlJobOptions(*job.JobID))
+		if err != nil {
+			return nil, fmt.Errorf("job %s: %w", *job.JobID, err)
+		}
+		if info.ResultsetLocation == nil {
+			continue
+		}
+		if location, err := cos.ParseURI(*info.ResultsetLocation); err == nil {
+			locations = append(locations, location)
+		}
+	}
+	return locations, nil
+}
+
+// cleanJob cleans up the results of a job, or returns nil if the job ended after the cutoff. The results
+// are kept if their location isn't specific to the job, or overlaps one of the "recent" locations.
+func (cleaner *Cleaner) cleanJob(ctx context.Context, jobID string, cutoff time.Time, recent []*cos.Location) *JobResult {
+	result := &JobResult{JobID: jobID}
+	job, _, err := cleaner.sql.GetSqlJobWithContext(ctx, cleaner.sql.NewGetSqlJobOptions(jobID))
+	if err != nil {
+		result.Action, result.Err = Action_Failed, err
+		return result
+	}
+	if job.EndTime != nil {
+		result.EndTime = time.Time(*job.EndTime)
+		if !result.EndTime.Before(cutoff) {
+			return nil
+		}
+	}
+	if job.ResultsetLocation == nil {
+		result.Action = Action_NoResults
+		return result
+	}
+	result.ResultsetLocation = *job.ResultsetLocation
+
+	for _, label := range cleaner.options.Labels(job) {
+		if cleaner.protected[label] {
+			result.Action = Action_Protected
+			return result
+		}
+	}
+
+	location, err := cos.ParseURI(result.ResultsetLocation)
+	if err != nil {
+		result.Action, result.Err = Action_Failed, err
+		return result
+	}
+	if !jobSpecific(location, jobID) {
+		result.Action = Action_Shared
+		return result
+	}
+	for _, other := range recent {
+		if overlaps(location, other) {
+			result.Action = Action_Shared
+			return result
+		}
+	}
+	objects, err := cleaner.resultObjects(ctx, location)
+	if err != nil {
+		result.Action, result.Err = Action_Failed, err
+		return result
+	}
+	if len(objects) == 0 {
+		result.Action = Action_NoResults
+		return result
+	}
+
+	result.Action = Action_WouldDelete
+	if !cleaner.options.DryRun {
+		result.Action = Action_Deleted
+	}
+	for _, object := range objects {
+		if !cleaner.options.DryRun {
+			err = cleaner.options.Storage.DeleteObject(ctx, &cos.Location{Endpoint: location.Endpoint, Bucket: location.Bucket, Key: object.Key})
+			if err != nil {
+				result.Action, result.Err = Action_Failed, err
+				return result
+			}
+		}
+		result.Objects++
+		result.Bytes += object.Size
+	}
+	return result
+}
+
+// jobSpecific reports whether "location" only holds the results of the job "jobID": whether a segment of
+// its key is jobid=<jobID>.
+func jobSpecific(location *cos.Location, jobID string) bool {
+	for _, segment := range strings.Split(location.Key, "/") {
+		if segment == "jobid="+jobID {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether the locations are in the same bucket and one of them contains the other.
+func overlaps(location *cos.Location, other *cos.Location) bool {
+	if location.Bucket != other.Bucket {
+		return false
+	}
+	key, otherKey := strings.TrimSuffix(location.Key, "/"), strings.TrimSuffix(other.Key, "/")
+	return key == otherKey || strings.HasPrefix(key, otherKey+"/") || strings.HasPrefix(otherKey, key+"/") || otherKey == ""
+}
+
+// resultObjects returns the objects of the results at "location": the object at the location itself, which
+// the service writes as a marker, and the objects under it. The objects of other jobs whose location merely
+// starts with the same characters are excluded.
+func (cleaner *Cleaner) resultObjects(ctx context.Context, location *cos.Location) ([]cos.Object, error) {
+	prefix := strings.TrimSuffix(location.Key, "/")
+	if prefix == "" {
+		return nil, fmt.Errorf("refusing to delete the whole bucket %s", location.Bucket)
+	}
+	objects, err := cleaner.options.Storage.ListObjects(ctx, location)
+	if err != nil {
+		if cos.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var results []cos.Object
+	for _, object := range objects {
+		if object.Key == prefix || strings.HasPrefix(object.Key, prefix+"/") {
+			results = append(results, object)
+		}
+	}
+	return results, nil
+}
+
+// Objects returns the number and total size of the objects deleted, or that would be deleted in a dry run.
+func (report *Report) Objects() (count int, size int64) {
+	for _, job := range report.Jobs {
+		count += job.Objects
+		size += job.Bytes
+	}
+	return
+}
+
+// Failed returns the number of jobs whose results couldn't be cleaned up.
+func (report *Report) Failed() int {
+	failed := 0
+	for _, job := range report.Jobs {
+		if job.Action == Action_Failed {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WriteTo writes the report as text, one line per job followed by a summary.
+func (report *Report) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	for _, job := range report.Jobs {
+		fmt.Fprintf(&buffer, "%s\t%s\t%s", job.JobID, job.Action, job.ResultsetLocation)
+		if job.Objects > 0 {
+			fmt.Fprintf(&buffer, "\t%d objects\t%d bytes", job.Objects, job.Bytes)
+		}
+		if job.Err != nil {
+			fmt.Fprintf(&buffer, "\t%s", job.Err.Error())
+		}
+		buffer.WriteString("\n")
+	}
+	count, size := report.Objects()
+	action := "deleted"
+	if report.DryRun {
+		action = "would delete"
+	}
+	fmt.Fprintf(&buffer, "%d jobs ended before %s: %s %d objects (%d bytes), %d failures\n",
+		len(report.Jobs), report.Cutoff.UTC().Format(time.RFC3339), action, count, size, report.Failed())
+	return buffer.WriteTo(w)
+}
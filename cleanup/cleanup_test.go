@@ -0,0 +1,202 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cleanup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testJob struct {
+	status   string
+	age      time.Duration
+	location string
+}
+
+var testJobs = map[string]testJob{
+	"old1":     {"completed", 30 * 24 * time.Hour, "cos://us-geo/bucket/results/unlabeled/jobid=old1"},
+	"keep1":    {"completed", 30 * 24 * time.Hour, "cos://us-geo/bucket/results/keep/jobid=keep1"},
+	"failed1":  {"failed", 30 * 24 * time.Hour, ""},
+	"running1": {"running", 30 * 24 * time.Hour, ""},
+	"new1":     {"completed", time.Hour, "cos://us-geo/bucket/results/unlabeled/jobid=new1"},
+
+	// Jobs writing into the same target, without job prefix, and into the location of an older job.
+	"old2": {"completed", 30 * 24 * time.Hour, "cos://us-geo/bucket/results/out"},
+	"new2": {"completed", time.Hour, "cos://us-geo/bucket/results/out"},
+	"old3": {"completed", 30 * 24 * time.Hour, "cos://us-geo/bucket/results/fixed/jobid=old3"},
+	"new3": {"running", time.Hour, "cos://us-geo/bucket/results/fixed/jobid=old3"},
+}
+
+func newTestService(t *testing.T) *sqlv2.SqlV2 {
+	now := time.Now().UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-type", "application/json")
+		if req.URL.Path == "/sql_jobs" {
+			var jobs []map[string]interface{}
+			for _, id := range []string{"new1", "running1", "old1", "keep1", "failed1", "old2", "new2", "old3", "new3"} {
+				jobs = append(jobs, map[string]interface{}{"job_id": id, "status": testJobs[id].status,
+					"submit_time": now.Add(-testJobs[id].age).Format(time.RFC3339)})
+			}
+			_ = json.NewEncoder(res).Encode(map[string]interface{}{"jobs": jobs})
+			return
+		}
+		id := strings.TrimPrefix(req.URL.Path, "/sql_jobs/")
+		job := testJobs[id]
+		result := map[string]interface{}{"job_id": id, "status": job.status, "user_id": "user", "statement": "SELECT 1",
+			"submit_time": now.Add(-job.age).Format(time.RFC3339), "end_time": now.Add(-job.age + time.Minute).Format(time.RFC3339)}
+		if job.location != "" {
+			result["resultset_location"] = job.location
+		}
+		_ = json.NewEncoder(res).Encode(result)
+	}))
+	t.Cleanup(server.Close)
+
+	sql, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           server.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"),
+	})
+	require.Nil(t, err)
+	return sql
+}
+
+func newTestStorage(t *testing.T) (*costest.Server, *cos.Client) {
+	server := costest.NewServer()
+	t.Cleanup(server.Close)
+	for _, key := range []string{
+		"results/unlabeled/jobid=old1",
+		"results/unlabeled/jobid=old1/part-00000.csv",
+		"results/unlabeled/jobid=old1/part-00001.csv",
+		"results/unlabeled/jobid=old10/part-00000.csv",
+		"results/unlabeled/jobid=new1/part-00000.csv",
+		"results/keep/jobid=keep1/part-00000.csv",
+		"results/out/part-00000.csv",
+		"results/fixed/jobid=old3/part-00000.csv",
+	} {
+		server.PutObject("bucket", key, []byte("a,b\n"))
+	}
+	client, err := cos.NewClient(&cos.Options{Authenticator: &core.NoAuthAuthenticator{}, EndpointURL: server.URL})
+	require.Nil(t, err)
+	return server, client
+}
+
+func TestDryRun(t *testing.T) {
+	server, storage := newTestStorage(t)
+	cleaner, err := New(newTestService(t), &Options{
+		Storage:       storage,
+		Retention:     7 * 24 * time.Hour,
+		ProtectLabels: []string{"keep"},
+		DryRun:        true,
+	})
+	require.Nil(t, err)
+
+	report, err := cleaner.Run(context.Background())
+	require.Nil(t, err)
+	require.Len(t, report.Jobs, 5)
+	assert.Equal(t, "old1", report.Jobs[0].JobID)
+	assert.Equal(t, Action_WouldDelete, report.Jobs[0].Action)
+	assert.Equal(t, 3, report.Jobs[0].Objects)
+	assert.Equal(t, int64(12), report.Jobs[0].Bytes)
+	assert.Equal(t, Action_Protected, report.Jobs[1].Action)
+	assert.Equal(t, Action_NoResults, report.Jobs[2].Action)
+	assert.Equal(t, "old2", report.Jobs[3].JobID)
+	assert.Equal(t, Action_Shared, report.Jobs[3].Action)
+	assert.Equal(t, "old3", report.Jobs[4].JobID)
+	assert.Equal(t, Action_Shared, report.Jobs[4].Action)
+	assert.Len(t, server.Keys("bucket"), 8)
+
+	var text bytes.Buffer
+	_, err = report.WriteTo(&text)
+	require.Nil(t, err)
+	assert.Contains(t, text.String(), "old1\twould delete\tcos://us-geo/bucket/results/unlabeled/jobid=old1\t3 objects\t12 bytes\n")
+	assert.Contains(t, text.String(), "old2\tshared\tcos://us-geo/bucket/results/out\n")
+	assert.Contains(t, text.String(), "5 jobs ended before ")
+	assert.Contains(t, text.String(), ": would delete 3 objects (12 bytes), 0 failures\n")
+}
+
+func TestRun(t *testing.T) {
+	server, storage := newTestStorage(t)
+	cleaner, err := New(newTestService(t), &Options{
+		Storage:       storage,
+		Retention:     7 * 24 * time.Hour,
+		ProtectLabels: []string{"keep"},
+	})
+	require.Nil(t, err)
+
+	report, err := cleaner.Run(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, Action_Deleted, report.Jobs[0].Action)
+	assert.Equal(t, 0, report.Failed())
+	assert.Equal(t, []string{
+		"results/fixed/jobid=old3/part-00000.csv",
+		"results/keep/jobid=keep1/part-00000.csv",
+		"results/out/part-00000.csv",
+		"results/unlabeled/jobid=new1/part-00000.csv",
+		"results/unlabeled/jobid=old10/part-00000.csv",
+	}, server.Keys("bucket"))
+
+	// The results are gone, so a second run has nothing left to delete.
+	report, err = cleaner.Run(context.Background())
+	require.Nil(t, err)
+	assert.Equal(t, Action_NoResults, report.Jobs[0].Action)
+}
+
+func TestJobWithoutID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-type", "application/json")
+		_, _ = res.Write([]byte(`{"jobs": [{"status": "completed", "submit_time": "2022-01-01T12:00:00Z"}]}`))
+	}))
+	defer server.Close()
+	sql, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           server.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"),
+	})
+	require.Nil(t, err)
+	_, storage := newTestStorage(t)
+	cleaner, err := New(sql, &Options{Storage: storage, Retention: time.Hour})
+	require.Nil(t, err)
+
+	report, err := cleaner.Run(context.Background())
+	require.Nil(t, err)
+	require.Len(t, report.Jobs, 1)
+	assert.Equal(t, Action_Failed, report.Jobs[0].Action)
+	assert.EqualError(t, report.Jobs[0].Err, "the job has no ID")
+}
+
+func TestNew(t *testing.T) {
+	_, storage := newTestStorage(t)
+	_, err := New(newTestService(t), &Options{Storage: storage})
+	assert.NotNil(t, err)
+	_, err = New(newTestService(t), nil)
+	assert.NotNil(t, err)
+	_, err = New(nil, &Options{Storage: storage, Retention: time.Hour})
+	assert.NotNil(t, err)
+}
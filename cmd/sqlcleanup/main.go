@@ -0,0 +1,98 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command sqlcleanup deletes the result objects of the jobs that ended before a retention period.
+//
+// The client is configured with a profile, see package config. Nothing is deleted unless -dry-run=false
+// is passed:
+//
+//	sqlcleanup -profile prod -retention 720h -protect monthly-report,audit -dry-run=false
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/IBM/sql-query-go-sdk/cleanup"
+	"github.com/IBM/sql-query-go-sdk/config"
+	"github.com/IBM/sql-query-go-sdk/cos"
+)
+
+func main() {
+	configOptions := &config.Options{}
+	configOptions.RegisterFlags(flag.CommandLine)
+	retention := flag.Duration("retention", 0, "how long the results of a job are kept after it ended, for example 720h (required)")
+	protect := flag.String("protect", "", "comma-separated labels of the jobs whose results are kept")
+	dryRun := flag.Bool("dry-run", true, "only report the objects that would be deleted")
+	endpointURL := flag.String("cos-endpoint", "", "the URL of the Cloud Object Storage endpoint to use instead of the one of the result locations")
+	flag.Parse()
+
+	if *retention <= 0 {
+		fmt.Fprintln(os.Stderr, "sqlcleanup: -retention is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(configOptions, *retention, *protect, *dryRun, *endpointURL); err != nil {
+		fmt.Fprintf(os.Stderr, "sqlcleanup: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(configOptions *config.Options, retention time.Duration, protect string, dryRun bool, endpointURL string) error {
+	sql, _, err := config.LoadConfig(configOptions)
+	if err != nil {
+		return err
+	}
+	storage, err := cos.NewClient(&cos.Options{
+		Authenticator: sql.Service.Options.Authenticator,
+		EndpointURL:   endpointURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	var protectLabels []string
+	for _, label := range strings.Split(protect, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			protectLabels = append(protectLabels, label)
+		}
+	}
+	cleaner, err := cleanup.New(sql, &cleanup.Options{
+		Storage:       storage,
+		Retention:     retention,
+		ProtectLabels: protectLabels,
+		DryRun:        dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	report, err := cleaner.Run(context.Background())
+	if err != nil {
+		return err
+	}
+	if _, err = report.WriteTo(os.Stdout); err != nil {
+		return err
+	}
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("the results of %d jobs couldn't be cleaned up", failed)
+	}
+	return nil
+}
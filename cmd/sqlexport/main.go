@@ -0,0 +1,136 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command sqlexport writes the results of a completed job to a local file.
+//
+// The client is configured with a profile, see package config. The format is taken from the extension of
+// the output file unless -format is passed, one of csv, jsonl, parquet and excel-csv:
+//
+//	sqlexport -profile prod -job 0b9b8c5a-... -o sales.parquet -columns region,total -limit 100000
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/IBM/sql-query-go-sdk/arrowresults"
+	"github.com/IBM/sql-query-go-sdk/config"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+func main() {
+	configOptions := &config.Options{}
+	configOptions.RegisterFlags(flag.CommandLine)
+	jobID := flag.String("job", "", "the ID of the job whose results are exported (required)")
+	output := flag.String("o", "-", "the file to write, or - for the standard output")
+	format := flag.String("format", "", "the format of the file: csv, jsonl, parquet or excel-csv (default from the file extension, else csv)")
+	limit := flag.Int64("limit", 0, "the maximum number of rows to export (default all)")
+	columns := flag.String("columns", "", "comma-separated columns to export, in this order (default all)")
+	concurrency := flag.Int("concurrency", results.DefaultConcurrency, "the number of result objects fetched at the same time, except for parquet")
+	quiet := flag.Bool("quiet", false, "don't report the progress on the standard error")
+	endpointURL := flag.String("cos-endpoint", "", "the URL of the Cloud Object Storage endpoint to use instead of the one of the result location")
+	flag.Parse()
+
+	if *jobID == "" {
+		fmt.Fprintln(os.Stderr, "sqlexport: -job is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = formatOf(*output)
+	}
+	exportOptions := &results.ExportOptions{
+		Limit:       *limit,
+		Concurrency: *concurrency,
+	}
+	for _, column := range strings.Split(*columns, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			exportOptions.Columns = append(exportOptions.Columns, column)
+		}
+	}
+	if !*quiet {
+		exportOptions.Progress = func(rows int64, total int64) {
+			if total > 0 {
+				fmt.Fprintf(os.Stderr, "sqlexport: %d of %d rows (%d%%)\n", rows, total, rows*100/total)
+			} else {
+				fmt.Fprintf(os.Stderr, "sqlexport: %d rows\n", rows)
+			}
+		}
+	}
+
+	if err := run(configOptions, *jobID, *output, *format, exportOptions, *endpointURL); err != nil {
+		fmt.Fprintf(os.Stderr, "sqlexport: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// formatOf returns the export format for the extension of "output".
+func formatOf(output string) string {
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".json", ".jsonl", ".ndjson":
+		return results.ExportFormat_JSONLines
+	case ".parquet":
+		return arrowresults.ExportFormat_Parquet
+	default:
+		return results.ExportFormat_CSV
+	}
+}
+
+func run(configOptions *config.Options, jobID string, output string, format string, exportOptions *results.ExportOptions, endpointURL string) (err error) {
+	sql, _, err := config.LoadConfig(configOptions)
+	if err != nil {
+		return err
+	}
+	storage, err := cos.NewClient(&cos.Options{
+		Authenticator: sql.Service.Options.Authenticator,
+		EndpointURL:   endpointURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	job, _, err := sql.GetSqlJobWithContext(ctx, sql.NewGetSqlJobOptions(jobID))
+	if err != nil {
+		return err
+	}
+	if job.Status == nil || *job.Status != sqlv2.SqlJobInfoFull_Status_Completed {
+		return fmt.Errorf("job %s has not completed", jobID)
+	}
+
+	var dst io.Writer = os.Stdout
+	if output != "-" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		dst = file
+	}
+	_, err = arrowresults.ExportResults(ctx, storage, job, dst, format, exportOptions)
+	return err
+}
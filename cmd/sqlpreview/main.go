@@ -0,0 +1,96 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command sqlpreview prints a sample of the rows of a catalog table as a text table.
+//
+// The client is configured with a profile, see package config. The rows are read by a sampling job, which
+// is submitted to the instance of the profile:
+//
+//	sqlpreview -profile prod -table sales -n 10 -where "region = 'EU'" -sample 5 -random
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/IBM/sql-query-go-sdk/config"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/preview"
+)
+
+func main() {
+	configOptions := &config.Options{}
+	configOptions.RegisterFlags(flag.CommandLine)
+	table := flag.String("table", "", "the name of the catalog table to preview (required)")
+	n := flag.Int("n", preview.DefaultRows, "the maximum number of rows")
+	where := flag.String("where", "", "a condition on the rows, without the WHERE keyword")
+	sample := flag.Float64("sample", 0, "the percentage of the table sampled with TABLESAMPLE (default the whole table)")
+	random := flag.Bool("random", false, "return random rows rather than the first rows read")
+	maxWidth := flag.Int("max-width", preview.DefaultMaxWidth, "the maximum width of the columns")
+	pollInterval := flag.Duration("poll-interval", preview.DefaultPollInterval, "how often the status of the job is polled")
+	endpointURL := flag.String("cos-endpoint", "", "the URL of the Cloud Object Storage endpoint to use instead of the one of the result location")
+	flag.Parse()
+
+	if *table == "" {
+		fmt.Fprintln(os.Stderr, "sqlpreview: -table is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+	previewOptions := &preview.Options{
+		Where:         *where,
+		SamplePercent: *sample,
+		Random:        *random,
+		PollInterval:  *pollInterval,
+	}
+	if _, err := preview.SampleStatement(*table, *n, previewOptions); err != nil {
+		fmt.Fprintf(os.Stderr, "sqlpreview: %s\n", err.Error())
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(configOptions, *table, *n, previewOptions, *maxWidth, *endpointURL); err != nil {
+		fmt.Fprintf(os.Stderr, "sqlpreview: %s\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+func run(configOptions *config.Options, table string, n int, previewOptions *preview.Options, maxWidth int, endpointURL string) error {
+	sql, _, err := config.LoadConfig(configOptions)
+	if err != nil {
+		return err
+	}
+	previewOptions.Storage, err = cos.NewClient(&cos.Options{
+		Authenticator: sql.Service.Options.Authenticator,
+		EndpointURL:   endpointURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	result, err := preview.PreviewTable(context.Background(), sql, table, n, previewOptions)
+	if err != nil {
+		return err
+	}
+	if err = preview.WriteTable(os.Stdout, result, maxWidth); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "sqlpreview: job %s in %s\n", *result.Job.JobID, time.Since(start).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,484 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package config : Named client profiles read from a YAML or JSON configuration file.
+//
+// A configuration file holds profiles keyed by name:
+//
+//	default_profile: dev
+//	profiles:
+//	  dev:
+//	    instance_crn: "crn:v1:bluemix:public:sql-query:us-south:a/<account>:<instance>::"
+//	    auth:
+//	      type: iam
+//	      apikey: <apikey>
+//	    resultset_target: cos://us-south/my-bucket/results/
+//	    retry:
+//	      max_retries: 3
+//	      max_interval: 30s
+//	    timeout: 1m
+//	    rate_limits:
+//	      "*": {rate: 5, burst: 10}
+//
+// The settings of the selected profile are layered: the file is overridden by the environment variables
+// (SQL_QUERY_INSTANCE_CRN and the other Env_ constants), which are overridden by the settings passed in
+// code with Options.Overrides.
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/crn"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"gopkg.in/yaml.v3"
+)
+
+// The environment variables read by LoadConfig.
+const (
+	Env_ConfigFile       = "SQL_QUERY_CONFIG_FILE"
+	Env_Profile          = "SQL_QUERY_PROFILE"
+	Env_InstanceCrn      = "SQL_QUERY_INSTANCE_CRN"
+	Env_Region           = "SQL_QUERY_REGION"
+	Env_URL              = "SQL_QUERY_URL"
+	Env_AuthType         = "SQL_QUERY_AUTH_TYPE"
+	Env_Apikey           = "SQL_QUERY_APIKEY"
+	Env_AuthURL          = "SQL_QUERY_AUTH_URL"
+	Env_Username         = "SQL_QUERY_USERNAME"
+	Env_Password         = "SQL_QUERY_PASSWORD"
+	Env_BearerToken      = "SQL_QUERY_BEARER_TOKEN"
+	Env_ResultsetTarget  = "SQL_QUERY_RESULTSET_TARGET"
+	Env_MaxRetries       = "SQL_QUERY_MAX_RETRIES"
+	Env_MaxRetryInterval = "SQL_QUERY_MAX_RETRY_INTERVAL"
+	Env_Timeout          = "SQL_QUERY_TIMEOUT"
+	Env_RateLimit        = "SQL_QUERY_RATE_LIMIT"
+	Env_RateLimitBurst   = "SQL_QUERY_RATE_LIMIT_BURST"
+)
+
+// The command line flags registered by Options.RegisterFlags.
+const (
+	Flag_ConfigFile = "config"
+	Flag_Profile    = "profile"
+)
+
+// DefaultProfileName is the profile used when none is selected.
+const DefaultProfileName = "default"
+
+// DefaultConfigFile is the configuration file read when none is set, relative to the home directory. It
+// is skipped if it doesn't exist.
+var DefaultConfigFile = filepath.Join(".sqlquery", "config.yaml")
+
+// File : The content of a configuration file.
+type File struct {
+	// The profile used when none is selected with Options.ProfileName or Env_Profile.
+	DefaultProfile string `json:"default_profile,omitempty" yaml:"default_profile,omitempty"`
+
+	// The profiles keyed by name.
+	Profiles map[string]*Profile `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+}
+
+// Profile : The settings of a client. Zero values are unset and don't override the lower layers.
+type Profile struct {
+	// The name of the profile, set by LoadConfig.
+	Name string `json:"-" yaml:"-"`
+
+	// The cloud resource name (CRN) of the SQL query service instance.
+	InstanceCrn string `json:"instance_crn,omitempty" yaml:"instance_crn,omitempty"`
+
+	// The region of the instance. Defaults to the region of InstanceCrn and must match it.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+
+	// The service URL. Defaults to sqlv2.DefaultServiceURL, the global endpoint, whatever the region.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// How requests are authenticated. If no type is set, the authenticator is read by go-sdk-core from the
+	// environment of the service sqlv2.DefaultServiceName.
+	Auth Auth `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// The default Cloud Object Storage location of the results, for example cos://us-south/bucket/results/, or a
+	// template like cos://us-south/bucket/results/{{.Date}}/{{.JobLabel}}/, see sqlv2.ResultsetTargetData.
+	ResultsetTarget string `json:"resultset_target,omitempty" yaml:"resultset_target,omitempty"`
+
+	// The automatic retries of failed requests.
+	Retry Retry `json:"retry,omitempty" yaml:"retry,omitempty"`
+
+	// The timeout of each HTTP request.
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// The rate limits of the operations, keyed by operation ID or sqlv2.RateLimitAllOperations.
+	RateLimits map[string]sqlv2.RateLimit `json:"rate_limits,omitempty" yaml:"rate_limits,omitempty"`
+}
+
+// Auth : The authentication settings of a profile.
+type Auth struct {
+	// The authentication type: iam, basic, bearerToken or noAuth, in any case.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// The API key of the iam type.
+	Apikey string `json:"apikey,omitempty" yaml:"apikey,omitempty"`
+
+	// The URL of the token server of the iam type. Defaults to the IAM production server.
+	URL string `json:"url,omitempty" yaml:"url,omitempty"`
+
+	// The user name and password of the basic type.
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+
+	// The token of the bearerToken type.
+	BearerToken string `json:"bearer_token,omitempty" yaml:"bearer_token,omitempty"`
+}
+
+// Retry : The retry settings of a profile. Retries are enabled when MaxRetries is set.
+type Retry struct {
+	// The maximum number of retries of a request.
+	MaxRetries int `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`
+
+	// The maximum interval between retries.
+	MaxInterval Duration `json:"max_interval,omitempty" yaml:"max_interval,omitempty"`
+}
+
+// Duration : A time.Duration written like "30s" or "1m30s" in configuration files.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (duration Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(duration).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (duration *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("invalid duration %s: %w", data, err)
+	}
+	return duration.parse(text)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (duration Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(duration).String(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (duration *Duration) UnmarshalYAML(value *yaml.Node) error {
+	var text string
+	if err := value.Decode(&text); err != nil {
+		return fmt.Errorf("invalid duration %q: %w", value.Value, err)
+	}
+	return duration.parse(text)
+}
+
+func (duration *Duration) parse(text string) error {
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*duration = Duration(parsed)
+	return nil
+}
+
+// Options : The LoadConfig options.
+type Options struct {
+	// The configuration file. Defaults to Env_ConfigFile, or else to DefaultConfigFile if it exists.
+	File string
+
+	// The name of the profile. Defaults to Env_Profile, or else to the default profile of the file, or else
+	// to DefaultProfileName.
+	ProfileName string
+
+	// The settings overriding those of the profile and of the environment.
+	Overrides *Profile
+
+	// The authenticator to use instead of the auth settings.
+	Authenticator core.Authenticator
+
+	// The options the client is constructed from, for the settings not covered by profiles, like the
+	// Tracer or the Logger. The settings covered by profiles are replaced.
+	ServiceOptions *sqlv2.SqlV2Options
+
+	// Reads the environment variables. Defaults to os.LookupEnv.
+	LookupEnv func(key string) (string, bool)
+}
+
+// RegisterFlags registers the Flag_ConfigFile and Flag_Profile flags setting File and ProfileName.
+func (options *Options) RegisterFlags(flags *flag.FlagSet) {
+	flags.StringVar(&options.File, Flag_ConfigFile, options.File, "the SQL Query configuration file (env "+Env_ConfigFile+")")
+	flags.StringVar(&options.ProfileName, Flag_Profile, options.ProfileName, "the SQL Query configuration profile (env "+Env_Profile+")")
+}
+
+// LoadConfig constructs a client from the selected profile. The returned profile holds the resolved
+// settings, for example the region.
+func LoadConfig(options *Options) (sql *sqlv2.SqlV2, defaults *Profile, err error) {
+	if options == nil {
+		options = &Options{}
+	}
+	defaults, err = ResolveProfile(options)
+	if err != nil {
+		return
+	}
+
+	serviceOptions := &sqlv2.SqlV2Options{}
+	if options.ServiceOptions != nil {
+		*serviceOptions = *options.ServiceOptions
+	}
+	serviceOptions.URL = defaults.URL
+	serviceOptions.InstanceCrn = core.StringPtr(defaults.InstanceCrn)
+	serviceOptions.RateLimits = defaults.RateLimits
+	serviceOptions.DefaultResultsetTarget = defaults.ResultsetTarget
+	serviceOptions.Authenticator = options.Authenticator
+	if serviceOptions.Authenticator == nil {
+		serviceOptions.Authenticator, err = defaults.Auth.authenticator()
+		if err != nil {
+			return
+		}
+	}
+	if serviceOptions.Authenticator == nil {
+		if serviceOptions.ServiceName == "" {
+			serviceOptions.ServiceName = sqlv2.DefaultServiceName
+		}
+		serviceOptions.Authenticator, err = core.GetAuthenticatorFromEnvironment(serviceOptions.ServiceName)
+		if err != nil {
+			return
+		}
+	}
+
+	sql, err = sqlv2.NewSqlV2(serviceOptions)
+	if err != nil {
+		return
+	}
+	if defaults.Retry.MaxRetries > 0 {
+		sql.EnableRetries(defaults.Retry.MaxRetries, time.Duration(defaults.Retry.MaxInterval))
+	}
+	if defaults.Timeout > 0 {
+		sql.Service.GetHTTPClient().Timeout = time.Duration(defaults.Timeout)
+	}
+	return
+}
+
+// ResolveProfile returns the settings of the selected profile, layered with the environment and the
+// overrides, without constructing a client.
+func ResolveProfile(options *Options) (*Profile, error) {
+	lookupEnv := options.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	file, err := readFile(options.File, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	name, selected := options.ProfileName, true
+	if name == "" {
+		name, selected = lookupEnv(Env_Profile)
+	}
+	if name == "" {
+		name, selected = file.DefaultProfile, file.DefaultProfile != ""
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+
+	profile := &Profile{}
+	if fileProfile, ok := file.Profiles[name]; ok && fileProfile != nil {
+		profile.merge(fileProfile)
+	} else if selected {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	environment, err := environmentProfile(lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	profile.merge(environment)
+	if options.Overrides != nil {
+		profile.merge(options.Overrides)
+	}
+	profile.Name = name
+
+	if profile.InstanceCrn == "" {
+		return nil, fmt.Errorf("profile %q: no instance CRN", name)
+	}
+	instance, err := crn.ParseInstance(profile.InstanceCrn)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: invalid instance CRN %q: %w", name, sqlv2.RedactCrn(profile.InstanceCrn), err)
+	}
+	if profile.Region == "" {
+		profile.Region = instance.Region()
+	} else if profile.Region != instance.Region() {
+		return nil, fmt.Errorf("profile %q: the region %q isn't the region %q of the instance", name, profile.Region, instance.Region())
+	}
+	return profile, nil
+}
+
+// readFile reads the configuration file, or returns an empty file if none is set and the default file
+// doesn't exist.
+func readFile(path string, lookupEnv func(key string) (string, bool)) (*File, error) {
+	if path == "" {
+		path, _ = lookupEnv(Env_ConfigFile)
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return &File{}, nil
+		}
+		path = filepath.Join(home, DefaultConfigFile)
+		if _, err := os.Stat(path); err != nil {
+			return &File{}, nil
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &File{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, file)
+	} else {
+		err = yaml.Unmarshal(data, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// environmentProfile returns the settings of the environment variables.
+func environmentProfile(lookupEnv func(key string) (string, bool)) (*Profile, error) {
+	getenv := func(key string) string {
+		value, _ := lookupEnv(key)
+		return value
+	}
+	profile := &Profile{
+		InstanceCrn: getenv(Env_InstanceCrn),
+		Region:      getenv(Env_Region),
+		URL:         getenv(Env_URL),
+		Auth: Auth{
+			Type:        getenv(Env_AuthType),
+			Apikey:      getenv(Env_Apikey),
+			URL:         getenv(Env_AuthURL),
+			Username:    getenv(Env_Username),
+			Password:    getenv(Env_Password),
+			BearerToken: getenv(Env_BearerToken),
+		},
+		ResultsetTarget: getenv(Env_ResultsetTarget),
+	}
+
+	var err error
+	if value := getenv(Env_MaxRetries); value != "" {
+		if profile.Retry.MaxRetries, err = strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", Env_MaxRetries, value, err)
+		}
+	}
+	durations := []struct {
+		key      string
+		duration *Duration
+	}{{Env_MaxRetryInterval, &profile.Retry.MaxInterval}, {Env_Timeout, &profile.Timeout}}
+	for _, duration := range durations {
+		if value := getenv(duration.key); value != "" {
+			if err = duration.duration.parse(value); err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", duration.key, value, err)
+			}
+		}
+	}
+	if value := getenv(Env_RateLimit); value != "" {
+		limit := sqlv2.RateLimit{}
+		if limit.Rate, err = strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", Env_RateLimit, value, err)
+		}
+		if burst := getenv(Env_RateLimitBurst); burst != "" {
+			if limit.Burst, err = strconv.Atoi(burst); err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", Env_RateLimitBurst, burst, err)
+			}
+		}
+		profile.RateLimits = map[string]sqlv2.RateLimit{sqlv2.RateLimitAllOperations: limit}
+	}
+	return profile, nil
+}
+
+// merge overrides the settings of "profile" with the ones set in "other".
+func (profile *Profile) merge(other *Profile) {
+	mergeString(&profile.InstanceCrn, other.InstanceCrn)
+	mergeString(&profile.Region, other.Region)
+	mergeString(&profile.URL, other.URL)
+	if other.Auth.Type != "" && !strings.EqualFold(other.Auth.Type, profile.Auth.Type) {
+		// The credentials of another authentication type don't apply.
+		profile.Auth = Auth{}
+	}
+	mergeString(&profile.Auth.Type, other.Auth.Type)
+	mergeString(&profile.Auth.Apikey, other.Auth.Apikey)
+	mergeString(&profile.Auth.URL, other.Auth.URL)
+	mergeString(&profile.Auth.Username, other.Auth.Username)
+	mergeString(&profile.Auth.Password, other.Auth.Password)
+	mergeString(&profile.Auth.BearerToken, other.Auth.BearerToken)
+	mergeString(&profile.ResultsetTarget, other.ResultsetTarget)
+	if other.Retry.MaxRetries != 0 {
+		profile.Retry.MaxRetries = other.Retry.MaxRetries
+	}
+	if other.Retry.MaxInterval != 0 {
+		profile.Retry.MaxInterval = other.Retry.MaxInterval
+	}
+	if other.Timeout != 0 {
+		profile.Timeout = other.Timeout
+	}
+	if len(other.RateLimits) > 0 {
+		rateLimits := make(map[string]sqlv2.RateLimit, len(profile.RateLimits)+len(other.RateLimits))
+		for operationID, limit := range profile.RateLimits {
+			rateLimits[operationID] = limit
+		}
+		for operationID, limit := range other.RateLimits {
+			rateLimits[operationID] = limit
+		}
+		profile.RateLimits = rateLimits
+	}
+}
+
+func mergeString(value *string, other string) {
+	if other != "" {
+		*value = other
+	}
+}
+
+// authenticator returns the authenticator of the settings, or nil if no type is set.
+func (auth *Auth) authenticator() (authenticator core.Authenticator, err error) {
+	switch strings.ToLower(auth.Type) {
+	case "":
+		return nil, nil
+	case strings.ToLower(core.AUTHTYPE_IAM):
+		authenticator = &core.IamAuthenticator{ApiKey: auth.Apikey, URL: auth.URL}
+	case strings.ToLower(core.AUTHTYPE_BASIC):
+		authenticator = &core.BasicAuthenticator{Username: auth.Username, Password: auth.Password}
+	case strings.ToLower(core.AUTHTYPE_BEARER_TOKEN):
+		authenticator = &core.BearerTokenAuthenticator{BearerToken: auth.BearerToken}
+	case strings.ToLower(core.AUTHTYPE_NOAUTH):
+		authenticator = &core.NoAuthAuthenticator{}
+	default:
+		return nil, fmt.Errorf("unsupported authentication type %q", auth.Type)
+	}
+	err = authenticator.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return
+}
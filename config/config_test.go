@@ -0,0 +1,167 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	devCrn  = "crn:v1:bluemix:public:sql-query:us-south:a/123:dev-instance::"
+	prodCrn = "crn:v1:bluemix:public:sql-query:eu-de:a/123:prod-instance::"
+)
+
+const testConfig = `
+default_profile: dev
+profiles:
+  dev:
+    instance_crn: "` + devCrn + `"
+    url: https://dev.example.com/v2
+    auth:
+      type: noauth
+    resultset_target: cos://us-south/dev-bucket/results/
+    retry:
+      max_retries: 3
+      max_interval: 10s
+    timeout: 1m
+    rate_limits:
+      "*": {rate: 5, burst: 10}
+  prod:
+    instance_crn: "` + prodCrn + `"
+    auth:
+      type: basic
+      username: user
+      password: pass
+`
+
+func writeFile(t *testing.T, name string, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	require.Nil(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func environment(variables map[string]string) func(key string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := variables[key]
+		return value, ok
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeFile(t, "config.yaml", testConfig)
+
+	sql, defaults, err := LoadConfig(&Options{File: path, LookupEnv: environment(nil)})
+	require.Nil(t, err)
+	assert.Equal(t, "dev", defaults.Name)
+	assert.Equal(t, "us-south", defaults.Region)
+	assert.Equal(t, "cos://us-south/dev-bucket/results/", defaults.ResultsetTarget)
+	assert.Equal(t, 3, defaults.Retry.MaxRetries)
+	assert.Equal(t, Duration(10*time.Second), defaults.Retry.MaxInterval)
+	assert.Equal(t, sqlv2.RateLimit{Rate: 5, Burst: 10}, defaults.RateLimits[sqlv2.RateLimitAllOperations])
+	assert.Equal(t, devCrn, *sql.InstanceCrn)
+	assert.Equal(t, "https://dev.example.com/v2", sql.GetServiceURL())
+	assert.Equal(t, time.Minute, sql.Service.GetHTTPClient().Timeout)
+	assert.Equal(t, "cos://us-south/dev-bucket/results/", sql.GetDefaultResultsetTarget())
+	assert.IsType(t, &core.NoAuthAuthenticator{}, sql.Service.Options.Authenticator)
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	path := writeFile(t, "config.json", `{"profiles": {"prod": {"instance_crn": "`+prodCrn+`", "timeout": "5s",
+		"auth": {"type": "basic", "username": "user", "password": "pass"}}}}`)
+	env := environment(map[string]string{
+		Env_ConfigFile:      path,
+		Env_Profile:         "prod",
+		Env_ResultsetTarget: "cos://eu-de/env-bucket/",
+		Env_Timeout:         "20s",
+		Env_AuthType:        "iam",
+		Env_Apikey:          "apikey",
+		Env_RateLimit:       "2.5",
+	})
+
+	defaults, err := ResolveProfile(&Options{LookupEnv: env})
+	require.Nil(t, err)
+	assert.Equal(t, "prod", defaults.Name)
+	assert.Equal(t, "eu-de", defaults.Region)
+	assert.Equal(t, "cos://eu-de/env-bucket/", defaults.ResultsetTarget)
+	assert.Equal(t, Duration(20*time.Second), defaults.Timeout)
+	assert.Equal(t, Auth{Type: "iam", Apikey: "apikey"}, defaults.Auth)
+	assert.Equal(t, sqlv2.RateLimit{Rate: 2.5}, defaults.RateLimits[sqlv2.RateLimitAllOperations])
+
+	defaults, err = ResolveProfile(&Options{LookupEnv: env, Overrides: &Profile{
+		ResultsetTarget: "cos://eu-de/code-bucket/",
+		Auth:            Auth{Type: "noAuth"},
+	}})
+	require.Nil(t, err)
+	assert.Equal(t, "cos://eu-de/code-bucket/", defaults.ResultsetTarget)
+	assert.Equal(t, Duration(20*time.Second), defaults.Timeout)
+	assert.Equal(t, Auth{Type: "noAuth"}, defaults.Auth)
+}
+
+func TestLoadConfigWithoutFile(t *testing.T) {
+	sql, defaults, err := LoadConfig(&Options{
+		File:          writeFile(t, "config.yaml", ""),
+		Authenticator: &core.NoAuthAuthenticator{},
+		LookupEnv:     environment(map[string]string{Env_InstanceCrn: devCrn}),
+	})
+	require.Nil(t, err)
+	assert.Equal(t, DefaultProfileName, defaults.Name)
+	assert.Equal(t, sqlv2.DefaultServiceURL, sql.GetServiceURL())
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	path := writeFile(t, "config.yaml", testConfig)
+	for name, options := range map[string]*Options{
+		"unknown profile":  {File: path, ProfileName: "test"},
+		"region mismatch":  {File: path, Overrides: &Profile{Region: "eu-de"}},
+		"invalid crn":      {File: path, Overrides: &Profile{InstanceCrn: "crn:v1:bluemix:public:iam::a/123:x::"}},
+		"auth type":        {File: path, Overrides: &Profile{Auth: Auth{Type: "unknown"}}},
+		"missing apikey":   {File: path, Overrides: &Profile{Auth: Auth{Type: "iam"}}},
+		"missing file":     {File: path + ".missing"},
+		"invalid duration": {File: writeFile(t, "config.yaml", "profiles: {default: {timeout: soon}}")},
+	} {
+		options.LookupEnv = environment(nil)
+		_, _, err := LoadConfig(options)
+		assert.NotNil(t, err, name)
+	}
+
+	_, err := ResolveProfile(&Options{File: path, LookupEnv: environment(map[string]string{Env_MaxRetries: "many"})})
+	assert.EqualError(t, err, `invalid SQL_QUERY_MAX_RETRIES "many": strconv.Atoi: parsing "many": invalid syntax`)
+	_, err = ResolveProfile(&Options{File: writeFile(t, "config.yaml", ""), LookupEnv: environment(nil)})
+	assert.EqualError(t, err, `profile "default": no instance CRN`)
+}
+
+func TestRegisterFlags(t *testing.T) {
+	options := &Options{}
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	options.RegisterFlags(flags)
+	require.Nil(t, flags.Parse([]string{"-config", "config.yaml", "-profile", "prod"}))
+	assert.Equal(t, "config.yaml", options.File)
+	assert.Equal(t, "prod", options.ProfileName)
+}
@@ -0,0 +1,413 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cos : A minimal client for the S3-compatible API of IBM Cloud Object Storage.
+//
+// The client only implements the few object operations that the SDK needs to work with the inputs and
+// results of SQL jobs. Requests are authenticated with a go-sdk-core Authenticator, so the IAM
+// authenticator of a sqlv2.SqlV2 instance can be shared, and can be sent to a local S3 stand-in by
+// setting Options.EndpointURL.
+package cos
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/common"
+)
+
+// Scheme is the URI scheme used by SQL Query for Cloud Object Storage locations.
+const Scheme = "cos://"
+
+// Location : A location in Cloud Object Storage, as written in SQL Query statements:
+// cos://<endpoint>/<bucket>/<key>.
+type Location struct {
+	// The endpoint, either an alias such as "us-geo" or "eu-de", or a host name.
+	Endpoint string
+
+	// The name of the bucket.
+	Bucket string
+
+	// The object key or key prefix. May be empty.
+	Key string
+}
+
+// ParseURI parses a cos:// URI into a Location.
+func ParseURI(uri string) (*Location, error) {
+	if len(uri) < len(Scheme) || !strings.EqualFold(uri[:len(Scheme)], Scheme) {
+		return nil, fmt.Errorf("invalid Cloud Object Storage URI %q: expected the %s scheme", uri, Scheme)
+	}
+	parts := strings.SplitN(uri[len(Scheme):], "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid Cloud Object Storage URI %q: expected %s<endpoint>/<bucket>[/<key>]", uri, Scheme)
+	}
+	location := &Location{Endpoint: parts[0], Bucket: parts[1]}
+	if len(parts) == 3 {
+		location.Key = parts[2]
+	}
+	return location, nil
+}
+
+// String returns the cos:// URI of the location.
+func (location *Location) String() string {
+	return Scheme + location.Endpoint + "/" + location.Bucket + "/" + location.Key
+}
+
+// geoAliases maps the cross-region endpoint aliases accepted by SQL Query to their COS region names.
+var geoAliases = map[string]string{
+	"us-geo": "us",
+	"eu-geo": "eu",
+	"ap-geo": "ap",
+}
+
+// EndpointHost returns the public host name of a COS endpoint alias. Host names are returned unchanged.
+func EndpointHost(endpoint string) string {
+	if strings.Contains(endpoint, ".") {
+		return endpoint
+	}
+	region := strings.ToLower(endpoint)
+	if alias, ok := geoAliases[region]; ok {
+		region = alias
+	}
+	return "s3." + region + ".cloud-object-storage.appdomain.cloud"
+}
+
+// Object : Information about a stored object.
+type Object struct {
+	// The key of the object.
+	Key string
+
+	// The size of the object in bytes.
+	Size int64
+
+	// The entity tag of the object, without quotes.
+	ETag string
+
+	// When the object was last modified.
+	LastModified time.Time
+}
+
+// Error : An error response returned by the object storage service.
+type Error struct {
+	// The HTTP status code of the response.
+	StatusCode int
+
+	// The S3 error code, such as "NoSuchKey".
+	Code string `xml:"Code"`
+
+	// The error message.
+	Message string `xml:"Message"`
+}
+
+// Error returns the error message.
+func (err *Error) Error() string {
+	if err.Code == "" {
+		return fmt.Sprintf("object storage request failed: %s", http.StatusText(err.StatusCode))
+	}
+	return fmt.Sprintf("object storage request failed: %s: %s", err.Code, err.Message)
+}
+
+// IsNotFound returns true if "err" reports that a bucket or an object does not exist.
+func IsNotFound(err error) bool {
+	cosErr, ok := err.(*Error)
+	return ok && cosErr.StatusCode == http.StatusNotFound
+}
+
+// Options : The Client options.
+type Options struct {
+	// The authenticator used for every request, typically a core.IamAuthenticator.
+	Authenticator core.Authenticator
+
+	// The HTTP client used to send requests. Defaults to core.DefaultHTTPClient().
+	HTTPClient *http.Client
+
+	// If set, every request is sent to this base URL, regardless of the endpoint of the locations.
+	// This is useful with a local S3 stand-in.
+	EndpointURL string
+}
+
+// Client sends requests to the S3-compatible API of Cloud Object Storage.
+type Client struct {
+	authenticator core.Authenticator
+	httpClient    *http.Client
+	endpointURL   string
+}
+
+// NewClient : constructs a Client with the passed in options.
+func NewClient(options *Options) (*Client, error) {
+	err := core.ValidateNotNil(options, "options cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if core.IsNil(options.Authenticator) {
+		return nil, fmt.Errorf(core.ERRORMSG_NO_AUTHENTICATOR)
+	}
+	if err = options.Authenticator.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := &Client{
+		authenticator: options.Authenticator,
+		httpClient:    options.HTTPClient,
+		endpointURL:   strings.TrimSuffix(options.EndpointURL, "/"),
+	}
+	if client.httpClient == nil {
+		client.httpClient = core.DefaultHTTPClient()
+	}
+	return client, nil
+}
+
+// ListObjects returns every object whose key starts with the key of "location", ordered by key.
+func (client *Client) ListObjects(ctx context.Context, location *Location) ([]Object, error) {
+	var objects []Object
+	var continuationToken string
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", location.Key)
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		response, err := client.do(ctx, http.MethodGet, &Location{Endpoint: location.Endpoint, Bucket: location.Bucket}, query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(response.Body).Decode(&result)
+		response.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the object listing: %s", err.Error())
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, Object{
+				Key:          content.Key,
+				Size:         content.Size,
+				ETag:         strings.Trim(content.ETag, `"`),
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// HeadObject returns information about the object at "location".
+func (client *Client) HeadObject(ctx context.Context, location *Location) (*Object, error) {
+	response, err := client.do(ctx, http.MethodHead, location, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	response.Body.Close()
+
+	object := &Object{
+		Key:  location.Key,
+		ETag: strings.Trim(response.Header.Get("ETag"), `"`),
+	}
+	object.Size, _ = strconv.ParseInt(response.Header.Get("Content-Length"), 10, 64)
+	object.LastModified, _ = http.ParseTime(response.Header.Get("Last-Modified"))
+	return object, nil
+}
+
+// GetObject returns the contents of the object at "location" starting at byte "offset". If "length" is
+// positive, at most "length" bytes are returned, otherwise the contents up to the end of the object. The
+// caller must close the returned reader. A ranged request fails if the service doesn't return the range
+// requested, rather than the contents of the whole object.
+func (client *Client) GetObject(ctx context.Context, location *Location, offset int64, length int64) (io.ReadCloser, error) {
+	header := http.Header{}
+	if length > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else if offset > 0 {
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	response, err := client.do(ctx, http.MethodGet, location, nil, header, nil)
+	if err != nil {
+		return nil, err
+	}
+	if header.Get("Range") != "" {
+		if err = checkContentRange(response, offset); err != nil {
+			response.Body.Close()
+			return nil, fmt.Errorf("%s: %w", location, err)
+		}
+	}
+	return response.Body, nil
+}
+
+// checkContentRange returns an error if "response" isn't a partial content response starting at "offset".
+func checkContentRange(response *http.Response, offset int64) error {
+	if response.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("ranged request returned status %d instead of %d", response.StatusCode, http.StatusPartialContent)
+	}
+	contentRange := response.Header.Get("Content-Range")
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &start, &end); err != nil || start != offset {
+		return fmt.Errorf("ranged request returned an unexpected content range %q", contentRange)
+	}
+	return nil
+}
+
+// ObjectReader reads an object of a known size with a ranged GET request per read. It implements
+// io.ReaderAt and io.ReadSeeker, for the formats that are read from the end, like Parquet.
+type ObjectReader struct {
+	ctx      context.Context
+	client   *Client
+	location *Location
+	size     int64
+	offset   int64
+}
+
+// NewObjectReader returns an ObjectReader of the object at "location", whose size is "size".
+func (client *Client) NewObjectReader(ctx context.Context, location *Location, size int64) *ObjectReader {
+	return &ObjectReader{ctx: ctx, client: client, location: location, size: size}
+}
+
+// Size returns the size of the object.
+func (reader *ObjectReader) Size() int64 {
+	return reader.size
+}
+
+// ReadAt reads len(p) bytes of the object at "offset".
+func (reader *ObjectReader) ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+	if offset >= reader.size {
+		return 0, io.EOF
+	}
+	length := int64(len(p))
+	if remaining := reader.size - offset; length > remaining {
+		length = remaining
+	}
+	if length == 0 {
+		return 0, nil
+	}
+	body, err := reader.client.GetObject(reader.ctx, reader.location, offset, length)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+	n, err := io.ReadFull(body, p[:length])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Read reads from the current offset.
+func (reader *ObjectReader) Read(p []byte) (int, error) {
+	n, err := reader.ReadAt(p, reader.offset)
+	reader.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek sets the offset of the next Read.
+func (reader *ObjectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += reader.offset
+	case io.SeekEnd:
+		offset += reader.size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+	reader.offset = offset
+	return offset, nil
+}
+
+// DeleteObject deletes the object at "location". Deleting an object that doesn't exist succeeds.
+func (client *Client) DeleteObject(ctx context.Context, location *Location) error {
+	response, err := client.do(ctx, http.MethodDelete, location, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+// listBucketResult : The response of the ListObjectsV2 operation.
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+// requestURL returns the path-style URL of "location".
+func (client *Client) requestURL(location *Location, query url.Values) string {
+	base := client.endpointURL
+	if base == "" {
+		base = "https://" + EndpointHost(location.Endpoint)
+	}
+	path := "/" + location.Bucket
+	if location.Key != "" {
+		path += "/" + location.Key
+	}
+	requestURL := base + (&url.URL{Path: path}).EscapedPath()
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	return requestURL
+}
+
+// do sends an authenticated request and returns the response if its status code reports a success.
+func (client *Client) do(ctx context.Context, method string, location *Location, query url.Values, header http.Header, body io.Reader) (*http.Response, error) {
+	request, err := http.NewRequest(method, client.requestURL(location, query), body)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.Header.Set("User-Agent", common.GetUserAgentInfo())
+	if err = client.authenticator.Authenticate(request); err != nil {
+		return nil, fmt.Errorf(core.ERRORMSG_AUTHENTICATE_ERROR, err.Error())
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return response, nil
+	}
+
+	defer response.Body.Close()
+	cosErr := &Error{StatusCode: response.StatusCode}
+	if body, readErr := ioutil.ReadAll(response.Body); readErr == nil && len(body) > 0 {
+		_ = xml.Unmarshal(body, cosErr)
+	}
+	return nil, cosErr
+}
@@ -0,0 +1,203 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cos_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T, server *costest.Server) *cos.Client {
+	client, err := cos.NewClient(&cos.Options{
+		Authenticator: &core.NoAuthAuthenticator{},
+		EndpointURL:   server.URL,
+	})
+	require.Nil(t, err)
+	return client
+}
+
+func TestParseURI(t *testing.T) {
+	location, err := cos.ParseURI("cos://us-geo/bucket/path/to/object.csv")
+	require.Nil(t, err)
+	assert.Equal(t, "us-geo", location.Endpoint)
+	assert.Equal(t, "bucket", location.Bucket)
+	assert.Equal(t, "path/to/object.csv", location.Key)
+	assert.Equal(t, "cos://us-geo/bucket/path/to/object.csv", location.String())
+
+	location, err = cos.ParseURI("COS://s3.eu-de.cloud-object-storage.appdomain.cloud/bucket")
+	require.Nil(t, err)
+	assert.Equal(t, "", location.Key)
+
+	for _, uri := range []string{"", "s3://us-geo/bucket", "cos://us-geo", "cos://us-geo/", "cos:///bucket"} {
+		_, err = cos.ParseURI(uri)
+		assert.NotNil(t, err, uri)
+	}
+}
+
+func TestEndpointHost(t *testing.T) {
+	assert.Equal(t, "s3.us.cloud-object-storage.appdomain.cloud", cos.EndpointHost("us-geo"))
+	assert.Equal(t, "s3.eu-de.cloud-object-storage.appdomain.cloud", cos.EndpointHost("eu-de"))
+	assert.Equal(t, "s3.private.us.cloud-object-storage.appdomain.cloud", cos.EndpointHost("s3.private.us.cloud-object-storage.appdomain.cloud"))
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := cos.NewClient(nil)
+	assert.NotNil(t, err)
+	_, err = cos.NewClient(&cos.Options{})
+	assert.NotNil(t, err)
+	_, err = cos.NewClient(&cos.Options{Authenticator: &core.BasicAuthenticator{}})
+	assert.NotNil(t, err)
+}
+
+func TestListObjects(t *testing.T) {
+	server := costest.NewServer()
+	defer server.Close()
+	server.PageSize = 2
+	modified := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		server.PutObjectAt("bucket", fmt.Sprintf("data/part-%d.csv", i), []byte("a,b\n"), modified)
+	}
+	server.PutObject("bucket", "other/file.csv", []byte("x"))
+	client := newClient(t, server)
+
+	objects, err := client.ListObjects(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "data/"})
+	require.Nil(t, err)
+	require.Len(t, objects, 5)
+	assert.Equal(t, "data/part-0.csv", objects[0].Key)
+	assert.Equal(t, int64(4), objects[0].Size)
+	assert.Len(t, objects[0].ETag, 32)
+	assert.True(t, modified.Equal(objects[0].LastModified))
+
+	_, err = client.ListObjects(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "missing"})
+	assert.True(t, cos.IsNotFound(err))
+	assert.Contains(t, err.Error(), "NoSuchBucket")
+}
+
+func TestHeadObject(t *testing.T) {
+	server := costest.NewServer()
+	defer server.Close()
+	server.PutObject("bucket", "dir/my object.csv", []byte("hello"))
+	client := newClient(t, server)
+
+	object, err := client.HeadObject(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "dir/my object.csv"})
+	require.Nil(t, err)
+	assert.Equal(t, int64(5), object.Size)
+	assert.Equal(t, "5d41402abc4b2a76b9719d911017c592", object.ETag)
+	assert.False(t, object.LastModified.IsZero())
+
+	_, err = client.HeadObject(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "missing"})
+	assert.True(t, cos.IsNotFound(err))
+}
+
+func TestDeleteObject(t *testing.T) {
+	server := costest.NewServer()
+	defer server.Close()
+	server.PutObject("bucket", "dir/a.csv", []byte("a"))
+	server.PutObject("bucket", "dir/b.csv", []byte("b"))
+	client := newClient(t, server)
+
+	err := client.DeleteObject(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "dir/a.csv"})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"dir/b.csv"}, server.Keys("bucket"))
+	assert.Equal(t, "DELETE /bucket/dir/a.csv", server.Requests()[0])
+}
+
+func TestGetObject(t *testing.T) {
+	server := costest.NewServer()
+	defer server.Close()
+	server.PutObject("bucket", "dir/a.csv", []byte("0123456789"))
+	client := newClient(t, server)
+	location := &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "dir/a.csv"}
+
+	for _, test := range []struct {
+		offset, length int64
+		expected       string
+	}{{0, 0, "0123456789"}, {2, 3, "234"}, {7, 0, "789"}, {8, 10, "89"}} {
+		body, err := client.GetObject(context.Background(), location, test.offset, test.length)
+		require.Nil(t, err)
+		data, err := ioutil.ReadAll(body)
+		body.Close()
+		require.Nil(t, err)
+		assert.Equal(t, test.expected, string(data), fmt.Sprint(test.offset, test.length))
+	}
+
+	_, err := client.GetObject(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "missing"}, 0, 0)
+	assert.True(t, cos.IsNotFound(err))
+}
+
+func TestGetObjectIgnoredRange(t *testing.T) {
+	// A server ignoring the Range header returns the whole object, which isn't taken as the range.
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+	client, err := cos.NewClient(&cos.Options{Authenticator: &core.NoAuthAuthenticator{}, EndpointURL: server.URL})
+	require.Nil(t, err)
+	location := &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "dir/a.parquet"}
+
+	_, err = client.GetObject(context.Background(), location, 2, 3)
+	assert.NotNil(t, err)
+	_, err = client.NewObjectReader(context.Background(), location, 10).ReadAt(make([]byte, 4), 2)
+	assert.NotNil(t, err)
+	body, err := client.GetObject(context.Background(), location, 0, 0)
+	require.Nil(t, err)
+	data, err := ioutil.ReadAll(body)
+	body.Close()
+	require.Nil(t, err)
+	assert.Equal(t, "0123456789", string(data))
+}
+
+func TestObjectReader(t *testing.T) {
+	server := costest.NewServer()
+	defer server.Close()
+	server.PutObject("bucket", "dir/a.parquet", []byte("0123456789"))
+	client := newClient(t, server)
+	reader := client.NewObjectReader(context.Background(), &cos.Location{Endpoint: "us-geo", Bucket: "bucket", Key: "dir/a.parquet"}, 10)
+	assert.Equal(t, int64(10), reader.Size())
+
+	buffer := make([]byte, 4)
+	n, err := reader.ReadAt(buffer, 2)
+	require.Nil(t, err)
+	assert.Equal(t, "2345", string(buffer[:n]))
+	n, err = reader.ReadAt(buffer, 8)
+	assert.Equal(t, io.EOF, err)
+	assert.Equal(t, "89", string(buffer[:n]))
+	_, err = reader.ReadAt(buffer, 10)
+	assert.Equal(t, io.EOF, err)
+
+	offset, err := reader.Seek(-3, io.SeekEnd)
+	require.Nil(t, err)
+	assert.Equal(t, int64(7), offset)
+	data, err := ioutil.ReadAll(reader)
+	require.Nil(t, err)
+	assert.Equal(t, "789", string(data))
+	_, err = reader.Seek(-1, io.SeekStart)
+	assert.NotNil(t, err)
+}
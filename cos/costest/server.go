@@ -0,0 +1,235 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package costest : An in-memory stand-in for the S3-compatible API of Cloud Object Storage, for tests.
+//
+// The Server implements the subset of the API used by the cos package with path-style addressing:
+// ListObjectsV2, HEAD, GET (including byte ranges), PUT and DELETE of single objects.
+package costest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// object : A stored object.
+type object struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+}
+
+// Server is an in-memory object store served over HTTP.
+type Server struct {
+	// The base URL of the server, to be used as cos.Options.EndpointURL.
+	URL string
+
+	// The number of objects returned per ListObjectsV2 page. Defaults to 1000.
+	PageSize int
+
+	server   *httptest.Server
+	mutex    sync.Mutex
+	buckets  map[string]map[string]*object
+	requests []string
+}
+
+// NewServer starts a new Server. It must be closed with Close.
+func NewServer() *Server {
+	server := &Server{
+		PageSize: 1000,
+		buckets:  make(map[string]map[string]*object),
+	}
+	server.server = httptest.NewServer(http.HandlerFunc(server.handle))
+	server.URL = server.server.URL
+	return server
+}
+
+// Close shuts the server down.
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+// PutObject stores an object, creating its bucket if needed.
+func (server *Server) PutObject(bucket string, key string, data []byte) {
+	server.PutObjectAt(bucket, key, data, time.Now())
+}
+
+// PutObjectAt stores an object with the given modification time, creating its bucket if needed.
+func (server *Server) PutObjectAt(bucket string, key string, data []byte, lastModified time.Time) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	sum := md5.Sum(data)
+	if server.buckets[bucket] == nil {
+		server.buckets[bucket] = make(map[string]*object)
+	}
+	server.buckets[bucket][key] = &object{
+		data:         append([]byte(nil), data...),
+		etag:         hex.EncodeToString(sum[:]),
+		lastModified: lastModified.UTC().Truncate(time.Second),
+	}
+}
+
+// GetObject returns the contents of an object.
+func (server *Server) GetObject(bucket string, key string) ([]byte, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	obj, ok := server.buckets[bucket][key]
+	if !ok {
+		return nil, false
+	}
+	return obj.data, true
+}
+
+// Keys returns the keys of the objects of a bucket, in order.
+func (server *Server) Keys(bucket string) []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.sortedKeys(bucket, "")
+}
+
+// Requests returns the method and path of every request received so far, such as "GET /bucket/key".
+func (server *Server) Requests() []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]string(nil), server.requests...)
+}
+
+func (server *Server) sortedKeys(bucket string, prefix string) []string {
+	var keys []string
+	for key := range server.buckets[bucket] {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (server *Server) handle(res http.ResponseWriter, req *http.Request) {
+	server.mutex.Lock()
+	server.requests = append(server.requests, req.Method+" "+req.URL.Path)
+	server.mutex.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	bucket, key := path, ""
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		bucket, key = path[:i], path[i+1:]
+	}
+
+	switch {
+	case key == "" && req.Method == http.MethodGet && req.URL.Query().Get("list-type") == "2":
+		server.list(res, req, bucket)
+	case key != "" && (req.Method == http.MethodGet || req.Method == http.MethodHead):
+		server.get(res, req, bucket, key)
+	case key != "" && req.Method == http.MethodPut:
+		data, _ := ioutil.ReadAll(req.Body)
+		server.PutObject(bucket, key, data)
+		res.WriteHeader(http.StatusOK)
+	case key != "" && req.Method == http.MethodDelete:
+		server.mutex.Lock()
+		delete(server.buckets[bucket], key)
+		server.mutex.Unlock()
+		res.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(res, http.StatusNotImplemented, "NotImplemented", "operation not supported by the stand-in")
+	}
+}
+
+func (server *Server) list(res http.ResponseWriter, req *http.Request, bucket string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if _, ok := server.buckets[bucket]; !ok {
+		writeError(res, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+		return
+	}
+
+	query := req.URL.Query()
+	keys := server.sortedKeys(bucket, query.Get("prefix"))
+	start := 0
+	if token := query.Get("continuation-token"); token != "" {
+		start = sort.SearchStrings(keys, token)
+	}
+	end := start + server.PageSize
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(xml.Header)
+	buffer.WriteString(`<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	fmt.Fprintf(&buffer, "<Name>%s</Name><KeyCount>%d</KeyCount>", escape(bucket), end-start)
+	for _, key := range keys[start:end] {
+		obj := server.buckets[bucket][key]
+		fmt.Fprintf(&buffer, `<Contents><Key>%s</Key><LastModified>%s</LastModified><ETag>"%s"</ETag><Size>%d</Size></Contents>`,
+			escape(key), obj.lastModified.Format(time.RFC3339), obj.etag, len(obj.data))
+	}
+	if end < len(keys) {
+		fmt.Fprintf(&buffer, "<IsTruncated>true</IsTruncated><NextContinuationToken>%s</NextContinuationToken>", escape(keys[end]))
+	} else {
+		buffer.WriteString("<IsTruncated>false</IsTruncated>")
+	}
+	buffer.WriteString("</ListBucketResult>")
+
+	res.Header().Set("Content-Type", "application/xml")
+	_, _ = res.Write(buffer.Bytes())
+}
+
+func (server *Server) get(res http.ResponseWriter, req *http.Request, bucket string, key string) {
+	server.mutex.Lock()
+	obj, ok := server.buckets[bucket][key]
+	server.mutex.Unlock()
+	if !ok {
+		if req.Method == http.MethodHead {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeError(res, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+		return
+	}
+
+	res.Header().Set("ETag", `"`+obj.etag+`"`)
+	res.Header().Set("Content-Type", "application/octet-stream")
+	if req.Method == http.MethodHead {
+		res.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		res.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+		res.WriteHeader(http.StatusOK)
+		return
+	}
+	http.ServeContent(res, req, key, obj.lastModified, bytes.NewReader(obj.data))
+}
+
+func writeError(res http.ResponseWriter, statusCode int, code string, message string) {
+	res.Header().Set("Content-Type", "application/xml")
+	res.WriteHeader(statusCode)
+	fmt.Fprintf(res, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, escape(message))
+}
+
+func escape(text string) string {
+	var buffer bytes.Buffer
+	_ = xml.EscapeText(&buffer, []byte(text))
+	return buffer.String()
+}
@@ -0,0 +1,128 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package crn : Parsing of IBM Cloud resource names (CRNs).
+//
+// A CRN has ten colon-separated segments:
+//
+//	crn:v1:<cname>:<ctype>:<service-name>:<location>:<scope>:<service-instance>:<resource-type>:<resource>
+//
+// The CRN of a SQL Query instance looks like
+// crn:v1:bluemix:public:sql-query:us-south:a/<account>:<instance>:: and is parsed with ParseInstance.
+package crn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ServiceName is the service name of SQL Query instances.
+const ServiceName = "sql-query"
+
+// AccountScopePrefix is the prefix of the scope segment of CRNs scoped to an account.
+const AccountScopePrefix = "a/"
+
+// CRN : A parsed cloud resource name.
+type CRN struct {
+	// The version of the CRN format, "v1".
+	Version string
+
+	// The cloud instance, such as "bluemix".
+	CName string
+
+	// The cloud type, such as "public".
+	CType string
+
+	// The name of the service, such as "sql-query".
+	ServiceName string
+
+	// The location of the resource, a region such as "us-south".
+	Location string
+
+	// The scope of the resource, such as "a/<account>".
+	Scope string
+
+	// The ID of the service instance.
+	ServiceInstance string
+
+	// The type of the resource within the instance. Empty for a service instance.
+	ResourceType string
+
+	// The ID of the resource within the instance. Empty for a service instance.
+	Resource string
+}
+
+// Parse parses a CRN. The errors don't include the CRN, which callers may want to redact.
+func Parse(crn string) (*CRN, error) {
+	segments := strings.Split(crn, ":")
+	if len(segments) != 10 || segments[0] != "crn" {
+		return nil, errors.New("crn: expected 10 segments: crn:v1:<cname>:<ctype>:<service-name>:<location>:<scope>:<service-instance>:<resource-type>:<resource>")
+	}
+	parsed := &CRN{
+		Version:         segments[1],
+		CName:           segments[2],
+		CType:           segments[3],
+		ServiceName:     segments[4],
+		Location:        segments[5],
+		Scope:           segments[6],
+		ServiceInstance: segments[7],
+		ResourceType:    segments[8],
+		Resource:        segments[9],
+	}
+	if parsed.Version != "v1" {
+		return nil, fmt.Errorf("crn: unsupported version %q", parsed.Version)
+	}
+	if parsed.CName == "" || parsed.CType == "" || parsed.ServiceName == "" {
+		return nil, errors.New("crn: the cname, ctype and service name are required")
+	}
+	return parsed, nil
+}
+
+// ParseInstance parses the CRN of a SQL Query instance. The service name must be ServiceName and the
+// location and service instance must be set.
+func ParseInstance(crn string) (*CRN, error) {
+	parsed, err := Parse(crn)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.ServiceName != ServiceName {
+		return nil, fmt.Errorf("crn: the service name is %q instead of %q", parsed.ServiceName, ServiceName)
+	}
+	if parsed.Location == "" || parsed.ServiceInstance == "" {
+		return nil, errors.New("crn: the location and service instance are required")
+	}
+	return parsed, nil
+}
+
+// String returns the CRN in its text form.
+func (crn *CRN) String() string {
+	return strings.Join([]string{"crn", crn.Version, crn.CName, crn.CType, crn.ServiceName, crn.Location,
+		crn.Scope, crn.ServiceInstance, crn.ResourceType, crn.Resource}, ":")
+}
+
+// Region returns the region of the resource, that is its location.
+func (crn *CRN) Region() string {
+	return crn.Location
+}
+
+// AccountID returns the account of the scope, or "" if the CRN isn't scoped to an account.
+func (crn *CRN) AccountID() string {
+	if strings.HasPrefix(crn.Scope, AccountScopePrefix) {
+		return strings.TrimPrefix(crn.Scope, AccountScopePrefix)
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInstance(t *testing.T) {
+	const instanceCrn = "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
+	parsed, err := ParseInstance(instanceCrn)
+	require.Nil(t, err)
+	assert.Equal(t, "v1", parsed.Version)
+	assert.Equal(t, "bluemix", parsed.CName)
+	assert.Equal(t, "public", parsed.CType)
+	assert.Equal(t, ServiceName, parsed.ServiceName)
+	assert.Equal(t, "us-south", parsed.Region())
+	assert.Equal(t, "23a24a3e3fe7a115473f07be1c44bdb5", parsed.AccountID())
+	assert.Equal(t, "d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234", parsed.ServiceInstance)
+	assert.Empty(t, parsed.ResourceType)
+	assert.Empty(t, parsed.Resource)
+	assert.Equal(t, instanceCrn, parsed.String())
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, crn := range []string{
+		"",
+		"testString",
+		"crn:v1:bluemix:public:sql-query:us-south:a/1:instance",
+		"urn:v1:bluemix:public:sql-query:us-south:a/1:instance::",
+		"crn:v2:bluemix:public:sql-query:us-south:a/1:instance::",
+		"crn:v1::public:sql-query:us-south:a/1:instance::",
+	} {
+		_, err := Parse(crn)
+		assert.NotNil(t, err, crn)
+	}
+
+	for _, crn := range []string{
+		"crn:v1:bluemix:public:cloud-object-storage:global:a/1:instance::",
+		"crn:v1:bluemix:public:sql-query::a/1:instance::",
+		"crn:v1:bluemix:public:sql-query:us-south:a/1:::",
+	} {
+		_, err := Parse(crn)
+		assert.Nil(t, err, crn)
+		_, err = ParseInstance(crn)
+		assert.NotNil(t, err, crn)
+	}
+
+	parsed, err := Parse("crn:v1:bluemix:public:iam-identity::s/1:::")
+	require.Nil(t, err)
+	assert.Empty(t, parsed.AccountID())
+}
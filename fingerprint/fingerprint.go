@@ -0,0 +1,182 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fingerprint : Normalizes and fingerprints SQL statements of the Data Engine dialect.
+//
+// Two statements that only differ in their literal values, whitespace, comments, the case of their
+// keywords or the partition values of the Cloud Object Storage paths they read get the same fingerprint,
+// so that "the same query" can be recognised across many jobs:
+//
+//	SELECT * FROM cos://us-geo/bucket/sales/year=2021/ STORED AS PARQUET WHERE id IN (1, 2, 3)
+//	select *
+//	  from cos://us-geo/bucket/sales/year=2022/ stored as parquet where id in (7) -- recent
+//
+// both normalize to
+//
+//	select * from cos://us-geo/bucket/sales/year=?/ stored as parquet where id in (?)
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Placeholder is the text that replaces literals and partition values in normalized statements.
+const Placeholder = "?"
+
+// Normalizer : Configures how statements are normalized. The zero value applies every normalization.
+type Normalizer struct {
+	// Keep literal values instead of replacing them with placeholders.
+	KeepLiterals bool
+
+	// Keep the partition values of Cloud Object Storage paths instead of replacing them with placeholders.
+	KeepPaths bool
+}
+
+// Normalize normalizes "statement" with the default Normalizer.
+func Normalize(statement string) string {
+	return Normalizer{}.Normalize(statement)
+}
+
+// Fingerprint returns the fingerprint of "statement" computed with the default Normalizer.
+func Fingerprint(statement string) string {
+	return Normalizer{}.Fingerprint(statement)
+}
+
+// Fingerprint returns a hex-encoded hash of the normalized form of "statement".
+func (normalizer Normalizer) Fingerprint(statement string) string {
+	sum := sha256.Sum256([]byte(normalizer.Normalize(statement)))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Normalize strips comments, collapses whitespace, lowercases unquoted keywords and identifiers (which are
+// case-insensitive in Data Engine), replaces literals with placeholders, collapses lists of placeholders and
+// replaces the partition values of cos:// paths with placeholders.
+func (normalizer Normalizer) Normalize(statement string) string {
+	var texts []string
+	var kinds []TokenKind
+	for _, token := range Tokenize(statement) {
+		var text string
+		switch token.Kind {
+		case TokenKind_Whitespace, TokenKind_Comment:
+			continue
+		case TokenKind_String, TokenKind_Number:
+			text = token.Text
+			if !normalizer.KeepLiterals {
+				text = Placeholder
+			}
+		case TokenKind_Word:
+			text = strings.ToLower(token.Text)
+		case TokenKind_URI:
+			text = token.Text
+			if !normalizer.KeepPaths {
+				text = NormalizePath(text)
+			}
+		default:
+			text = token.Text
+		}
+		texts = append(texts, text)
+		kinds = append(kinds, token.Kind)
+	}
+	for len(texts) > 0 && texts[len(texts)-1] == ";" {
+		texts = texts[:len(texts)-1]
+	}
+	if !normalizer.KeepLiterals {
+		texts, kinds = collapsePlaceholderLists(texts, kinds)
+	}
+
+	var builder strings.Builder
+	for i, text := range texts {
+		if i > 0 && needsSpace(texts[i-1], kinds[i-1], text) {
+			builder.WriteByte(' ')
+		}
+		builder.WriteString(text)
+	}
+	return builder.String()
+}
+
+// collapsePlaceholderLists replaces parenthesized lists of placeholders, such as "(?, ?, ?)", with "(?)".
+func collapsePlaceholderLists(texts []string, kinds []TokenKind) ([]string, []TokenKind) {
+	var outTexts []string
+	var outKinds []TokenKind
+	for i := 0; i < len(texts); i++ {
+		if texts[i] == "(" {
+			j := i + 1
+			for j+1 < len(texts) && texts[j] == Placeholder && texts[j+1] == "," {
+				j += 2
+			}
+			if j > i+1 && j < len(texts) && texts[j] == Placeholder && j+1 < len(texts) && texts[j+1] == ")" {
+				outTexts = append(outTexts, "(", Placeholder, ")")
+				outKinds = append(outKinds, TokenKind_Punctuation, kinds[j], TokenKind_Punctuation)
+				i = j + 1
+				continue
+			}
+		}
+		outTexts = append(outTexts, texts[i])
+		outKinds = append(outKinds, kinds[i])
+	}
+	return outTexts, outKinds
+}
+
+// spacedKeywords lists the keywords that stay separated from a following parenthesis, which is otherwise
+// attached to the preceding word as in a function call.
+var spacedKeywords = map[string]bool{
+	"all": true, "and": true, "any": true, "as": true, "else": true, "exists": true, "from": true,
+	"in": true, "into": true, "join": true, "not": true, "on": true, "or": true, "over": true,
+	"select": true, "some": true, "then": true, "union": true, "using": true, "values": true,
+	"when": true, "where": true, "with": true,
+}
+
+// needsSpace decides whether normalized tokens are separated by a space.
+func needsSpace(previous string, previousKind TokenKind, current string) bool {
+	switch current {
+	case ")", ",", ".", ";":
+		return false
+	case "(":
+		if previousKind == TokenKind_Word && !spacedKeywords[previous] {
+			return false
+		}
+		return previousKind != TokenKind_Identifier
+	}
+	return previous != "(" && previous != "."
+}
+
+var (
+	partitionSegment = regexp.MustCompile(`^([^=]+)=.*$`)
+	valueSegment     = regexp.MustCompile(`^[0-9][0-9\-_:.T]*$`)
+)
+
+// NormalizePath replaces the partition values of a cos:// path with placeholders. Hive-style segments
+// ("year=2021") keep their column name, and segments that only hold a number or a date ("2021-03-01")
+// are replaced entirely. The endpoint and the bucket are left untouched.
+func NormalizePath(uri string) string {
+	const scheme = "cos://"
+	if !strings.HasPrefix(strings.ToLower(uri), scheme) {
+		return uri
+	}
+	segments := strings.Split(uri[len(scheme):], "/")
+	for i := 2; i < len(segments); i++ {
+		segment := segments[i]
+		if match := partitionSegment.FindStringSubmatch(segment); match != nil {
+			segments[i] = match[1] + "=" + Placeholder
+		} else if valueSegment.MatchString(segment) {
+			segments[i] = Placeholder
+		}
+	}
+	return uri[:len(scheme)] + strings.Join(segments, "/")
+}
@@ -0,0 +1,96 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fingerprint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM cos://us-geo/bucket/sales/year=2021/ STORED AS PARQUET WHERE id IN (1, 2, 3)":        "select * from cos://us-geo/bucket/sales/year=?/ stored as parquet where id in (?)",
+		"select *\n  from cos://us-geo/bucket/sales/year=2022/ stored as parquet where id in (7) -- recent": "select * from cos://us-geo/bucket/sales/year=?/ stored as parquet where id in (?)",
+		"SELECT name, COUNT(*) FROM t WHERE a.b = 'it''s' AND c > 1.5e3D;":                                  "select name, count(*) from t where a.b = ? and c > ?",
+		"SELECT /* a /* nested */ comment */ `Mixed Case` FROM t WHERE x = \"v\\\"w\"":                      "select `Mixed Case` from t where x = ?",
+		"SELECT X'0AFF', DATE '2021-01-01', .5, 10L FROM t":                                                 "select ?, date ?, ?, ? from t",
+		"SELECT * FROM cos://us-geo/b/logs/2021/03/01/part-0001.csv INTO cos://us-geo/b/out/ STORED AS CSV": "select * from cos://us-geo/b/logs/?/?/?/part-0001.csv into cos://us-geo/b/out/ stored as csv",
+		"SELECT a FROM (SELECT a FROM t) WHERE b <=> NULL":                                                  "select a from (select a from t) where b <=> null",
+		"": "",
+	}
+	for statement, expected := range cases {
+		assert.Equal(t, expected, Normalize(statement), statement)
+	}
+}
+
+func TestNormalizerOptions(t *testing.T) {
+	statement := "SELECT * FROM cos://us-geo/b/t/dt=2021-03-01/ WHERE id IN (1, 2)"
+	assert.Equal(t, "select * from cos://us-geo/b/t/dt=2021-03-01/ where id in (1, 2)",
+		Normalizer{KeepLiterals: true, KeepPaths: true}.Normalize(statement))
+	assert.Equal(t, "select * from cos://us-geo/b/t/dt=?/ where id in (1, 2)",
+		Normalizer{KeepLiterals: true}.Normalize(statement))
+	assert.Equal(t, "select * from cos://us-geo/b/t/dt=2021-03-01/ where id in (?)",
+		Normalizer{KeepPaths: true}.Normalize(statement))
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Fingerprint("SELECT * FROM t WHERE id = 1")
+	b := Fingerprint("select *\n\tfrom T where ID=42;")
+	c := Fingerprint("SELECT * FROM t WHERE id > 1")
+	assert.Len(t, a, 32)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+
+	exact := Normalizer{KeepLiterals: true}
+	assert.NotEqual(t, exact.Fingerprint("SELECT * FROM t WHERE id = 1"), exact.Fingerprint("SELECT * FROM t WHERE id = 2"))
+}
+
+func TestTokenize(t *testing.T) {
+	statement := "SELECT 'a' -- c\nFROM cos://us-geo/b/o.csv, `t`"
+	tokens := Tokenize(statement)
+
+	var builder strings.Builder
+	var kinds []TokenKind
+	for _, token := range tokens {
+		builder.WriteString(token.Text)
+		if token.Kind != TokenKind_Whitespace {
+			kinds = append(kinds, token.Kind)
+		}
+	}
+	assert.Equal(t, statement, builder.String())
+	assert.Equal(t, []TokenKind{
+		TokenKind_Word, TokenKind_String, TokenKind_Comment, TokenKind_Word,
+		TokenKind_URI, TokenKind_Punctuation, TokenKind_Identifier,
+	}, kinds)
+
+	uri := tokens[len(tokens)-4]
+	assert.Equal(t, "cos://us-geo/b/o.csv", uri.Text)
+	assert.Equal(t, strings.Index(statement, "cos://"), uri.Offset)
+
+	// Unterminated constructs extend to the end of the statement.
+	for _, statement := range []string{"SELECT 'abc", "SELECT /* abc", "SELECT `abc"} {
+		tokens := Tokenize(statement)
+		assert.Equal(t, "abc", tokens[len(tokens)-1].Text[len(tokens[len(tokens)-1].Text)-3:])
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	assert.Equal(t, "cos://us-geo/2021/jobid=?/part=?/", NormalizePath("cos://us-geo/2021/jobid=abc-123/part=1/"))
+	assert.Equal(t, "s3://bucket/2021/", NormalizePath("s3://bucket/2021/"))
+}
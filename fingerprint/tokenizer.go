@@ -0,0 +1,203 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fingerprint
+
+import (
+	"strings"
+)
+
+// TokenKind : The lexical category of a Token.
+type TokenKind int
+
+// Constants associated with the Token.Kind property.
+const (
+	TokenKind_Whitespace TokenKind = iota
+	TokenKind_Comment
+	TokenKind_String
+	TokenKind_Number
+	TokenKind_Word
+	TokenKind_Identifier
+	TokenKind_URI
+	TokenKind_Punctuation
+)
+
+// Token : A lexical token of an SQL statement.
+type Token struct {
+	// The category of the token.
+	Kind TokenKind
+
+	// The text of the token, exactly as it appears in the statement.
+	Text string
+
+	// The byte offset of the token in the statement.
+	Offset int
+}
+
+// operators lists the punctuation tokens longer than one character, longest first.
+var operators = []string{"<=>", "<=", ">=", "<>", "!=", "==", "||", "->", "::", "&&"}
+
+// Tokenize splits an SQL statement of the Data Engine dialect into tokens. Concatenating the text of all
+// the tokens yields the statement. The tokenizer recognizes comments ("--" and nested "/* */"), string
+// literals in single or double quotes (including X'..' binary literals), numbers with their type suffixes,
+// backquoted identifiers and unquoted cos:// URIs, and never fails: unterminated constructs extend to the
+// end of the statement.
+func Tokenize(statement string) []Token {
+	var tokens []Token
+	for offset := 0; offset < len(statement); {
+		kind, length := scan(statement[offset:])
+		tokens = append(tokens, Token{Kind: kind, Text: statement[offset : offset+length], Offset: offset})
+		offset += length
+	}
+	return tokens
+}
+
+// scan returns the kind and length of the token at the start of "input", which is not empty.
+func scan(input string) (TokenKind, int) {
+	c := input[0]
+	switch {
+	case isSpace(c):
+		i := 1
+		for i < len(input) && isSpace(input[i]) {
+			i++
+		}
+		return TokenKind_Whitespace, i
+	case strings.HasPrefix(input, "--"):
+		i := strings.IndexByte(input, '\n')
+		if i < 0 {
+			return TokenKind_Comment, len(input)
+		}
+		return TokenKind_Comment, i + 1
+	case strings.HasPrefix(input, "/*"):
+		return TokenKind_Comment, scanBlockComment(input)
+	case c == '\'' || c == '"':
+		return TokenKind_String, scanQuoted(input, c, true)
+	case c == '`':
+		return TokenKind_Identifier, scanQuoted(input, c, false)
+	case isDigit(c) || (c == '.' && len(input) > 1 && isDigit(input[1])):
+		return TokenKind_Number, scanNumber(input)
+	case isWordStart(c):
+		if (c == 'x' || c == 'X') && len(input) > 1 && input[1] == '\'' {
+			return TokenKind_String, 1 + scanQuoted(input[1:], '\'', true)
+		}
+		if len(input) > 6 && strings.EqualFold(input[:6], "cos://") {
+			return TokenKind_URI, scanURI(input)
+		}
+		i := 1
+		for i < len(input) && isWordPart(input[i]) {
+			i++
+		}
+		return TokenKind_Word, i
+	}
+	for _, operator := range operators {
+		if strings.HasPrefix(input, operator) {
+			return TokenKind_Punctuation, len(operator)
+		}
+	}
+	return TokenKind_Punctuation, 1
+}
+
+// scanBlockComment returns the length of a possibly nested bracketed comment.
+func scanBlockComment(input string) int {
+	depth := 0
+	for i := 0; i < len(input)-1; i++ {
+		switch {
+		case input[i] == '/' && input[i+1] == '*':
+			depth++
+			i++
+		case input[i] == '*' && input[i+1] == '/':
+			depth--
+			i++
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(input)
+}
+
+// scanQuoted returns the length of a token enclosed in "quote" characters. A doubled quote stands for
+// the quote itself and, if "backslash" is set, a backslash escapes the next character.
+func scanQuoted(input string, quote byte, backslash bool) int {
+	for i := 1; i < len(input); i++ {
+		switch {
+		case backslash && input[i] == '\\':
+			i++
+		case input[i] == quote:
+			if i+1 < len(input) && input[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(input)
+}
+
+// scanNumber returns the length of a numeric literal, including an exponent and a type suffix such as L or BD.
+func scanNumber(input string) int {
+	i := 0
+	for i < len(input) && isDigit(input[i]) {
+		i++
+	}
+	if i < len(input) && input[i] == '.' {
+		i++
+		for i < len(input) && isDigit(input[i]) {
+			i++
+		}
+	}
+	if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+		j := i + 1
+		if j < len(input) && (input[j] == '+' || input[j] == '-') {
+			j++
+		}
+		if j < len(input) && isDigit(input[j]) {
+			i = j
+			for i < len(input) && isDigit(input[i]) {
+				i++
+			}
+		}
+	}
+	for i < len(input) && isWordPart(input[i]) {
+		i++
+	}
+	return i
+}
+
+// scanURI returns the length of an unquoted cos:// URI, which ends at whitespace or at a delimiter.
+func scanURI(input string) int {
+	i := 0
+	for i < len(input) && !isSpace(input[i]) && strings.IndexByte(",;()", input[i]) < 0 {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordPart(c byte) bool {
+	return isWordStart(c) || isDigit(c) || c == '$'
+}
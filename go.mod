@@ -4,11 +4,14 @@ go 1.15
 
 require (
 	github.com/IBM/go-sdk-core/v5 v5.10.2
+	github.com/apache/arrow/go/v7 v7.0.0
 	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d // indirect
 	github.com/fsnotify/fsnotify v1.5.1 // indirect
 	github.com/go-openapi/strfmt v0.21.3
+	github.com/golang/snappy v0.0.4
 	github.com/mitchellh/mapstructure v1.4.3 // indirect
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.19.0
 	github.com/stretchr/testify v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,71 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package metrics : Request and job metrics of the SDK.
+//
+// A Recorder receives an observation for every request sent to the service and for every job seen
+// finishing. Noop, the default, discards them; Prometheus aggregates them into counters and histograms
+// and exposes them in the Prometheus text format. Other monitoring systems can be supported by
+// implementing Recorder.
+package metrics
+
+import "time"
+
+// Request : An observation of a request sent to the service.
+type Request struct {
+	// The ID of the operation, such as "GetSqlJob".
+	Operation string
+
+	// The HTTP status code of the response, or 0 if no response was received.
+	StatusCode int
+
+	// The time taken by the operation, including retries.
+	Duration time.Duration
+
+	// Whether the operation failed.
+	Failed bool
+}
+
+// Job : An observation of a job that finished.
+type Job struct {
+	// The final status of the job, such as "completed" or "failed".
+	Status string
+
+	// The time between the submission of the job and the first time it was seen running. It is zero when
+	// the job wasn't seen running, as it can only be measured while polling.
+	QueueTime time.Duration
+
+	// The time between the submission and the end of the job.
+	RunTime time.Duration
+
+	// The number of bytes read by the job.
+	BytesRead int64
+}
+
+// Recorder receives metric observations. Its methods must be safe for concurrent use.
+type Recorder interface {
+	ObserveRequest(request Request)
+	ObserveJob(job Job)
+}
+
+// Noop is a Recorder that discards every observation.
+type Noop struct{}
+
+// ObserveRequest does nothing.
+func (Noop) ObserveRequest(Request) {}
+
+// ObserveJob does nothing.
+func (Noop) ObserveJob(Job) {}
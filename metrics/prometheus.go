@@ -0,0 +1,269 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultNamespace is the prefix of the metric names when PrometheusOptions.Namespace is empty.
+const DefaultNamespace = "sql_query"
+
+// The default histogram buckets.
+var (
+	// Request durations, in seconds.
+	DefaultRequestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// Job queue and run times, in seconds.
+	DefaultJobTimeBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600, 7200}
+
+	// Bytes read by jobs.
+	DefaultBytesBuckets = []float64{1e3, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12}
+)
+
+// PrometheusOptions : The Prometheus options.
+type PrometheusOptions struct {
+	// The prefix of the metric names. Defaults to DefaultNamespace.
+	Namespace string
+
+	// The histogram buckets. Each defaults to the matching Default*Buckets.
+	RequestBuckets []float64
+	JobTimeBuckets []float64
+	BytesBuckets   []float64
+}
+
+// Prometheus is a Recorder aggregating the observations into metrics exposed in the Prometheus text format:
+//
+//	<namespace>_requests_total{operation,code}              counter
+//	<namespace>_request_errors_total{operation,code}        counter
+//	<namespace>_request_duration_seconds{operation}         histogram
+//	<namespace>_job_queue_seconds{status}                   histogram
+//	<namespace>_job_run_seconds{status}                     histogram
+//	<namespace>_job_read_bytes{status}                      histogram
+//
+// It is an http.Handler, so it can be served on a metrics endpoint.
+type Prometheus struct {
+	mutex    sync.Mutex
+	families []*family
+	requests *family
+	errors   *family
+	duration *family
+	queue    *family
+	run      *family
+	bytes    *family
+}
+
+// NewPrometheus : constructs a Prometheus recorder. "options" may be nil.
+func NewPrometheus(options *PrometheusOptions) *Prometheus {
+	if options == nil {
+		options = &PrometheusOptions{}
+	}
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	buckets := func(buckets []float64, defaults []float64) []float64 {
+		if len(buckets) == 0 {
+			buckets = defaults
+		}
+		buckets = append([]float64(nil), buckets...)
+		sort.Float64s(buckets)
+		return buckets
+	}
+
+	prometheus := &Prometheus{}
+	add := func(name string, help string, labels []string, buckets []float64) *family {
+		f := &family{
+			name:    namespace + "_" + name,
+			help:    help,
+			labels:  labels,
+			buckets: buckets,
+			series:  make(map[string]*series),
+		}
+		prometheus.families = append(prometheus.families, f)
+		return f
+	}
+	prometheus.requests = add("requests_total", "Requests sent to the SQL Query service.",
+		[]string{"operation", "code"}, nil)
+	prometheus.errors = add("request_errors_total", "Requests to the SQL Query service that failed.",
+		[]string{"operation", "code"}, nil)
+	prometheus.duration = add("request_duration_seconds", "Duration of the requests to the SQL Query service, including retries.",
+		[]string{"operation"}, buckets(options.RequestBuckets, DefaultRequestBuckets))
+	prometheus.queue = add("job_queue_seconds", "Time between the submission of SQL jobs and the first time they were seen running.",
+		[]string{"status"}, buckets(options.JobTimeBuckets, DefaultJobTimeBuckets))
+	prometheus.run = add("job_run_seconds", "Time between the submission and the end of SQL jobs.",
+		[]string{"status"}, buckets(options.JobTimeBuckets, DefaultJobTimeBuckets))
+	prometheus.bytes = add("job_read_bytes", "Bytes read by SQL jobs.",
+		[]string{"status"}, buckets(options.BytesBuckets, DefaultBytesBuckets))
+	return prometheus
+}
+
+// ObserveRequest records a request.
+func (prometheus *Prometheus) ObserveRequest(request Request) {
+	prometheus.mutex.Lock()
+	defer prometheus.mutex.Unlock()
+	code := strconv.Itoa(request.StatusCode)
+	prometheus.requests.observe(1, request.Operation, code)
+	if request.Failed {
+		prometheus.errors.observe(1, request.Operation, code)
+	}
+	prometheus.duration.observe(request.Duration.Seconds(), request.Operation)
+}
+
+// ObserveJob records a finished job.
+func (prometheus *Prometheus) ObserveJob(job Job) {
+	prometheus.mutex.Lock()
+	defer prometheus.mutex.Unlock()
+	if job.QueueTime > 0 {
+		prometheus.queue.observe(job.QueueTime.Seconds(), job.Status)
+	}
+	prometheus.run.observe(job.RunTime.Seconds(), job.Status)
+	prometheus.bytes.observe(float64(job.BytesRead), job.Status)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (prometheus *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	writer := bufio.NewWriter(counter)
+	prometheus.mutex.Lock()
+	for _, f := range prometheus.families {
+		f.write(writer)
+	}
+	prometheus.mutex.Unlock()
+	err := writer.Flush()
+	return counter.n, err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (prometheus *Prometheus) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", PrometheusContentType)
+	_, _ = prometheus.WriteTo(res)
+}
+
+// family : A metric and its series, a counter if it has no buckets and a histogram otherwise.
+type family struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series : The values of a metric for a set of label values.
+type series struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (f *family) observe(value float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: labelValues, counts: make([]uint64, len(f.buckets))}
+		f.series[key] = s
+	}
+	s.count++
+	s.sum += value
+	for i, bound := range f.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+}
+
+func (f *family) write(w *bufio.Writer) {
+	if len(f.series) == 0 {
+		return
+	}
+	kind := "counter"
+	if f.buckets != nil {
+		kind = "histogram"
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := f.series[key]
+		labels := f.formatLabels(s.labelValues)
+		if f.buckets == nil {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labels, formatFloat(s.sum))
+			continue
+		}
+		for i, bound := range f.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(s.labelValues, "le", formatFloat(bound)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.formatLabels(s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labels, formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labels, s.count)
+	}
+}
+
+// formatLabels returns the label set of a series, with an optional extra label name and value.
+func (f *family) formatLabels(labelValues []string, extra ...string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, name+`="`+escapeLabelValue(labelValues[i])+`"`)
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, extra[0]+`="`+extra[1]+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (writer *countingWriter) Write(p []byte) (int, error) {
+	n, err := writer.w.Write(p)
+	writer.n += int64(n)
+	return n, err
+}
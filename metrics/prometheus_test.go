@@ -0,0 +1,108 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheus(t *testing.T) {
+	prometheus := NewPrometheus(&PrometheusOptions{
+		Namespace:      "test",
+		RequestBuckets: []float64{1, 0.1},
+		JobTimeBuckets: []float64{60},
+		BytesBuckets:   []float64{1e6},
+	})
+	var recorder Recorder = prometheus
+	recorder.ObserveRequest(Request{Operation: "GetSqlJob", StatusCode: 200, Duration: 50 * time.Millisecond})
+	recorder.ObserveRequest(Request{Operation: "GetSqlJob", StatusCode: 200, Duration: 500 * time.Millisecond})
+	recorder.ObserveRequest(Request{Operation: "GetSqlJob", StatusCode: 404, Duration: 2 * time.Second, Failed: true})
+	recorder.ObserveJob(Job{Status: "completed", QueueTime: 10 * time.Second, RunTime: 90 * time.Second, BytesRead: 2048})
+	recorder.ObserveJob(Job{Status: "failed", RunTime: 5 * time.Second})
+
+	var buffer bytes.Buffer
+	n, err := prometheus.WriteTo(&buffer)
+	require.Nil(t, err)
+	assert.Equal(t, int64(buffer.Len()), n)
+	assert.Equal(t, strings.Join([]string{
+		`# HELP test_requests_total Requests sent to the SQL Query service.`,
+		`# TYPE test_requests_total counter`,
+		`test_requests_total{operation="GetSqlJob",code="200"} 2`,
+		`test_requests_total{operation="GetSqlJob",code="404"} 1`,
+		`# HELP test_request_errors_total Requests to the SQL Query service that failed.`,
+		`# TYPE test_request_errors_total counter`,
+		`test_request_errors_total{operation="GetSqlJob",code="404"} 1`,
+		`# HELP test_request_duration_seconds Duration of the requests to the SQL Query service, including retries.`,
+		`# TYPE test_request_duration_seconds histogram`,
+		`test_request_duration_seconds_bucket{operation="GetSqlJob",le="0.1"} 1`,
+		`test_request_duration_seconds_bucket{operation="GetSqlJob",le="1"} 2`,
+		`test_request_duration_seconds_bucket{operation="GetSqlJob",le="+Inf"} 3`,
+		`test_request_duration_seconds_sum{operation="GetSqlJob"} 2.55`,
+		`test_request_duration_seconds_count{operation="GetSqlJob"} 3`,
+		`# HELP test_job_queue_seconds Time between the submission of SQL jobs and the first time they were seen running.`,
+		`# TYPE test_job_queue_seconds histogram`,
+		`test_job_queue_seconds_bucket{status="completed",le="60"} 1`,
+		`test_job_queue_seconds_bucket{status="completed",le="+Inf"} 1`,
+		`test_job_queue_seconds_sum{status="completed"} 10`,
+		`test_job_queue_seconds_count{status="completed"} 1`,
+		`# HELP test_job_run_seconds Time between the submission and the end of SQL jobs.`,
+		`# TYPE test_job_run_seconds histogram`,
+		`test_job_run_seconds_bucket{status="completed",le="60"} 0`,
+		`test_job_run_seconds_bucket{status="completed",le="+Inf"} 1`,
+		`test_job_run_seconds_sum{status="completed"} 90`,
+		`test_job_run_seconds_count{status="completed"} 1`,
+		`test_job_run_seconds_bucket{status="failed",le="60"} 1`,
+		`test_job_run_seconds_bucket{status="failed",le="+Inf"} 1`,
+		`test_job_run_seconds_sum{status="failed"} 5`,
+		`test_job_run_seconds_count{status="failed"} 1`,
+		`# HELP test_job_read_bytes Bytes read by SQL jobs.`,
+		`# TYPE test_job_read_bytes histogram`,
+		`test_job_read_bytes_bucket{status="completed",le="1e+06"} 1`,
+		`test_job_read_bytes_bucket{status="completed",le="+Inf"} 1`,
+		`test_job_read_bytes_sum{status="completed"} 2048`,
+		`test_job_read_bytes_count{status="completed"} 1`,
+		`test_job_read_bytes_bucket{status="failed",le="1e+06"} 1`,
+		`test_job_read_bytes_bucket{status="failed",le="+Inf"} 1`,
+		`test_job_read_bytes_sum{status="failed"} 0`,
+		`test_job_read_bytes_count{status="failed"} 1`,
+		``,
+	}, "\n"), buffer.String())
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	prometheus := NewPrometheus(nil)
+	prometheus.ObserveRequest(Request{Operation: "List\"Tables\n", StatusCode: 200})
+
+	res := httptest.NewRecorder()
+	prometheus.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, PrometheusContentType, res.Header().Get("Content-Type"))
+	assert.Contains(t, res.Body.String(), `sql_query_requests_total{operation="List\"Tables\n",code="200"} 1`)
+	assert.NotContains(t, res.Body.String(), "sql_query_job_run_seconds")
+}
+
+func TestNoop(t *testing.T) {
+	var recorder Recorder = Noop{}
+	recorder.ObserveRequest(Request{})
+	recorder.ObserveJob(Job{})
+}
@@ -0,0 +1,405 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package notify : Sends HTTP notifications when SQL jobs finish.
+//
+// A Notifier watches SQL jobs with a sqlv2.Watcher and, whenever a job completes or fails, POSTs a
+// signed JSON Payload to every configured URL. Deliveries are retried with exponential backoff, and
+// payloads that could not be delivered are appended to an optional dead-letter file.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/common"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/go-openapi/strfmt"
+)
+
+// Names of the HTTP headers that carry the payload signature.
+const (
+	HeaderSignature = "X-Sql-Query-Signature"
+	HeaderTimestamp = "X-Sql-Query-Timestamp"
+	HeaderEvent     = "X-Sql-Query-Event"
+)
+
+// Default delivery settings.
+const (
+	DefaultMaxAttempts    = 5
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// Payload : The JSON document sent to the configured URLs when a job finishes.
+type Payload struct {
+	// Identifier of the SQL job.
+	JobID string `json:"job_id"`
+
+	// Final status of the job ("completed" or "failed").
+	Status string `json:"status"`
+
+	// ID of the user who submitted the job.
+	UserID string `json:"user_id,omitempty"`
+
+	// Timestamp indicating when the job was accepted by the service.
+	SubmitTime *strfmt.DateTime `json:"submit_time,omitempty"`
+
+	// Timestamp indicating when the job finished processing.
+	EndTime *strfmt.DateTime `json:"end_time,omitempty"`
+
+	// Time between SubmitTime and EndTime, in seconds.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// Number of rows read.
+	RowsRead float64 `json:"rows_read"`
+
+	// Number of bytes read.
+	BytesRead float64 `json:"bytes_read"`
+
+	// Number of rows returned.
+	RowsReturned float64 `json:"rows_returned"`
+
+	// Where the query result is stored.
+	ResultsetLocation string `json:"resultset_location,omitempty"`
+
+	// The error that was encountered while processing the job.
+	Error string `json:"error,omitempty"`
+
+	// Detailed information about the error.
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// NewPayload builds the notification payload for "job".
+func NewPayload(job *sqlv2.SqlJobInfoFull) *Payload {
+	payload := &Payload{
+		JobID:             stringValue(job.JobID),
+		Status:            stringValue(job.Status),
+		UserID:            stringValue(job.UserID),
+		SubmitTime:        job.SubmitTime,
+		EndTime:           job.EndTime,
+		RowsRead:          floatValue(job.RowsRead),
+		BytesRead:         floatValue(job.BytesRead),
+		RowsReturned:      floatValue(job.RowsReturned),
+		ResultsetLocation: stringValue(job.ResultsetLocation),
+		Error:             stringValue(job.Error),
+		ErrorMessage:      stringValue(job.ErrorMessage),
+	}
+	if job.SubmitTime != nil && job.EndTime != nil {
+		duration := time.Time(*job.EndTime).Sub(time.Time(*job.SubmitTime))
+		payload.DurationSeconds = duration.Seconds()
+	}
+	return payload
+}
+
+// Options : The Notifier options.
+type Options struct {
+	// The URLs that receive a POST for every finished job.
+	URLs []string `validate:"required,min=1,dive,url"`
+
+	// The key used to sign payloads with HMAC-SHA256. Payloads are not signed when empty.
+	Secret []byte
+
+	// Identifiers of the jobs to watch. If empty, every job of the instance is watched.
+	JobIDs []string
+
+	// How often the service is polled. Defaults to sqlv2.DefaultWatchInterval.
+	Interval time.Duration
+
+	// The job statuses that trigger a notification. Defaults to "completed" and "failed".
+	Statuses []string
+
+	// The maximum number of delivery attempts per URL. Defaults to DefaultMaxAttempts.
+	MaxAttempts int
+
+	// The delay before the first retry, doubled after each attempt. Defaults to DefaultInitialBackoff.
+	InitialBackoff time.Duration
+
+	// The upper bound for the delay between attempts. Defaults to DefaultMaxBackoff.
+	MaxBackoff time.Duration
+
+	// Path of a file to which undeliverable notifications are appended as JSON lines. Optional.
+	DeadLetterFile string
+
+	// The HTTP client used for deliveries. Defaults to a client with a 30 second timeout.
+	HTTPClient *http.Client
+}
+
+// DeadLetter : An entry of the dead-letter file.
+type DeadLetter struct {
+	// The URL that could not be reached.
+	URL string `json:"url"`
+
+	// The notification that was not delivered.
+	Payload *Payload `json:"payload"`
+
+	// The number of delivery attempts that were made.
+	Attempts int `json:"attempts"`
+
+	// The error of the last attempt.
+	Error string `json:"error"`
+
+	// When the notification was given up on.
+	Time time.Time `json:"time"`
+}
+
+// Notifier posts a signed Payload to a set of URLs whenever a watched SQL job finishes.
+type Notifier struct {
+	watcher  *sqlv2.Watcher
+	options  Options
+	statuses map[string]bool
+	client   *http.Client
+
+	deliveries   sync.WaitGroup
+	deadLetterMu sync.Mutex
+}
+
+// New : constructs a Notifier that uses "sql" to watch jobs.
+func New(sql *sqlv2.SqlV2, options *Options) (*Notifier, error) {
+	err := core.ValidateNotNil(options, "options cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateStruct(options, "options")
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := &Notifier{
+		options:  *options,
+		statuses: make(map[string]bool),
+		client:   options.HTTPClient,
+	}
+	if notifier.options.MaxAttempts <= 0 {
+		notifier.options.MaxAttempts = DefaultMaxAttempts
+	}
+	if notifier.options.InitialBackoff <= 0 {
+		notifier.options.InitialBackoff = DefaultInitialBackoff
+	}
+	if notifier.options.MaxBackoff <= 0 {
+		notifier.options.MaxBackoff = DefaultMaxBackoff
+	}
+	if notifier.client == nil {
+		notifier.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	statuses := options.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{sqlv2.SqlJobInfoFull_Status_Completed, sqlv2.SqlJobInfoFull_Status_Failed}
+	}
+	for _, status := range statuses {
+		notifier.statuses[status] = true
+	}
+
+	notifier.watcher = sqlv2.NewWatcher(sql, &sqlv2.WatcherOptions{
+		Interval: options.Interval,
+		JobIDs:   options.JobIDs,
+	})
+	return notifier, nil
+}
+
+// Watch adds a job to the set of watched jobs.
+func (notifier *Notifier) Watch(jobID string) {
+	notifier.watcher.Watch(jobID)
+}
+
+// Run watches the jobs until "ctx" is done, then waits for the deliveries in flight to finish.
+// Deliveries are cancelled along with "ctx", in which case they end up in the dead-letter file.
+// Polling errors, the errors of the deliveries and those of the dead-letter file are passed to "onError"
+// when it is not nil, one at a time. Run must only be called once.
+func (notifier *Notifier) Run(ctx context.Context, onError func(error)) error {
+	var report func(error)
+	if onError != nil {
+		var errorMu sync.Mutex
+		report = func(err error) {
+			errorMu.Lock()
+			defer errorMu.Unlock()
+			onError(err)
+		}
+	}
+	notifier.watcher.OnEvent(func(event sqlv2.JobEvent) {
+		if event.Type == sqlv2.JobEventType_Submitted || !notifier.statuses[event.NewStatus] {
+			return
+		}
+		notifier.deliveries.Add(1)
+		go func() {
+			defer notifier.deliveries.Done()
+			errs := notifier.Notify(ctx, event.Job)
+			if report != nil {
+				for _, err := range errs {
+					report(err)
+				}
+			}
+		}()
+	})
+	err := notifier.watcher.Run(ctx, report)
+	notifier.deliveries.Wait()
+	return err
+}
+
+// Notify sends the payload for "job" to every configured URL, retrying failed deliveries.
+// It returns the errors of the URLs that could not be reached after all attempts, followed for each of
+// them by the error of the dead-letter file, if the payload could not be appended to it.
+func (notifier *Notifier) Notify(ctx context.Context, job *sqlv2.SqlJobInfoFull) []error {
+	payload := NewPayload(job)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, url := range notifier.options.URLs {
+		attempts, err := notifier.deliver(ctx, url, payload.Status, body)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", url, err.Error()))
+			if err = notifier.deadLetter(url, payload, attempts, err); err != nil {
+				errs = append(errs, fmt.Errorf("%s: dead letter: %w", url, err))
+			}
+		}
+	}
+	return errs
+}
+
+// deliver posts "body" to "url" until it is accepted or the attempts are exhausted.
+func (notifier *Notifier) deliver(ctx context.Context, url string, status string, body []byte) (attempts int, err error) {
+	backoff := notifier.options.InitialBackoff
+	for attempts = 1; ; attempts++ {
+		var retryable bool
+		retryable, err = notifier.post(ctx, url, status, body)
+		if err == nil || !retryable || attempts >= notifier.options.MaxAttempts {
+			return
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			err = ctx.Err()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > notifier.options.MaxBackoff {
+			backoff = notifier.options.MaxBackoff
+		}
+	}
+}
+
+// post makes a single delivery attempt and reports whether a failure is worth retrying.
+func (notifier *Notifier) post(ctx context.Context, url string, status string, body []byte) (retryable bool, err error) {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	request = request.WithContext(ctx)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", common.GetUserAgentInfo())
+	request.Header.Set(HeaderEvent, "job."+status)
+	if len(notifier.options.Secret) > 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		request.Header.Set(HeaderTimestamp, timestamp)
+		request.Header.Set(HeaderSignature, Sign(notifier.options.Secret, timestamp, body))
+	}
+
+	response, err := notifier.client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retryable = response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected response status %s", response.Status)
+}
+
+// deadLetter appends an undeliverable notification to the dead-letter file, if one is configured.
+func (notifier *Notifier) deadLetter(url string, payload *Payload, attempts int, deliveryErr error) error {
+	if notifier.options.DeadLetterFile == "" {
+		return nil
+	}
+	line, err := json.Marshal(&DeadLetter{
+		URL:      url,
+		Payload:  payload,
+		Attempts: attempts,
+		Error:    deliveryErr.Error(),
+		Time:     time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	notifier.deadLetterMu.Lock()
+	defer notifier.deadLetterMu.Unlock()
+	file, err := os.OpenFile(notifier.options.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Sign computes the value of the signature header for a payload sent at "timestamp".
+// The signature is the hex-encoded HMAC-SHA256 of the timestamp, a period and the body, prefixed with "sha256=".
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers of a received notification. Receivers should also reject
+// timestamps that are too old to protect against replayed notifications.
+func Verify(secret []byte, header http.Header, body []byte) bool {
+	timestamp := header.Get(HeaderTimestamp)
+	signature := header.Get(HeaderSignature)
+	if timestamp == "" || signature == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func floatValue(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
@@ -0,0 +1,257 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("s3cr3t")
+
+// receiver records the notifications it gets and answers with the scripted status codes.
+type receiver struct {
+	mutex    sync.Mutex
+	codes    []int
+	payloads []Payload
+	signed   []bool
+	received chan struct{}
+}
+
+func newReceiver(codes ...int) (*receiver, *httptest.Server) {
+	r := &receiver{codes: codes, received: make(chan struct{}, 16)}
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		var payload Payload
+		_ = json.Unmarshal(body, &payload)
+
+		r.mutex.Lock()
+		code := r.codes[0]
+		if len(r.codes) > 1 {
+			r.codes = r.codes[1:]
+		}
+		r.payloads = append(r.payloads, payload)
+		r.signed = append(r.signed, Verify(secret, req.Header, body))
+		r.mutex.Unlock()
+
+		res.WriteHeader(code)
+		r.received <- struct{}{}
+	}))
+	return r, server
+}
+
+func testJob() *sqlv2.SqlJobInfoFull {
+	submitTime, _ := core.ParseDateTime("2022-01-01T12:00:00.000Z")
+	endTime, _ := core.ParseDateTime("2022-01-01T12:01:30.000Z")
+	return &sqlv2.SqlJobInfoFull{
+		JobID:        core.StringPtr("job1"),
+		Status:       core.StringPtr(sqlv2.SqlJobInfoFull_Status_Failed),
+		SubmitTime:   &submitTime,
+		EndTime:      &endTime,
+		RowsRead:     core.Float64Ptr(10),
+		BytesRead:    core.Float64Ptr(2048),
+		Error:        core.StringPtr("SQL-1"),
+		ErrorMessage: core.StringPtr("Syntax error"),
+	}
+}
+
+func TestNewPayload(t *testing.T) {
+	payload := NewPayload(testJob())
+	assert.Equal(t, "job1", payload.JobID)
+	assert.Equal(t, "failed", payload.Status)
+	assert.Equal(t, 90.0, payload.DurationSeconds)
+	assert.Equal(t, 10.0, payload.RowsRead)
+	assert.Equal(t, 2048.0, payload.BytesRead)
+	assert.Equal(t, "SQL-1", payload.Error)
+	assert.Equal(t, "Syntax error", payload.ErrorMessage)
+}
+
+func TestNewValidation(t *testing.T) {
+	_, err := New(nil, nil)
+	assert.NotNil(t, err)
+	_, err = New(nil, &Options{})
+	assert.NotNil(t, err)
+	_, err = New(nil, &Options{URLs: []string{"not a url"}})
+	assert.NotNil(t, err)
+}
+
+func TestNotifyRetriesAndSigns(t *testing.T) {
+	r, server := newReceiver(503, 200)
+	defer server.Close()
+
+	notifier, err := New(nil, &Options{
+		URLs:           []string{server.URL},
+		Secret:         secret,
+		InitialBackoff: time.Millisecond,
+	})
+	require.Nil(t, err)
+
+	errs := notifier.Notify(context.Background(), testJob())
+	assert.Empty(t, errs)
+	require.Len(t, r.payloads, 2)
+	assert.Equal(t, "job1", r.payloads[1].JobID)
+	assert.Equal(t, []bool{true, true}, r.signed)
+}
+
+func TestNotifyDeadLetter(t *testing.T) {
+	r, server := newReceiver(500)
+	defer server.Close()
+	_, rejecting := newReceiver(400)
+	defer rejecting.Close()
+	deadLetterFile := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+
+	notifier, err := New(nil, &Options{
+		URLs:           []string{server.URL, rejecting.URL},
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		DeadLetterFile: deadLetterFile,
+	})
+	require.Nil(t, err)
+
+	errs := notifier.Notify(context.Background(), testJob())
+	assert.Len(t, errs, 2)
+	assert.Len(t, r.payloads, 3)
+	assert.Equal(t, []bool{false, false, false}, r.signed)
+
+	contents, err := ioutil.ReadFile(deadLetterFile)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+	require.Len(t, lines, 2)
+	var entry DeadLetter
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, server.URL, entry.URL)
+	assert.Equal(t, 3, entry.Attempts)
+	assert.Equal(t, "job1", entry.Payload.JobID)
+	require.Nil(t, json.Unmarshal([]byte(lines[1]), &entry))
+	assert.Equal(t, rejecting.URL, entry.URL)
+	assert.Equal(t, 1, entry.Attempts)
+}
+
+func TestRunNotifiesFinishedJobs(t *testing.T) {
+	var mutex sync.Mutex
+	statuses := []string{"running", "completed"}
+	service := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		mutex.Unlock()
+		res.Header().Set("Content-type", "application/json")
+		res.WriteHeader(200)
+		fmt.Fprintf(res, `{"job_id": "job1", "status": "%s", "user_id": "user", "submit_time": "2022-01-01T12:00:00.000Z", "statement": "SELECT 1", "rows_returned": 4}`, status)
+	}))
+	defer service.Close()
+	sqlService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           service.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+	})
+	require.Nil(t, err)
+
+	r, server := newReceiver(200)
+	defer server.Close()
+	notifier, err := New(sqlService, &Options{
+		URLs:     []string{server.URL},
+		Secret:   secret,
+		JobIDs:   []string{"job1"},
+		Interval: 5 * time.Millisecond,
+	})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- notifier.Run(ctx, nil)
+	}()
+	select {
+	case <-r.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification received")
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	require.Len(t, r.payloads, 1)
+	assert.Equal(t, "completed", r.payloads[0].Status)
+	assert.Equal(t, 4.0, r.payloads[0].RowsReturned)
+	assert.True(t, r.signed[0])
+}
+
+func TestRunReportsErrors(t *testing.T) {
+	service := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-type", "application/json")
+		res.WriteHeader(200)
+		fmt.Fprint(res, `{"job_id": "job1", "status": "completed", "user_id": "user", "submit_time": "2022-01-01T12:00:00.000Z", "statement": "SELECT 1"}`)
+	}))
+	defer service.Close()
+	sqlService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           service.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+	})
+	require.Nil(t, err)
+
+	// The delivery is rejected, and the dead-letter file can't be created.
+	r, server := newReceiver(400)
+	defer server.Close()
+	notifier, err := New(sqlService, &Options{
+		URLs:           []string{server.URL},
+		JobIDs:         []string{"job1"},
+		Interval:       5 * time.Millisecond,
+		DeadLetterFile: filepath.Join(t.TempDir(), "missing", "dead-letter.jsonl"),
+	})
+	require.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reported := make(chan error, 16)
+	done := make(chan error)
+	go func() {
+		done <- notifier.Run(ctx, func(err error) { reported <- err })
+	}()
+	var errs []string
+	for len(errs) < 2 {
+		select {
+		case err := <-reported:
+			errs = append(errs, err.Error())
+		case <-time.After(5 * time.Second):
+			t.Fatal("no error reported")
+		}
+	}
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+
+	assert.Len(t, r.payloads, 1)
+	assert.Equal(t, server.URL+": unexpected response status 400 Bad Request", errs[0])
+	assert.True(t, strings.HasPrefix(errs[1], server.URL+": dead letter: open "), errs[1])
+}
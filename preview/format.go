@@ -0,0 +1,134 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package preview
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxWidth is the maximum width of the columns of WriteTable when none is configured.
+const DefaultMaxWidth = 40
+
+// nullText is how WriteTable shows the nulls.
+const nullText = "NULL"
+
+// WriteTable writes the rows of "preview" as a text table with a header, truncating the values longer than
+// "maxWidth" characters, or DefaultMaxWidth if 0. The numbers are aligned to the right.
+func WriteTable(w io.Writer, preview *Preview, maxWidth int) error {
+	if maxWidth <= 0 {
+		maxWidth = DefaultMaxWidth
+	}
+	columns := len(preview.Columns)
+	cells := make([][]string, 0, len(preview.Rows)+1)
+	header := make([]string, columns)
+	for i, column := range preview.Columns {
+		if column.Name != nil {
+			header[i] = truncate(*column.Name, maxWidth)
+		}
+	}
+	cells = append(cells, header)
+	for _, row := range preview.Rows {
+		line := make([]string, columns)
+		for i := range line {
+			var value interface{}
+			if i < len(row) {
+				value = row[i]
+			}
+			line[i] = truncate(formatValue(baseType(columnType(preview.Columns[i])), value), maxWidth)
+		}
+		cells = append(cells, line)
+	}
+
+	widths := make([]int, columns)
+	for _, line := range cells {
+		for i, cell := range line {
+			if width := utf8.RuneCountInString(cell); width > widths[i] {
+				widths[i] = width
+			}
+		}
+	}
+	rightAligned := make([]bool, columns)
+	for i, column := range preview.Columns {
+		switch baseType(columnType(column)) {
+		case "tinyint", "byte", "smallint", "short", "int", "integer", "bigint", "long", "float", "real", "double", "decimal", "numeric", "dec":
+			rightAligned[i] = true
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	for l, line := range cells {
+		for i, cell := range line {
+			if i > 0 {
+				writer.WriteString(" | ")
+			}
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if rightAligned[i] && l > 0 {
+				writer.WriteString(padding + cell)
+			} else if i < columns-1 {
+				writer.WriteString(cell + padding)
+			} else {
+				writer.WriteString(cell)
+			}
+		}
+		writer.WriteString("\n")
+		if l == 0 {
+			for i, width := range widths {
+				if i > 0 {
+					writer.WriteString("-+-")
+				}
+				writer.WriteString(strings.Repeat("-", width))
+			}
+			writer.WriteString("\n")
+		}
+	}
+	fmt.Fprintf(writer, "(%d rows)\n", len(preview.Rows))
+	return writer.Flush()
+}
+
+// formatValue returns the text of a value converted by ConvertValue for a column of "sqlType".
+func formatValue(sqlType string, value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return nullText
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case time.Time:
+		if sqlType == "date" {
+			return value.Format("2006-01-02")
+		}
+		return value.Format("2006-01-02 15:04:05.999999999")
+	case string:
+		// Keep each row on one line.
+		return strings.NewReplacer("\r", `\r`, "\n", `\n`, "\t", `\t`).Replace(value)
+	}
+	return fmt.Sprint(value)
+}
+
+// truncate shortens "text" to "width" characters, ending with an ellipsis when truncated.
+func truncate(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,56 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package preview
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTable(t *testing.T) {
+	preview := &Preview{
+		Columns: []sqlv2.ColumnInformation{
+			{Name: core.StringPtr("id"), Type: core.StringPtr("bigint")},
+			{Name: core.StringPtr("name"), Type: core.StringPtr("string")},
+			{Name: core.StringPtr("day"), Type: core.StringPtr("date")},
+			{Name: core.StringPtr("at"), Type: core.StringPtr("timestamp")},
+		},
+		Rows: [][]interface{}{
+			{int64(1), "alice", time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC), time.Date(2022, 1, 31, 10, 0, 0, 500000000, time.UTC)},
+			{int64(1000), "bob\nsmith the third", nil, nil},
+		},
+	}
+
+	var output bytes.Buffer
+	require.Nil(t, WriteTable(&output, preview, 12))
+	assert.Equal(t, ""+
+		"id   | name         | day        | at\n"+
+		"-----+--------------+------------+-------------\n"+
+		"   1 | alice        | 2022-01-31 | 2022-01-31 …\n"+
+		"1000 | bob\\nsmith … | NULL       | NULL\n"+
+		"(2 rows)\n", output.String())
+
+	output.Reset()
+	require.Nil(t, WriteTable(&output, &Preview{Columns: preview.Columns[:1]}, 0))
+	assert.Equal(t, "id\n--\n(0 rows)\n", output.String())
+}
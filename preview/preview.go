@@ -0,0 +1,361 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package preview : Previewing the rows of catalog tables.
+//
+// PreviewTable reads the columns of a table with GetTable, submits a bounded sampling query, waits for the
+// job and reads its results, converting the values to the types of the columns. WriteTable formats the rows
+// as a text table for a terminal.
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+	"github.com/IBM/sql-query-go-sdk/results"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// DefaultRows is the number of rows previewed when none is requested.
+const DefaultRows = 20
+
+// MaxRows is the maximum number of rows that can be previewed.
+const MaxRows = 10000
+
+// DefaultPollInterval is how often the status of the sampling job is polled when none is configured.
+const DefaultPollInterval = 2 * time.Second
+
+// DefaultJobLabel is the label of the sampling jobs, used to expand the default resultset target of the
+// client.
+const DefaultJobLabel = "preview"
+
+// tableNamePattern matches the names of catalog tables, optionally qualified by a database.
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Options : The PreviewTable options.
+type Options struct {
+	// The client used to read the results of the sampling job.
+	Storage *cos.Client `validate:"required"`
+
+	// A condition on the rows, the text of a WHERE clause without the keyword.
+	Where string
+
+	// The percentage of the table that is sampled with TABLESAMPLE, between 0 and 100. The whole table is read
+	// when 0.
+	SamplePercent float64
+
+	// Return random rows rather than the first rows read.
+	Random bool
+
+	// How often the status of the job is polled. Defaults to DefaultPollInterval.
+	PollInterval time.Duration
+
+	// The label of the job. Defaults to DefaultJobLabel.
+	JobLabel string
+}
+
+// Preview : The rows sampled from a table.
+type Preview struct {
+	// The table as returned by GetTable.
+	Table *sqlv2.TableInformation
+
+	// The sampling job, which has completed.
+	Job *sqlv2.SqlJobInfoFull
+
+	// The columns of the rows: the columns of the table, followed by any other column of the results as
+	// strings.
+	Columns []sqlv2.ColumnInformation
+
+	// The values of the rows, in the order of Columns, typed by ConvertValue.
+	Rows [][]interface{}
+}
+
+// PreviewTable returns at most "n" rows of the catalog table "tableName", or DefaultRows if "n" is 0. It
+// submits a sampling query to the default instance of "sql" and waits for it to complete.
+func PreviewTable(ctx context.Context, sql *sqlv2.SqlV2, tableName string, n int, options *Options) (*Preview, error) {
+	err := core.ValidateNotNil(sql, "sql cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(options, "options cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateStruct(options, "options")
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		n = DefaultRows
+	}
+	statement, err := SampleStatement(tableName, n, options)
+	if err != nil {
+		return nil, err
+	}
+
+	table, _, err := sql.GetTableWithContext(ctx, sql.NewGetTableOptions(tableName))
+	if err != nil {
+		return nil, err
+	}
+	submitSqlJobOptions := sql.NewSubmitSqlJobOptions(statement)
+	submitSqlJobOptions.SetJobLabel(DefaultJobLabel)
+	if options.JobLabel != "" {
+		submitSqlJobOptions.SetJobLabel(options.JobLabel)
+	}
+	submitted, _, err := sql.SubmitSqlJobWithContext(ctx, submitSqlJobOptions)
+	if err != nil {
+		return nil, err
+	}
+	job, err := waitForJob(ctx, sql, *submitted.JobID, options.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &Preview{Table: table, Job: job}
+	reader, err := results.NewReader(ctx, options.Storage, job, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	var rows [][]interface{}
+	for len(rows) < n {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	// Map the columns of the results to the columns of the table, whose names are case insensitive.
+	preview.Columns = append(preview.Columns, table.Columns...)
+	mapping := make([]int, len(preview.Columns))
+	for i := range mapping {
+		mapping[i] = -1
+	}
+	for j, name := range reader.Columns() {
+		found := false
+		for i, column := range table.Columns {
+			if column.Name != nil && strings.EqualFold(*column.Name, name) && mapping[i] < 0 {
+				mapping[i], found = j, true
+				break
+			}
+		}
+		if !found {
+			preview.Columns = append(preview.Columns, sqlv2.ColumnInformation{Name: core.StringPtr(name), Type: core.StringPtr("string")})
+			mapping = append(mapping, j)
+		}
+	}
+	for _, row := range rows {
+		values := make([]interface{}, len(preview.Columns))
+		for i, index := range mapping {
+			if index < 0 || index >= len(row) {
+				continue
+			}
+			values[i], err = ConvertValue(columnType(preview.Columns[i]), row[index])
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", *preview.Columns[i].Name, err)
+			}
+		}
+		preview.Rows = append(preview.Rows, values)
+	}
+	return preview, nil
+}
+
+// SampleStatement returns the query of at most "n" rows of "tableName" submitted by PreviewTable.
+func SampleStatement(tableName string, n int, options *Options) (string, error) {
+	if !tableNamePattern.MatchString(tableName) {
+		return "", fmt.Errorf("invalid table name %q", tableName)
+	}
+	if n < 1 || n > MaxRows {
+		return "", fmt.Errorf("the number of rows must be between 1 and %d", MaxRows)
+	}
+	if options == nil {
+		options = &Options{}
+	}
+	if options.SamplePercent < 0 || options.SamplePercent > 100 {
+		return "", fmt.Errorf("the sample percentage must be between 0 and 100")
+	}
+
+	var statement strings.Builder
+	statement.WriteString("SELECT * FROM `" + strings.Replace(tableName, ".", "`.`", 1) + "`")
+	if options.SamplePercent > 0 {
+		statement.WriteString(" TABLESAMPLE (" + strconv.FormatFloat(options.SamplePercent, 'f', -1, 64) + " PERCENT)")
+	}
+	if where := strings.TrimSpace(options.Where); where != "" {
+		if err := validateWhere(where); err != nil {
+			return "", err
+		}
+		statement.WriteString(" WHERE (" + where + ")")
+	}
+	if options.Random {
+		statement.WriteString(" ORDER BY rand()")
+	}
+	statement.WriteString(" LIMIT " + strconv.Itoa(n))
+	return statement.String(), nil
+}
+
+// validateWhere returns an error if the condition "where" could end the WHERE clause it is put in: if it has
+// comments, semicolons, unbalanced parentheses or an unterminated literal.
+func validateWhere(where string) error {
+	tokens := fingerprint.Tokenize(where)
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case token.Kind == fingerprint.TokenKind_Comment:
+			return fmt.Errorf("invalid condition %q: comments aren't allowed", where)
+		case token.Kind != fingerprint.TokenKind_Punctuation:
+		case token.Text == ";":
+			return fmt.Errorf("invalid condition %q: semicolons aren't allowed", where)
+		case token.Text == "(":
+			depth++
+		case token.Text == ")":
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("invalid condition %q: unbalanced parentheses", where)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("invalid condition %q: unbalanced parentheses", where)
+	}
+	// An unterminated literal extends to the end of the condition, so only the last token can be one: it is
+	// terminated if it doesn't extend over the text that follows it.
+	last := tokens[len(tokens)-1]
+	if last.Kind == fingerprint.TokenKind_String || last.Kind == fingerprint.TokenKind_Identifier {
+		if fingerprint.Tokenize(last.Text + " ")[0].Text != last.Text {
+			return fmt.Errorf("invalid condition %q: unterminated literal", where)
+		}
+	}
+	return nil
+}
+
+// waitForJob polls the status of a job until it has completed, and returns an error if it failed.
+func waitForJob(ctx context.Context, sql *sqlv2.SqlV2, jobID string, interval time.Duration) (*sqlv2.SqlJobInfoFull, error) {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	for {
+		job, _, err := sql.GetSqlJobWithContext(ctx, sql.NewGetSqlJobOptions(jobID))
+		if err != nil {
+			return nil, err
+		}
+		if job.Status != nil && *job.Status == sqlv2.SqlJobInfoFull_Status_Completed {
+			return job, nil
+		}
+		if job.Status != nil && *job.Status == sqlv2.SqlJobInfoFull_Status_Failed {
+			message := "unknown error"
+			if job.ErrorMessage != nil {
+				message = *job.ErrorMessage
+			} else if job.Error != nil {
+				message = *job.Error
+			}
+			return job, fmt.Errorf("job %s failed: %s", jobID, message)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func columnType(column sqlv2.ColumnInformation) string {
+	if column.Type == nil {
+		return "string"
+	}
+	return *column.Type
+}
+
+// baseType returns the lower-case SQL type without its parameters, for example decimal for decimal(10,2).
+func baseType(sqlType string) string {
+	sqlType = strings.ToLower(strings.TrimSpace(sqlType))
+	if i := strings.IndexAny(sqlType, "(<"); i >= 0 {
+		sqlType = strings.TrimSpace(sqlType[:i])
+	}
+	return sqlType
+}
+
+// ConvertValue converts a value of the results, as returned by results.Reader, to the Go type of a column
+// of "sqlType": int64 for the integer types, float64 for float and double, bool for boolean, and time.Time
+// for date and timestamp. The decimals are kept as strings to stay exact, and the complex types are
+// strings of JSON text. The empty strings of CSV results are nulls, except in string columns.
+func ConvertValue(sqlType string, value interface{}) (interface{}, error) {
+	var text string
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		text = value
+	case json.Number:
+		text = value.String()
+	case bool:
+		text = strconv.FormatBool(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		text = string(encoded)
+	}
+
+	switch baseType(sqlType) {
+	case "tinyint", "byte", "smallint", "short", "int", "integer", "bigint", "long":
+		if text == "" {
+			return nil, nil
+		}
+		return strconv.ParseInt(text, 10, 64)
+	case "float", "real", "double":
+		if text == "" {
+			return nil, nil
+		}
+		return strconv.ParseFloat(text, 64)
+	case "boolean":
+		if text == "" {
+			return nil, nil
+		}
+		return strconv.ParseBool(text)
+	case "date", "timestamp":
+		if text == "" {
+			return nil, nil
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999", "2006-01-02"} {
+			if parsed, err := time.Parse(layout, text); err == nil {
+				return parsed, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid %s %q", sqlType, text)
+	case "decimal", "numeric", "dec", "binary":
+		if text == "" {
+			return nil, nil
+		}
+	}
+	return text, nil
+}
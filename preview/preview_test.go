@@ -0,0 +1,212 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testService is a fake service with the table "sales", whose sampling jobs complete after one poll unless
+// failed is set.
+type testService struct {
+	mutex      sync.Mutex
+	statements []string
+	polls      int
+	failed     bool
+}
+
+func newTestService(t *testing.T, service *testService) *sqlv2.SqlV2 {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		service.mutex.Lock()
+		defer service.mutex.Unlock()
+		res.Header().Set("Content-type", "application/json")
+		switch {
+		case req.URL.Path == "/tables/sales":
+			_, _ = res.Write([]byte(`{"name":"sales","type":"TABLE","columns":[{"name":"id","type":"int","nullable":false},` +
+				`{"name":"Region","type":"string"},{"name":"amount","type":"decimal(10,2)"},{"name":"day","type":"date"},` +
+				`{"name":"paid","type":"boolean"},{"name":"rate","type":"double"}]}`))
+		case req.URL.Path == "/tables/missing":
+			res.WriteHeader(http.StatusNotFound)
+			_, _ = res.Write([]byte(`{"errors":[{"code":"not_found","message":"table missing not found"}]}`))
+		case req.URL.Path == "/sql_jobs" && req.Method == http.MethodPost:
+			var body map[string]string
+			_ = json.NewDecoder(req.Body).Decode(&body)
+			service.statements = append(service.statements, body["statement"])
+			_, _ = res.Write([]byte(`{"job_id":"job1","status":"queued"}`))
+		case req.URL.Path == "/sql_jobs/job1":
+			service.polls++
+			result := map[string]interface{}{"job_id": "job1", "status": "running", "user_id": "user", "statement": "SELECT",
+				"submit_time": time.Now().UTC().Format(time.RFC3339)}
+			if service.polls > 1 && service.failed {
+				result["status"] = "failed"
+				result["error_message"] = "Table sales not found"
+			} else if service.polls > 1 {
+				result["status"] = "completed"
+				result["resultset_location"] = "cos://us-geo/bucket/results/jobid=job1"
+				result["resultset_format"] = "csv"
+			}
+			_ = json.NewEncoder(res).Encode(result)
+		default:
+			res.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	sql, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+		URL:           server.URL,
+		Authenticator: &core.NoAuthAuthenticator{},
+		InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"),
+	})
+	require.Nil(t, err)
+	return sql
+}
+
+func newTestStorage(t *testing.T) *cos.Client {
+	server := costest.NewServer()
+	t.Cleanup(server.Close)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.csv", []byte(
+		"id,region,amount,day,paid,rate,extra\n1,EU,12.50,2022-01-31,true,0.5,x\n2,,,,,,\n3,US,-1.00,2022-02-01,false,1e3,y\n"))
+	client, err := cos.NewClient(&cos.Options{Authenticator: &core.NoAuthAuthenticator{}, EndpointURL: server.URL})
+	require.Nil(t, err)
+	return client
+}
+
+func TestPreviewTable(t *testing.T) {
+	service := &testService{}
+	preview, err := PreviewTable(context.Background(), newTestService(t, service), "sales", 2, &Options{
+		Storage:       newTestStorage(t),
+		Where:         "region = 'EU'",
+		SamplePercent: 10,
+		Random:        true,
+		PollInterval:  time.Millisecond,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"SELECT * FROM `sales` TABLESAMPLE (10 PERCENT) WHERE (region = 'EU') ORDER BY rand() LIMIT 2"}, service.statements)
+	assert.Equal(t, 2, service.polls)
+	assert.Equal(t, "completed", *preview.Job.Status)
+	assert.Equal(t, "sales", *preview.Table.Name)
+
+	var names []string
+	for _, column := range preview.Columns {
+		names = append(names, *column.Name)
+	}
+	assert.Equal(t, []string{"id", "Region", "amount", "day", "paid", "rate", "extra"}, names)
+	assert.Equal(t, [][]interface{}{
+		{int64(1), "EU", "12.50", time.Date(2022, 1, 31, 0, 0, 0, 0, time.UTC), true, 0.5, "x"},
+		{int64(2), "", nil, nil, nil, nil, ""},
+	}, preview.Rows)
+}
+
+func TestPreviewTableErrors(t *testing.T) {
+	service := &testService{failed: true}
+	sql := newTestService(t, service)
+	storage := newTestStorage(t)
+
+	_, err := PreviewTable(context.Background(), sql, "sales", 0, nil)
+	assert.NotNil(t, err)
+	_, err = PreviewTable(context.Background(), sql, "sales; DROP TABLE sales", 0, &Options{Storage: storage})
+	assert.NotNil(t, err)
+	_, err = PreviewTable(context.Background(), sql, "missing", 0, &Options{Storage: storage})
+	assert.NotNil(t, err)
+	assert.Empty(t, service.statements)
+
+	_, err = PreviewTable(context.Background(), sql, "sales", 0, &Options{Storage: storage, PollInterval: time.Millisecond})
+	require.NotNil(t, err)
+	assert.Equal(t, "job job1 failed: Table sales not found", err.Error())
+	assert.Equal(t, []string{"SELECT * FROM `sales` LIMIT 20"}, service.statements)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// The job keeps running until the context expires.
+	service.polls = -1000
+	_, err = PreviewTable(ctx, sql, "sales", 0, &Options{Storage: storage, PollInterval: time.Hour})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestSampleStatement(t *testing.T) {
+	statement, err := SampleStatement("db.sales", 5, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `db`.`sales` LIMIT 5", statement)
+	statement, err = SampleStatement("sales", 1, &Options{SamplePercent: 0.5, Where: "  "})
+	require.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `sales` TABLESAMPLE (0.5 PERCENT) LIMIT 1", statement)
+
+	for _, name := range []string{"", "1sales", "a.b.c", "sales`", "sales x"} {
+		_, err = SampleStatement(name, 5, nil)
+		assert.NotNil(t, err, name)
+	}
+	_, err = SampleStatement("sales", MaxRows+1, nil)
+	assert.NotNil(t, err)
+	_, err = SampleStatement("sales", 5, &Options{SamplePercent: 101})
+	assert.NotNil(t, err)
+
+	statement, err = SampleStatement("sales", 5, &Options{Where: "region = ')' AND (`a;b` > 1 OR note = 'x--y')"})
+	require.Nil(t, err)
+	assert.Equal(t, "SELECT * FROM `sales` WHERE (region = ')' AND (`a;b` > 1 OR note = 'x--y')) LIMIT 5", statement)
+	for _, where := range []string{
+		"id = 1 -- comment", "id = 1 /* comment */", "id = 1; DROP TABLE sales", "id = 1) OR (1 = 1",
+		"(id = 1", "id = 1)", "name = 'abc", "name = 'abc\\'", "`name = 1",
+	} {
+		_, err = SampleStatement("sales", 5, &Options{Where: where})
+		assert.NotNil(t, err, where)
+	}
+	_, err = SampleStatement("sales", 5, &Options{Where: "id = 1; "})
+	assert.EqualError(t, err, `invalid condition "id = 1;": semicolons aren't allowed`)
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		sqlType  string
+		value    interface{}
+		expected interface{}
+	}{
+		{"bigint", "42", int64(42)},
+		{"SMALLINT", json.Number("-3"), int64(-3)},
+		{"double", "2.5", 2.5},
+		{"boolean", true, true},
+		{"timestamp", "2022-01-31 10:00:00.5", time.Date(2022, 1, 31, 10, 0, 0, 500000000, time.UTC)},
+		{"decimal(10,2)", "1.10", "1.10"},
+		{"decimal(10,2)", "", nil},
+		{"string", "", ""},
+		{"array<int>", []interface{}{json.Number("1")}, "[1]"},
+		{"int", nil, nil},
+	}
+	for _, test := range tests {
+		value, err := ConvertValue(test.sqlType, test.value)
+		require.Nil(t, err, test.sqlType)
+		assert.Equal(t, test.expected, value, test.sqlType)
+	}
+
+	for _, sqlType := range []string{"int", "double", "boolean", "date"} {
+		_, err := ConvertValue(sqlType, "x")
+		assert.NotNil(t, err, sqlType)
+	}
+	assert.Equal(t, "decimal", baseType(" Decimal (10, 2)"))
+}
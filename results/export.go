@@ -0,0 +1,306 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// The formats of the files written by ExportResults.
+const (
+	ExportFormat_CSV       = "csv"
+	ExportFormat_JSONLines = "jsonl"
+
+	// CSV that Excel opens as UTF-8: with a byte order mark and CRLF line breaks.
+	ExportFormat_ExcelCSV = "excel-csv"
+)
+
+// DefaultProgressRows is the number of rows between two calls of ExportOptions.Progress when none is
+// configured.
+const DefaultProgressRows = 10000
+
+// utf8BOM starts the CSV files written for Excel.
+const utf8BOM = "\ufeff"
+
+// ExportOptions : The ExportResults options.
+type ExportOptions struct {
+	// The format of the results. Defaults to the ResultsetFormat of the job.
+	Format string
+
+	// The size of the ranged GET requests. Defaults to DefaultChunkSize.
+	ChunkSize int64
+
+	// The maximum number of parts fetched at the same time. Defaults to DefaultConcurrency.
+	Concurrency int
+
+	// The maximum number of rows to export. All of them if 0.
+	Limit int64
+
+	// The columns to export, in this order, including partition columns. The columns missing from the
+	// results are exported as nulls. All of them if empty: the CSV files then have the columns of the first
+	// row, so the columns of JSON results that are null in the first row are only exported to JSON Lines.
+	Columns []string
+
+	// If set, called with the number of rows exported and the number of rows expected, RowsReturned
+	// bounded by Limit or 0 if unknown, every ProgressRows rows and once the export is complete.
+	Progress func(rows int64, total int64)
+
+	// The number of rows between two calls of Progress. Defaults to DefaultProgressRows.
+	ProgressRows int64
+}
+
+// ExportResults writes the results of "job", which must have completed, to "dst" in "format", one of the
+// ExportFormat_ constants, in the order of the result objects. It returns the number of rows written. The
+// results are exported to Parquet, with typed columns, by arrowresults.ExportResults.
+func ExportResults(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, dst io.Writer, format string, options *ExportOptions) (int64, error) {
+	if options == nil {
+		options = &ExportOptions{}
+	}
+	buffered := bufio.NewWriter(dst)
+	var writer rowWriter
+	switch strings.ToLower(format) {
+	case ExportFormat_CSV:
+		writer = &csvRowWriter{w: buffered}
+	case ExportFormat_ExcelCSV:
+		writer = &csvRowWriter{w: buffered, excel: true}
+	case ExportFormat_JSONLines:
+		writer = &jsonLinesRowWriter{w: buffered, all: len(options.Columns) == 0}
+	case "parquet":
+		return 0, fmt.Errorf("the results are exported to Parquet by arrowresults.ExportResults")
+	default:
+		return 0, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	fetcher, err := NewFetcher(ctx, storage, job, &FetcherOptions{
+		Format:      options.Format,
+		ChunkSize:   options.ChunkSize,
+		Concurrency: options.Concurrency,
+		Ordered:     true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer fetcher.Close()
+
+	total := fetcher.Total()
+	if options.Limit > 0 && (total == 0 || total > options.Limit) {
+		total = options.Limit
+	}
+	progressRows := options.ProgressRows
+	if progressRows <= 0 {
+		progressRows = DefaultProgressRows
+	}
+
+	columns := options.Columns
+	projection := &projection{}
+	var rows int64
+	for options.Limit <= 0 || rows < options.Limit {
+		row, err := fetcher.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, err
+		}
+		if rows == 0 {
+			if len(columns) == 0 {
+				columns = row.Columns
+			}
+			if err = writer.writeHeader(columns); err != nil {
+				return rows, err
+			}
+		}
+		if err = writer.write(row, projection.values(columns, row)); err != nil {
+			return rows, err
+		}
+		rows++
+		if options.Progress != nil && rows%progressRows == 0 {
+			options.Progress(rows, total)
+		}
+	}
+
+	if rows == 0 {
+		if err = writer.writeHeader(columns); err != nil {
+			return rows, err
+		}
+	}
+	if err = writer.close(); err != nil {
+		return rows, err
+	}
+	if err = buffered.Flush(); err != nil {
+		return rows, err
+	}
+	if options.Progress != nil {
+		options.Progress(rows, total)
+	}
+	return rows, nil
+}
+
+// projection selects the exported values of the rows.
+type projection struct {
+	// The columns of the last row, and the index in its values of each exported column, or -1.
+	rowColumns []string
+	indexes    []int
+}
+
+func (projection *projection) values(columns []string, row *Row) []interface{} {
+	// The columns of the rows of a part only change when new columns are seen.
+	if len(row.Columns) != len(projection.rowColumns) || len(row.Columns) > 0 && &row.Columns[0] != &projection.rowColumns[0] {
+		projection.rowColumns = row.Columns
+		projection.indexes = make([]int, len(columns))
+		for i, column := range columns {
+			projection.indexes[i] = -1
+			for j, rowColumn := range row.Columns {
+				if rowColumn == column {
+					projection.indexes[i] = j
+					break
+				}
+			}
+		}
+	}
+	values := make([]interface{}, len(columns))
+	for i, index := range projection.indexes {
+		if index >= 0 && index < len(row.Values) {
+			values[i] = row.Values[index]
+		}
+	}
+	return values
+}
+
+// formatValue returns the text of a value: strings as is, and the other values in JSON.
+func formatValue(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		text, err := json.Marshal(value)
+		return string(text), err
+	}
+}
+
+// rowWriter writes the exported rows in a format.
+type rowWriter interface {
+	// writeHeader is called once, before the first row.
+	writeHeader(columns []string) error
+
+	// write writes "values", the values of the exported columns of "row".
+	write(row *Row, values []interface{}) error
+
+	close() error
+}
+
+// csvRowWriter writes CSV with a header line. Null values are written as empty fields.
+type csvRowWriter struct {
+	w      io.Writer
+	excel  bool
+	writer *csv.Writer
+	fields []string
+}
+
+func (writer *csvRowWriter) writeHeader(columns []string) error {
+	if writer.excel {
+		if _, err := io.WriteString(writer.w, utf8BOM); err != nil {
+			return err
+		}
+	}
+	writer.writer = csv.NewWriter(writer.w)
+	writer.writer.UseCRLF = writer.excel
+	writer.fields = make([]string, len(columns))
+	if len(columns) == 0 {
+		// There were no rows to learn the columns from.
+		return nil
+	}
+	return writer.writer.Write(columns)
+}
+
+func (writer *csvRowWriter) write(row *Row, values []interface{}) error {
+	for i, value := range values {
+		writer.fields[i] = ""
+		if value != nil {
+			text, err := formatValue(value)
+			if err != nil {
+				return err
+			}
+			writer.fields[i] = text
+		}
+	}
+	return writer.writer.Write(writer.fields)
+}
+
+func (writer *csvRowWriter) close() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+// jsonLinesRowWriter writes a JSON object per row, with the members in the order of the columns. Without
+// projection, every column of each row is written.
+type jsonLinesRowWriter struct {
+	w       io.Writer
+	all     bool
+	columns []string
+	line    bytes.Buffer
+}
+
+func (writer *jsonLinesRowWriter) writeHeader(columns []string) error {
+	writer.columns = columns
+	return nil
+}
+
+func (writer *jsonLinesRowWriter) write(row *Row, values []interface{}) error {
+	columns := writer.columns
+	if writer.all {
+		columns, values = row.Columns, row.Values
+	}
+	writer.line.Reset()
+	writer.line.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			writer.line.WriteByte(',')
+		}
+		name, _ := json.Marshal(column)
+		writer.line.Write(name)
+		writer.line.WriteByte(':')
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+		text, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		writer.line.Write(text)
+	}
+	writer.line.WriteString("}\n")
+	_, err := writer.w.Write(writer.line.Bytes())
+	return err
+}
+
+func (writer *jsonLinesRowWriter) close() error {
+	return nil
+}
@@ -0,0 +1,95 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCSV(t *testing.T) {
+	server, storage := newStorage(t)
+	putCSVParts(t, server)
+
+	var file bytes.Buffer
+	rows, err := ExportResults(context.Background(), storage, newJob("csv", 4), &file, ExportFormat_CSV, nil)
+	require.Nil(t, err)
+	assert.Equal(t, int64(4), rows)
+	assert.Equal(t, "id,name\n1,alice\n2,\"bob\nsmith\"\n3,carol\n4,dave\n", file.String())
+
+	file.Reset()
+	rows, err = ExportResults(context.Background(), storage, newJob("csv", 4), &file, ExportFormat_ExcelCSV, &ExportOptions{Limit: 2})
+	require.Nil(t, err)
+	assert.Equal(t, int64(2), rows)
+	assert.Equal(t, "\ufeffid,name\r\n1,alice\r\n2,\"bob\r\nsmith\"\r\n", file.String())
+}
+
+func TestExportJSONLines(t *testing.T) {
+	server, storage := newStorage(t)
+	putCSVParts(t, server)
+
+	var progress [][2]int64
+	var file bytes.Buffer
+	rows, err := ExportResults(context.Background(), storage, newJob("csv", 4), &file, ExportFormat_JSONLines, &ExportOptions{
+		Limit:        3,
+		Columns:      []string{"name", "id", "missing"},
+		ProgressRows: 2,
+		Progress:     func(rows int64, total int64) { progress = append(progress, [2]int64{rows, total}) },
+	})
+	require.Nil(t, err)
+	assert.Equal(t, int64(3), rows)
+	assert.Equal(t, `{"name":"alice","id":"1","missing":null}
+{"name":"bob\nsmith","id":"2","missing":null}
+{"name":"carol","id":"3","missing":null}
+`, file.String())
+	assert.Equal(t, [][2]int64{{2, 3}, {3, 3}}, progress)
+
+	// Without projection, every column of each row of JSON results is exported.
+	server.PutObject("bucket", "results/jobid=job2/part-00000.json", []byte(`{"id":1}`+"\n"+`{"id":2,"tags":["a"]}`+"\n"))
+	job := newJob("json", 2)
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	file.Reset()
+	_, err = ExportResults(context.Background(), storage, job, &file, ExportFormat_JSONLines, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2,\"tags\":[\"a\"]}\n", file.String())
+
+	file.Reset()
+	_, err = ExportResults(context.Background(), storage, job, &file, ExportFormat_CSV, nil)
+	require.Nil(t, err)
+	assert.Equal(t, "id\n1\n2\n", file.String())
+}
+
+func TestExportErrors(t *testing.T) {
+	server, storage := newStorage(t)
+	putCSVParts(t, server)
+
+	var file bytes.Buffer
+	_, err := ExportResults(context.Background(), storage, newJob("csv", 4), &file, "xlsx", nil)
+	assert.EqualError(t, err, `unsupported export format "xlsx"`)
+	_, err = ExportResults(context.Background(), storage, newJob("csv", 4), &file, "Parquet", nil)
+	assert.EqualError(t, err, "the results are exported to Parquet by arrowresults.ExportResults")
+
+	server.PutObject("bucket", "results/jobid=job1/part-00002.csv", []byte("id,name\n1\n"))
+	rows, err := ExportResults(context.Background(), storage, newJob("csv", 4), &file, ExportFormat_CSV, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int64(4), rows)
+}
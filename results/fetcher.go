@@ -0,0 +1,276 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// DefaultConcurrency is the number of parts fetched at the same time when none is configured.
+const DefaultConcurrency = 4
+
+// DefaultBufferRows is the number of decoded rows buffered per part when none is configured.
+const DefaultBufferRows = 1024
+
+// FetcherOptions : The Fetcher options.
+type FetcherOptions struct {
+	// The format of the results. Defaults to the ResultsetFormat of the job.
+	Format string
+
+	// The size of the ranged GET requests. Defaults to DefaultChunkSize.
+	ChunkSize int64
+
+	// The maximum number of parts fetched and decoded at the same time. Defaults to DefaultConcurrency.
+	Concurrency int
+
+	// The number of decoded rows buffered per part before its fetching pauses. Defaults to DefaultBufferRows.
+	BufferRows int
+
+	// If true, the rows are returned in the order of the parts and, within a part, in the order of the
+	// part, like a Reader returns them. Otherwise they are returned as soon as they are decoded.
+	Ordered bool
+}
+
+// Row : A row returned by a Fetcher.
+type Row struct {
+	// The index in Parts of the part of the row.
+	Part int
+
+	// The names of the columns of Values. They are the columns read so far from the part, see
+	// Reader.Columns, and must not be modified.
+	Columns []string
+
+	// The values of the row.
+	Values []interface{}
+}
+
+// fetched : A row or the error that ended the reading of a part.
+type fetched struct {
+	row *Row
+	err error
+}
+
+// Fetcher reads the rows of the results of a job, fetching and decoding several parts concurrently. Next
+// isn't safe for concurrent use.
+type Fetcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	parts  []Part
+	total  int64
+	wg     sync.WaitGroup
+
+	// In ordered mode, the rows of each part. Otherwise a single channel receives the rows of every part,
+	// and is closed once every part is read.
+	partRows []chan fetched
+	rows     chan fetched
+
+	current int
+	err     error
+}
+
+// NewFetcher returns a Fetcher of the results of "job", which must have completed, and starts fetching
+// them. The Fetcher must be closed to stop fetching before reading every row.
+func NewFetcher(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, options *FetcherOptions) (*Fetcher, error) {
+	err := core.ValidateNotNil(storage, "storage cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(job, "job cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &FetcherOptions{}
+	}
+	if job.ResultsetLocation == nil {
+		return nil, fmt.Errorf("the job has no resultset location")
+	}
+
+	format := strings.ToLower(options.Format)
+	if format == "" && job.ResultsetFormat != nil {
+		format = strings.ToLower(*job.ResultsetFormat)
+	}
+	if format != Format_CSV && format != Format_JSON {
+		return nil, fmt.Errorf("unsupported result format %q", format)
+	}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	bufferRows := options.BufferRows
+	if bufferRows <= 0 {
+		bufferRows = DefaultBufferRows
+	}
+
+	parts, err := ListParts(ctx, storage, *job.ResultsetLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := &Fetcher{parts: parts}
+	if job.RowsReturned != nil {
+		fetcher.total = int64(*job.RowsReturned)
+	}
+	fetcher.ctx, fetcher.cancel = context.WithCancel(ctx)
+	if options.Ordered {
+		fetcher.partRows = make([]chan fetched, len(parts))
+		for i := range parts {
+			fetcher.partRows[i] = make(chan fetched, bufferRows)
+		}
+	} else {
+		fetcher.rows = make(chan fetched, bufferRows)
+	}
+
+	// The parts are started in order, so that in ordered mode the part being returned is always fetched.
+	fetcher.wg.Add(1)
+	go func() {
+		defer fetcher.wg.Done()
+		var workers sync.WaitGroup
+		slots := make(chan struct{}, concurrency)
+		for i := range parts {
+			select {
+			case slots <- struct{}{}:
+			case <-fetcher.ctx.Done():
+			}
+			if fetcher.ctx.Err() != nil {
+				break
+			}
+			workers.Add(1)
+			go func(index int) {
+				defer workers.Done()
+				fetcher.fetch(storage, format, chunkSize, index)
+				<-slots
+			}(i)
+		}
+		workers.Wait()
+		if fetcher.rows != nil {
+			close(fetcher.rows)
+		}
+	}()
+	return fetcher, nil
+}
+
+// fetch reads the part at "index" and sends its rows.
+func (fetcher *Fetcher) fetch(storage *cos.Client, format string, chunkSize int64, index int) {
+	out := fetcher.rows
+	if out == nil {
+		out = fetcher.partRows[index]
+		defer close(out)
+	}
+	reader := &Reader{
+		ctx:         fetcher.ctx,
+		storage:     storage,
+		format:      format,
+		chunkSize:   chunkSize,
+		parts:       fetcher.parts[index : index+1],
+		columnIndex: make(map[string]int),
+	}
+	defer reader.Close()
+
+	for {
+		values, err := reader.Next()
+		if err == io.EOF {
+			return
+		}
+		result := fetched{err: err}
+		if err == nil {
+			result.row = &Row{Part: index, Columns: reader.Columns(), Values: values}
+		}
+		select {
+		case out <- result:
+		case <-fetcher.ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Parts returns the result objects read.
+func (fetcher *Fetcher) Parts() []Part {
+	return fetcher.parts
+}
+
+// Total returns the number of rows of the results reported by the service, RowsReturned, for progress
+// reporting, or 0 if unknown.
+func (fetcher *Fetcher) Total() int64 {
+	return fetcher.total
+}
+
+// Next returns the next row. It returns io.EOF after the last row, and the first error of any part, after
+// which the Fetcher stops.
+func (fetcher *Fetcher) Next() (*Row, error) {
+	if fetcher.err != nil {
+		return nil, fetcher.err
+	}
+	result, err := fetcher.receive()
+	if err == nil {
+		err = result.err
+	}
+	if err != nil {
+		fetcher.err = err
+		fetcher.cancel()
+		return nil, err
+	}
+	return result.row, nil
+}
+
+func (fetcher *Fetcher) receive() (fetched, error) {
+	for {
+		var rows chan fetched
+		if fetcher.rows != nil {
+			rows = fetcher.rows
+		} else if fetcher.current < len(fetcher.partRows) {
+			rows = fetcher.partRows[fetcher.current]
+		} else {
+			return fetched{}, io.EOF
+		}
+
+		select {
+		case result, ok := <-rows:
+			if ok {
+				return result, nil
+			}
+			if fetcher.rows != nil {
+				return fetched{}, io.EOF
+			}
+			fetcher.current++
+		case <-fetcher.ctx.Done():
+			return fetched{}, fetcher.ctx.Err()
+		}
+	}
+}
+
+// Close stops fetching and waits for the fetching goroutines to end.
+func (fetcher *Fetcher) Close() error {
+	fetcher.cancel()
+	fetcher.wg.Wait()
+	return nil
+}
@@ -0,0 +1,149 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// putPartitionedParts stores 10 parts of 3 rows, partitioned by day and country.
+func putPartitionedParts(server *costest.Server) {
+	server.PutObject("bucket", "results/jobid=job1/_SUCCESS", []byte{})
+	for i := 0; i < 10; i++ {
+		country := []string{"DE", "FR", DefaultPartitionName}[i%3]
+		key := fmt.Sprintf("results/jobid=job1/day=2022-01-%02d%%2001/country=%s/part-%05d.csv", i+1, country, i)
+		data := "id,name\n"
+		for j := 0; j < 3; j++ {
+			data += fmt.Sprintf("%d,name%d\n", i*3+j, i*3+j)
+		}
+		server.PutObject("bucket", key, []byte(data))
+	}
+}
+
+func fetchAll(t *testing.T, fetcher *Fetcher) []*Row {
+	var rows []*Row
+	for {
+		row, err := fetcher.Next()
+		if err == io.EOF {
+			return rows
+		}
+		require.Nil(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestPartitionValues(t *testing.T) {
+	server, storage := newStorage(t)
+	putPartitionedParts(server)
+
+	parts, err := ListParts(context.Background(), storage, jobLocation)
+	require.Nil(t, err)
+	require.Len(t, parts, 10)
+	assert.Equal(t, "day", parts[0].Partition[0].Column)
+	assert.Equal(t, "2022-01-01 01", *parts[0].Partition[0].Value)
+	assert.Equal(t, "DE", *parts[0].Partition[1].Value)
+	assert.Nil(t, parts[2].Partition[1].Value)
+
+	reader, err := NewReader(context.Background(), storage, newJob("csv", 30), nil)
+	require.Nil(t, err)
+	defer reader.Close()
+	rows := readAll(t, reader)
+	assert.Equal(t, []string{"id", "name", "day", "country"}, reader.Columns())
+	assert.Equal(t, []interface{}{"0", "name0", "2022-01-01 01", "DE"}, rows[0])
+	assert.Equal(t, []interface{}{"6", "name6", "2022-01-03 01", nil}, rows[6])
+}
+
+func TestFetchOrdered(t *testing.T) {
+	server, storage := newStorage(t)
+	putPartitionedParts(server)
+
+	fetcher, err := NewFetcher(context.Background(), storage, newJob("csv", 30), &FetcherOptions{Concurrency: 3, BufferRows: 1, Ordered: true})
+	require.Nil(t, err)
+	defer fetcher.Close()
+	assert.Len(t, fetcher.Parts(), 10)
+	assert.Equal(t, int64(30), fetcher.Total())
+
+	rows := fetchAll(t, fetcher)
+	require.Len(t, rows, 30)
+	for i, row := range rows {
+		assert.Equal(t, i/3, row.Part)
+		assert.Equal(t, fmt.Sprint(i), row.Values[0])
+	}
+	assert.Equal(t, []string{"id", "name", "day", "country"}, rows[29].Columns)
+	assert.Equal(t, []interface{}{"29", "name29", "2022-01-10 01", "DE"}, rows[29].Values)
+}
+
+func TestFetchUnordered(t *testing.T) {
+	server, storage := newStorage(t)
+	putPartitionedParts(server)
+
+	fetcher, err := NewFetcher(context.Background(), storage, newJob("csv", 30), &FetcherOptions{Concurrency: 4})
+	require.Nil(t, err)
+	defer fetcher.Close()
+
+	var ids []int
+	for _, row := range fetchAll(t, fetcher) {
+		id, err := strconv.Atoi(row.Values[0].(string))
+		require.Nil(t, err)
+		assert.Equal(t, id/3, row.Part)
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	require.Len(t, ids, 30)
+	for i, id := range ids {
+		assert.Equal(t, i, id)
+	}
+	_, err = fetcher.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestFetchErrors(t *testing.T) {
+	server, storage := newStorage(t)
+	putPartitionedParts(server)
+	server.PutObject("bucket", "results/jobid=job1/day=2022-01-05%2001/part-99999.csv", []byte("id,name\n1\n"))
+
+	for _, ordered := range []bool{true, false} {
+		fetcher, err := NewFetcher(context.Background(), storage, newJob("csv", 30), &FetcherOptions{Ordered: ordered, BufferRows: 1})
+		require.Nil(t, err)
+		for err == nil {
+			_, err = fetcher.Next()
+		}
+		assert.EqualError(t, err, "cos://us-geo/bucket/results/jobid=job1/day=2022-01-05%2001/part-99999.csv: 1 values instead of 2 at offset 8", ordered)
+		_, err = fetcher.Next()
+		assert.NotNil(t, err)
+		assert.Nil(t, fetcher.Close())
+	}
+
+	// Closing before reading every row stops the fetching.
+	fetcher, err := NewFetcher(context.Background(), storage, newJob("csv", 30), &FetcherOptions{Concurrency: 1, BufferRows: 1})
+	require.Nil(t, err)
+	_, err = fetcher.Next()
+	require.Nil(t, err)
+	assert.Nil(t, fetcher.Close())
+
+	_, err = NewFetcher(context.Background(), storage, newJob("orc", 1), nil)
+	assert.EqualError(t, err, `unsupported result format "orc"`)
+}
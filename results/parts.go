@@ -0,0 +1,289 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/golang/snappy"
+)
+
+// The compressions of result objects, see Part.Compression.
+const (
+	Compression_None   = ""
+	Compression_Gzip   = "gzip"
+	Compression_Snappy = "snappy"
+)
+
+// DefaultChunkSize is the size of the ranged GET requests when none is configured.
+const DefaultChunkSize = 8 << 20
+
+// maxSnappyBlockSize bounds the memory used by a corrupt snappy stream.
+const maxSnappyBlockSize = 64 << 20
+
+// Part : A result object holding rows.
+type Part struct {
+	// The location of the object.
+	Location *cos.Location
+
+	// The size of the object in bytes, as stored.
+	Size int64
+
+	// How the object is compressed, one of the Compression_ constants.
+	Compression string
+
+	// The values of the partition directories (col=value/) between the resultset location and the object, in
+	// the order of the path.
+	Partition []PartitionValue
+}
+
+// PartitionValue : The value of a partition column of a part.
+type PartitionValue struct {
+	// The name of the column.
+	Column string
+
+	// The value, or nil for the default partition of null values.
+	Value *string
+}
+
+// DefaultPartitionName is the directory name of the partition of null values.
+const DefaultPartitionName = "__HIVE_DEFAULT_PARTITION__"
+
+// ListParts returns the result objects under "resultsetLocation", ordered by key. Markers, empty objects and
+// the objects whose name starts with '_' or '.', like _SUCCESS, are skipped.
+func ListParts(ctx context.Context, storage *cos.Client, resultsetLocation string) ([]Part, error) {
+	location, err := cos.ParseURI(resultsetLocation)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(location.Key, "/") + "/"
+	if prefix == "/" {
+		prefix = ""
+	}
+	objects, err := storage.ListObjects(ctx, &cos.Location{Endpoint: location.Endpoint, Bucket: location.Bucket, Key: prefix})
+	if err != nil {
+		return nil, err
+	}
+
+	var parts []Part
+	for _, object := range objects {
+		name := path.Base(object.Key)
+		if object.Size == 0 || strings.HasSuffix(object.Key, "/") || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		parts = append(parts, Part{
+			Location:    &cos.Location{Endpoint: location.Endpoint, Bucket: location.Bucket, Key: object.Key},
+			Size:        object.Size,
+			Compression: compressionOf(name),
+			Partition:   partitionOf(strings.TrimPrefix(object.Key, prefix)),
+		})
+	}
+	return parts, nil
+}
+
+// partitionOf returns the partition values of the directories of "relativeKey". The names and values are
+// unescaped like Hive does.
+func partitionOf(relativeKey string) []PartitionValue {
+	segments := strings.Split(relativeKey, "/")
+	var partition []PartitionValue
+	for _, segment := range segments[:len(segments)-1] {
+		i := strings.IndexByte(segment, '=')
+		if i <= 0 {
+			continue
+		}
+		value := &PartitionValue{Column: unescapePartition(segment[:i])}
+		if raw := segment[i+1:]; raw != DefaultPartitionName {
+			unescaped := unescapePartition(raw)
+			value.Value = &unescaped
+		}
+		partition = append(partition, *value)
+	}
+	return partition
+}
+
+func unescapePartition(text string) string {
+	unescaped, err := url.PathUnescape(text)
+	if err != nil {
+		return text
+	}
+	return unescaped
+}
+
+// compressionOf returns the compression of an object from its name.
+func compressionOf(name string) string {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".gz", ".gzip":
+		return Compression_Gzip
+	case ".snappy":
+		return Compression_Snappy
+	default:
+		return Compression_None
+	}
+}
+
+// rangeReader reads an object with ranged GET requests of at most chunkSize bytes, so that no request is
+// left open while the caller doesn't read.
+type rangeReader struct {
+	ctx       context.Context
+	storage   *cos.Client
+	location  *cos.Location
+	offset    int64
+	end       int64
+	chunkSize int64
+	body      io.ReadCloser
+}
+
+func newRangeReader(ctx context.Context, storage *cos.Client, part *Part, offset int64, chunkSize int64) *rangeReader {
+	return &rangeReader{ctx: ctx, storage: storage, location: part.Location, offset: offset, end: part.Size, chunkSize: chunkSize}
+}
+
+func (reader *rangeReader) Read(p []byte) (int, error) {
+	for {
+		if reader.body == nil {
+			if reader.offset >= reader.end {
+				return 0, io.EOF
+			}
+			length := reader.chunkSize
+			if remaining := reader.end - reader.offset; length > remaining {
+				length = remaining
+			}
+			body, err := reader.storage.GetObject(reader.ctx, reader.location, reader.offset, length)
+			if err != nil {
+				return 0, err
+			}
+			reader.body = body
+		}
+
+		n, err := reader.body.Read(p)
+		reader.offset += int64(n)
+		if err == io.EOF {
+			// The chunk is complete: the next read requests the next one.
+			reader.body.Close()
+			reader.body = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (reader *rangeReader) Close() error {
+	if reader.body == nil {
+		return nil
+	}
+	err := reader.body.Close()
+	reader.body = nil
+	return err
+}
+
+// decompress returns a reader of the decompressed contents of "r".
+func decompress(r io.Reader, compression string) (io.Reader, error) {
+	switch compression {
+	case Compression_Gzip:
+		return gzip.NewReader(r)
+	case Compression_Snappy:
+		return newSnappyReader(r)
+	default:
+		return r, nil
+	}
+}
+
+// snappyStreamIdentifier starts the streams of the snappy framing format.
+var snappyStreamIdentifier = []byte("\xff\x06\x00\x00sNaPpY")
+
+// newSnappyReader returns a reader of a snappy stream: either the framing format, or the block format of
+// the Hadoop codec written by Spark.
+func newSnappyReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	start, err := buffered.Peek(len(snappyStreamIdentifier))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(start, snappyStreamIdentifier) {
+		return snappy.NewReader(buffered), nil
+	}
+	return &hadoopSnappyReader{r: buffered}, nil
+}
+
+// hadoopSnappyReader decodes the Hadoop snappy format: a sequence of blocks, each made of the big-endian
+// uint32 length of its uncompressed data followed by chunks made of the big-endian uint32 length of their
+// compressed data followed by that data.
+type hadoopSnappyReader struct {
+	r         io.Reader
+	remaining uint32
+	pending   []byte
+}
+
+func (reader *hadoopSnappyReader) Read(p []byte) (int, error) {
+	for len(reader.pending) == 0 {
+		if reader.remaining == 0 {
+			length, err := reader.readLength()
+			if err != nil {
+				return 0, err
+			}
+			reader.remaining = length
+			continue
+		}
+		length, err := reader.readLength()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if length > maxSnappyBlockSize {
+			return 0, fmt.Errorf("invalid snappy block of %d bytes", length)
+		}
+		compressed := make([]byte, length)
+		if _, err = io.ReadFull(reader.r, compressed); err != nil {
+			return 0, err
+		}
+		decoded, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			return 0, err
+		}
+		if uint32(len(decoded)) > reader.remaining {
+			return 0, fmt.Errorf("invalid snappy block: %d bytes decoded instead of at most %d", len(decoded), reader.remaining)
+		}
+		reader.remaining -= uint32(len(decoded))
+		reader.pending = decoded
+	}
+	n := copy(p, reader.pending)
+	reader.pending = reader.pending[n:]
+	return n, nil
+}
+
+// readLength reads a big-endian uint32, returning io.EOF only at the end of the stream.
+func (reader *hadoopSnappyReader) readLength() (uint32, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(reader.r, length[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(length[:]), nil
+}
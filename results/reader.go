@@ -0,0 +1,477 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package results : Reading the result objects of SQL jobs from Cloud Object Storage.
+//
+// A Reader streams the rows of a job with bounded memory: it walks the result objects in order, fetches them
+// with ranged GET requests only as fast as the rows are consumed, decompresses them and decodes one row at a
+// time. Its Position can be saved to resume reading after a failure. A Fetcher reads the result objects
+// concurrently instead, for the results written into many objects.
+//
+// The results written PARTITIONED BY columns are stored in directories named after the values of these
+// columns, col=value/. Both read the values of the partition columns from these names, and return them as
+// extra columns.
+//
+// ExportResults writes the results to a local file in CSV or JSON Lines, and arrowresults.ExportResults in
+// Parquet too. GetResultSchema returns the columns of CSV and JSON results and their types, and
+// arrowresults.GetResultSchema those of any results.
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// The result formats, compared with SqlJobInfoFull.ResultsetFormat ignoring case. A Reader decodes CSV and
+// JSON; Parquet results are decoded by package arrowresults.
+const (
+	Format_CSV     = "csv"
+	Format_JSON    = "json"
+	Format_Parquet = "parquet"
+)
+
+// ReaderOptions : The Reader options.
+type ReaderOptions struct {
+	// The format of the results. Defaults to the ResultsetFormat of the job.
+	Format string
+
+	// The size of the ranged GET requests. Defaults to DefaultChunkSize.
+	ChunkSize int64
+
+	// Where to start reading, typically the Position of a previous Reader of the same job.
+	Resume *Position
+}
+
+// Position : A position in the results of a job.
+//
+// A Position with a Key resumes reading at the byte Offset of that part, which is counted in the
+// decompressed contents of the part. Only uncompressed parts are fetched from the offset: compressed
+// parts are decompressed from their start and the bytes before the offset are discarded. A Position
+// without a Key skips the first Row rows of the results instead, decoding and discarding them.
+type Position struct {
+	// The key of the part of the next row.
+	Key string `json:"key,omitempty"`
+
+	// The offset of the next row in the decompressed contents of the part.
+	Offset int64 `json:"offset,omitempty"`
+
+	// The number of rows before the position.
+	Row int64 `json:"row"`
+}
+
+// Reader reads the rows of the results of a job, one at a time. It isn't safe for concurrent use.
+type Reader struct {
+	ctx       context.Context
+	storage   *cos.Client
+	format    string
+	chunkSize int64
+	parts     []Part
+	total     int64
+
+	columns     []string
+	columnIndex map[string]int
+
+	// The index in parts of the part being read, and its stream.
+	partIndex int
+	stream    *partStream
+
+	position Position
+	skipRows int64
+}
+
+// partStream : The decoding state of a part.
+type partStream struct {
+	source  io.Closer
+	scanner *recordScanner
+	mapping []int
+
+	// The indexes in Columns of the partition columns of the part.
+	partitionMapping []int
+}
+
+// NewReader returns a Reader of the results of "job", which must have completed.
+func NewReader(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, options *ReaderOptions) (*Reader, error) {
+	err := core.ValidateNotNil(storage, "storage cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(job, "job cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &ReaderOptions{}
+	}
+	if job.ResultsetLocation == nil {
+		return nil, fmt.Errorf("the job has no resultset location")
+	}
+
+	reader := &Reader{
+		ctx:         ctx,
+		storage:     storage,
+		format:      strings.ToLower(options.Format),
+		chunkSize:   options.ChunkSize,
+		columnIndex: make(map[string]int),
+	}
+	if reader.format == "" && job.ResultsetFormat != nil {
+		reader.format = strings.ToLower(*job.ResultsetFormat)
+	}
+	if reader.format != Format_CSV && reader.format != Format_JSON {
+		return nil, fmt.Errorf("unsupported result format %q", reader.format)
+	}
+	if reader.chunkSize <= 0 {
+		reader.chunkSize = DefaultChunkSize
+	}
+	if job.RowsReturned != nil {
+		reader.total = int64(*job.RowsReturned)
+	}
+
+	reader.parts, err = ListParts(ctx, storage, *job.ResultsetLocation)
+	if err != nil {
+		return nil, err
+	}
+
+	if resume := options.Resume; resume != nil {
+		if resume.Key == "" {
+			reader.skipRows = resume.Row
+		} else {
+			reader.partIndex = -1
+			for i := range reader.parts {
+				if reader.parts[i].Location.Key == resume.Key {
+					reader.partIndex = i
+				}
+			}
+			if reader.partIndex < 0 {
+				return nil, fmt.Errorf("cannot resume: no result part %s", resume.Key)
+			}
+			reader.position = *resume
+		}
+	}
+	return reader, nil
+}
+
+// Parts returns the result objects read.
+func (reader *Reader) Parts() []Part {
+	return reader.parts
+}
+
+// Total returns the number of rows of the results reported by the service, RowsReturned, for progress
+// reporting, or 0 if unknown.
+func (reader *Reader) Total() int64 {
+	return reader.total
+}
+
+// Columns returns the names of the columns read so far. The columns of CSV results are known once the first
+// row is read. JSON results omit null values, so columns are added as they are first seen: the rows read
+// before have no value for them.
+func (reader *Reader) Columns() []string {
+	return reader.columns
+}
+
+// Position returns the position of the next row.
+func (reader *Reader) Position() Position {
+	return reader.position
+}
+
+// Next returns the values of the next row, in the order of Columns. The values of CSV results are strings;
+// the values of JSON results are decoded with json.Number for numbers. Next returns io.EOF after the last
+// row. After any other error, reading can be resumed at Position with a new Reader.
+func (reader *Reader) Next() ([]interface{}, error) {
+	for {
+		row, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if reader.skipRows > 0 {
+			reader.skipRows--
+			continue
+		}
+		return row, nil
+	}
+}
+
+func (reader *Reader) next() ([]interface{}, error) {
+	for {
+		if reader.partIndex >= len(reader.parts) {
+			return nil, io.EOF
+		}
+		if reader.stream == nil {
+			if err := reader.open(); err != nil {
+				return nil, err
+			}
+		}
+
+		record, err := reader.stream.scanner.next()
+		if err == io.EOF {
+			reader.closeStream()
+			reader.partIndex++
+			// At the end of the results, the position stays at the end of the last part.
+			if reader.partIndex < len(reader.parts) {
+				reader.position.Key = reader.parts[reader.partIndex].Location.Key
+				reader.position.Offset = 0
+			}
+			continue
+		}
+		if err != nil {
+			return nil, reader.partError(err)
+		}
+
+		row, err := reader.decode(record)
+		if err != nil {
+			return nil, reader.partError(err)
+		}
+		reader.position.Offset = reader.stream.scanner.offset
+		reader.position.Row++
+		return row, nil
+	}
+}
+
+// open starts reading the current part at the offset of the position.
+func (reader *Reader) open() error {
+	part := &reader.parts[reader.partIndex]
+	reader.position.Key = part.Location.Key
+	offset := reader.position.Offset
+
+	source := newRangeReader(reader.ctx, reader.storage, part, 0, reader.chunkSize)
+	stream := &partStream{source: source}
+	decompressed, err := decompress(source, part.Compression)
+	if err != nil {
+		source.Close()
+		return reader.partError(err)
+	}
+	stream.scanner = newRecordScanner(decompressed, reader.format == Format_CSV)
+	reader.stream = stream
+
+	if reader.format == Format_CSV {
+		header, err := stream.scanner.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return reader.partError(err)
+		}
+		names, err := parseCSV(header)
+		if err != nil {
+			return reader.partError(err)
+		}
+		stream.mapping = reader.mapColumns(names)
+		reader.mapPartition()
+		if offset < stream.scanner.offset {
+			reader.position.Offset = stream.scanner.offset
+		}
+	}
+
+	if skip := offset - stream.scanner.offset; skip > 0 {
+		if part.Compression == Compression_None {
+			// Fetch the part from the offset rather than discarding the bytes before it.
+			source.Close()
+			source = newRangeReader(reader.ctx, reader.storage, part, offset, reader.chunkSize)
+			stream.source = source
+			stream.scanner = newRecordScanner(source, reader.format == Format_CSV)
+			stream.scanner.offset = offset
+		} else if err := stream.scanner.discard(skip); err != nil {
+			return reader.partError(err)
+		}
+	}
+	return nil
+}
+
+func (reader *Reader) partError(err error) error {
+	return fmt.Errorf("%s: %w", reader.parts[reader.partIndex].Location.String(), err)
+}
+
+// mapColumns returns the index in Columns of each of "names", adding the columns not seen yet.
+func (reader *Reader) mapColumns(names []string) []int {
+	mapping := make([]int, len(names))
+	for i, name := range names {
+		index, ok := reader.columnIndex[name]
+		if !ok {
+			index = len(reader.columns)
+			reader.columns = append(reader.columns, name)
+			reader.columnIndex[name] = index
+		}
+		mapping[i] = index
+	}
+	return mapping
+}
+
+// decode decodes a row of the current part.
+func (reader *Reader) decode(record []byte) ([]interface{}, error) {
+	var names []string
+	var values []interface{}
+	if reader.format == Format_CSV {
+		fields, err := parseCSV(record)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) != len(reader.stream.mapping) {
+			return nil, fmt.Errorf("%d values instead of %d at offset %d", len(fields), len(reader.stream.mapping), reader.position.Offset)
+		}
+		values = make([]interface{}, len(fields))
+		for i, field := range fields {
+			values[i] = field
+		}
+	} else {
+		var err error
+		names, values, err = parseJSONObject(record)
+		if err != nil {
+			return nil, err
+		}
+		reader.stream.mapping = reader.mapColumns(names)
+		if reader.stream.partitionMapping == nil {
+			reader.mapPartition()
+		}
+	}
+
+	row := make([]interface{}, len(reader.columns))
+	for i, value := range values {
+		row[reader.stream.mapping[i]] = value
+	}
+	for i, partitionValue := range reader.parts[reader.partIndex].Partition {
+		if partitionValue.Value != nil {
+			row[reader.stream.partitionMapping[i]] = *partitionValue.Value
+		}
+	}
+	return row, nil
+}
+
+// mapPartition adds the partition columns of the current part, after the columns of its data seen so far.
+// The values of partition columns are strings, or nil for the default partition.
+func (reader *Reader) mapPartition() {
+	partition := reader.parts[reader.partIndex].Partition
+	names := make([]string, len(partition))
+	for i, partitionValue := range partition {
+		names[i] = partitionValue.Column
+	}
+	reader.stream.partitionMapping = reader.mapColumns(names)
+}
+
+func (reader *Reader) closeStream() {
+	if reader.stream != nil {
+		reader.stream.source.Close()
+		reader.stream = nil
+	}
+}
+
+// Close releases the connection of the part being read.
+func (reader *Reader) Close() error {
+	reader.closeStream()
+	return nil
+}
+
+// recordScanner splits a stream into records: lines, except that in CSV the line breaks within double
+// quotes are part of the record. JSON Lines records never contain a line break, since JSON escapes them
+// in strings, but they may contain escaped double quotes. It tracks the offset of the next record.
+type recordScanner struct {
+	r      *bufio.Reader
+	quoted bool
+	offset int64
+}
+
+// newRecordScanner returns a scanner of the records of "r", CSV records if "quoted" is set, otherwise
+// JSON Lines records.
+func newRecordScanner(r io.Reader, quoted bool) *recordScanner {
+	return &recordScanner{r: bufio.NewReader(r), quoted: quoted}
+}
+
+// next returns the next non-blank record, including its line break, or io.EOF.
+func (scanner *recordScanner) next() ([]byte, error) {
+	for {
+		var record []byte
+		quotes := 0
+		for {
+			line, err := scanner.r.ReadSlice('\n')
+			record = append(record, line...)
+			scanner.offset += int64(len(line))
+			if scanner.quoted {
+				quotes += bytes.Count(line, []byte{'"'})
+			}
+			if err == bufio.ErrBufferFull || (err == nil && quotes%2 != 0) {
+				continue
+			}
+			if err == io.EOF && len(record) > 0 {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+		if len(bytes.TrimSpace(record)) > 0 {
+			return record, nil
+		}
+	}
+}
+
+// discard skips "n" bytes.
+func (scanner *recordScanner) discard(n int64) error {
+	discarded, err := io.CopyN(ioutil.Discard, scanner.r, n)
+	scanner.offset += discarded
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func parseCSV(record []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(record))
+	reader.FieldsPerRecord = -1
+	return reader.Read()
+}
+
+// parseJSONObject decodes a JSON object, keeping the order of its members.
+func parseJSONObject(record []byte) (names []string, values []interface{}, err error) {
+	decoder := json.NewDecoder(bytes.NewReader(record))
+	decoder.UseNumber()
+	token, err := decoder.Token()
+	if err != nil {
+		return
+	}
+	if token != json.Delim('{') {
+		err = fmt.Errorf("a row isn't a JSON object")
+		return
+	}
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return
+		}
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			return
+		}
+		names = append(names, token.(string))
+		values = append(values, value)
+	}
+	if _, err = decoder.Token(); err != nil {
+		return
+	}
+	if _, extra := decoder.Token(); extra != io.EOF {
+		err = fmt.Errorf("a row has data after its JSON object")
+	}
+	return
+}
@@ -0,0 +1,195 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/cos/costest"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jobLocation = "cos://us-geo/bucket/results/jobid=job1"
+
+func newStorage(t *testing.T) (*costest.Server, *cos.Client) {
+	server := costest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := cos.NewClient(&cos.Options{Authenticator: &core.NoAuthAuthenticator{}, EndpointURL: server.URL})
+	require.Nil(t, err)
+	return server, client
+}
+
+func newJob(format string, rows float64) *sqlv2.SqlJobInfoFull {
+	return &sqlv2.SqlJobInfoFull{
+		JobID:             core.StringPtr("job1"),
+		ResultsetLocation: core.StringPtr(jobLocation),
+		ResultsetFormat:   core.StringPtr(format),
+		RowsReturned:      &rows,
+	}
+}
+
+func gzipData(t *testing.T, data string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(data))
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+	return buffer.Bytes()
+}
+
+// hadoopSnappyData encodes "blocks" in the format of the Hadoop snappy codec, one chunk per block.
+func hadoopSnappyData(blocks ...string) []byte {
+	var buffer bytes.Buffer
+	for _, block := range blocks {
+		compressed := snappy.Encode(nil, []byte(block))
+		_ = binary.Write(&buffer, binary.BigEndian, uint32(len(block)))
+		_ = binary.Write(&buffer, binary.BigEndian, uint32(len(compressed)))
+		buffer.Write(compressed)
+	}
+	return buffer.Bytes()
+}
+
+func putCSVParts(t *testing.T, server *costest.Server) {
+	server.PutObject("bucket", "results/jobid=job1", []byte{})
+	server.PutObject("bucket", "results/jobid=job1/_SUCCESS", []byte{})
+	server.PutObject("bucket", "results/jobid=job1/part-00000-c000.csv", []byte("id,name\n1,alice\n2,\"bob\nsmith\"\n"))
+	server.PutObject("bucket", "results/jobid=job1/part-00001-c000.csv.gz", gzipData(t, "id,name\n3,carol\n\n4,dave\n"))
+	server.PutObject("bucket", "results/jobid=job10/part-00000-c000.csv", []byte("id,name\n5,other\n"))
+}
+
+func readAll(t *testing.T, reader *Reader) [][]interface{} {
+	var rows [][]interface{}
+	for {
+		row, err := reader.Next()
+		if err == io.EOF {
+			return rows
+		}
+		require.Nil(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	server, storage := newStorage(t)
+	putCSVParts(t, server)
+
+	reader, err := NewReader(context.Background(), storage, newJob("CSV", 4), &ReaderOptions{ChunkSize: 5})
+	require.Nil(t, err)
+	defer reader.Close()
+	require.Len(t, reader.Parts(), 2)
+	assert.Equal(t, Compression_Gzip, reader.Parts()[1].Compression)
+	assert.Equal(t, int64(4), reader.Total())
+
+	assert.Equal(t, [][]interface{}{{"1", "alice"}, {"2", "bob\nsmith"}, {"3", "carol"}, {"4", "dave"}}, readAll(t, reader))
+	assert.Equal(t, []string{"id", "name"}, reader.Columns())
+	assert.Equal(t, Position{Key: "results/jobid=job1/part-00001-c000.csv.gz", Offset: 24, Row: 4}, reader.Position())
+}
+
+func TestReadJSON(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.json.snappy",
+		hadoopSnappyData(`{"id":1,"name":"alice"}`+"\n", `{"id":2}`+"\n"))
+	var framed bytes.Buffer
+	writer := snappy.NewBufferedWriter(&framed)
+	_, err := writer.Write([]byte(`{"id":3,"name":"carol","tags":["a"]}` + "\n"))
+	require.Nil(t, err)
+	require.Nil(t, writer.Close())
+	server.PutObject("bucket", "results/jobid=job1/part-00001.json.snappy", framed.Bytes())
+
+	reader, err := NewReader(context.Background(), storage, newJob("JSON", 3), nil)
+	require.Nil(t, err)
+	rows := readAll(t, reader)
+	assert.Equal(t, []string{"id", "name", "tags"}, reader.Columns())
+	assert.Equal(t, [][]interface{}{
+		{json.Number("1"), "alice"},
+		{json.Number("2"), nil},
+		{json.Number("3"), "carol", []interface{}{"a"}},
+	}, rows)
+}
+
+func TestReadJSONEscapedQuotes(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.json", []byte(`{"a":"x\"y"}`+"\n"+`{"a":"second"}`+"\n"+`{"a":"third"}`+"\n"))
+
+	reader, err := NewReader(context.Background(), storage, newJob("JSON", 3), nil)
+	require.Nil(t, err)
+	defer reader.Close()
+	assert.Equal(t, [][]interface{}{{`x"y`}, {"second"}, {"third"}}, readAll(t, reader))
+	assert.Equal(t, Position{Key: "results/jobid=job1/part-00000.json", Offset: 42, Row: 3}, reader.Position())
+
+	// The data after the object of a row isn't dropped silently.
+	_, _, err = parseJSONObject([]byte(`{"a":1} {"a":2}`))
+	assert.NotNil(t, err)
+}
+
+func TestResume(t *testing.T) {
+	server, storage := newStorage(t)
+	putCSVParts(t, server)
+
+	for _, stop := range []int{1, 2, 3} {
+		reader, err := NewReader(context.Background(), storage, newJob("csv", 4), &ReaderOptions{ChunkSize: 4})
+		require.Nil(t, err)
+		var rows [][]interface{}
+		for len(rows) < stop {
+			row, err := reader.Next()
+			require.Nil(t, err)
+			rows = append(rows, row)
+		}
+		position := reader.Position()
+		reader.Close()
+
+		resumed, err := NewReader(context.Background(), storage, newJob("csv", 4), &ReaderOptions{Resume: &position})
+		require.Nil(t, err)
+		rows = append(rows, readAll(t, resumed)...)
+		assert.Len(t, rows, 4, stop)
+		assert.Equal(t, []interface{}{"4", "dave"}, rows[3], stop)
+		assert.Equal(t, int64(4), resumed.Position().Row, stop)
+	}
+
+	reader, err := NewReader(context.Background(), storage, newJob("csv", 4), &ReaderOptions{Resume: &Position{Row: 3}})
+	require.Nil(t, err)
+	assert.Equal(t, [][]interface{}{{"4", "dave"}}, readAll(t, reader))
+
+	_, err = NewReader(context.Background(), storage, newJob("csv", 4), &ReaderOptions{Resume: &Position{Key: "results/jobid=job1/missing.csv"}})
+	assert.NotNil(t, err)
+}
+
+func TestReaderErrors(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.csv", []byte("id,name\n1\n"))
+
+	_, err := NewReader(context.Background(), storage, newJob("orc", 1), nil)
+	assert.EqualError(t, err, `unsupported result format "orc"`)
+	_, err = NewReader(context.Background(), storage, &sqlv2.SqlJobInfoFull{}, nil)
+	assert.NotNil(t, err)
+
+	reader, err := NewReader(context.Background(), storage, newJob("csv", 1), nil)
+	require.Nil(t, err)
+	_, err = reader.Next()
+	assert.EqualError(t, err, "cos://us-geo/bucket/results/jobid=job1/part-00000.csv: 1 values instead of 2 at offset 8")
+}
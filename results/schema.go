@@ -0,0 +1,197 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+)
+
+// DefaultSampleRows is the number of rows of CSV and JSON results inspected to infer the types of their
+// columns when none is configured.
+const DefaultSampleRows = 1000
+
+// The SQL types inferred from the values of CSV and JSON results.
+const (
+	sqlType_Bigint    = "bigint"
+	sqlType_Double    = "double"
+	sqlType_Boolean   = "boolean"
+	sqlType_Date      = "date"
+	sqlType_Timestamp = "timestamp"
+	sqlType_String    = "string"
+)
+
+// SchemaOptions : The GetResultSchema options.
+type SchemaOptions struct {
+	// The format of the results. Defaults to the ResultsetFormat of the job.
+	Format string
+
+	// The number of rows of CSV and JSON results inspected to infer the types of the columns. Defaults to
+	// DefaultSampleRows.
+	SampleRows int
+
+	// The size of the ranged GET requests of CSV and JSON results. Defaults to DefaultChunkSize.
+	ChunkSize int64
+}
+
+// GetResultSchema returns the columns of CSV and JSON results of "job", which must have completed, with
+// their SQL types as in the ColumnInformation of catalog tables. The columns marshal to JSON like the
+// columns returned by GetTable, so they can be stored with the job. The schema of Parquet results is read
+// by arrowresults.GetResultSchema.
+//
+// The columns of CSV results are read from the header, and those of JSON results from the names of the
+// values; their types are inferred from the first SampleRows rows: bigint, double, boolean, date,
+// timestamp, or else string. The columns are nullable. Partition columns follow the columns of the data,
+// as strings.
+func GetResultSchema(ctx context.Context, storage *cos.Client, job *sqlv2.SqlJobInfoFull, options *SchemaOptions) ([]sqlv2.ColumnInformation, error) {
+	err := core.ValidateNotNil(storage, "storage cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	err = core.ValidateNotNil(job, "job cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	if options == nil {
+		options = &SchemaOptions{}
+	}
+	if job.ResultsetLocation == nil {
+		return nil, fmt.Errorf("the job has no resultset location")
+	}
+	format := strings.ToLower(options.Format)
+	if format == "" && job.ResultsetFormat != nil {
+		format = strings.ToLower(*job.ResultsetFormat)
+	}
+	if format == Format_Parquet {
+		return nil, fmt.Errorf("the schema of Parquet results is read by arrowresults.GetResultSchema")
+	}
+
+	reader, err := NewReader(ctx, storage, job, &ReaderOptions{Format: format, ChunkSize: options.ChunkSize})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	sampleRows := options.SampleRows
+	if sampleRows <= 0 {
+		sampleRows = DefaultSampleRows
+	}
+	var rows [][]interface{}
+	for len(rows) < sampleRows {
+		row, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	partition := make(map[string]bool)
+	if parts := reader.Parts(); len(parts) > 0 {
+		for _, partitionValue := range parts[0].Partition {
+			partition[partitionValue.Column] = true
+		}
+	}
+	var columns, partitionColumns []sqlv2.ColumnInformation
+	for i, name := range reader.Columns() {
+		column := sqlv2.ColumnInformation{Name: core.StringPtr(name), Type: core.StringPtr(sqlType_String), Nullable: core.BoolPtr(true)}
+		if partition[name] {
+			partitionColumns = append(partitionColumns, column)
+			continue
+		}
+		values := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			if i < len(row) {
+				values = append(values, row[i])
+			}
+		}
+		column.Type = core.StringPtr(inferSQLType(values))
+		columns = append(columns, column)
+	}
+	return append(columns, partitionColumns...), nil
+}
+
+// inferSQLType returns the SQL type of the values of a column of CSV or JSON results: strings, json.Number,
+// bools, JSON arrays and objects, or nil. The nulls and the empty strings are ignored.
+func inferSQLType(values []interface{}) string {
+	sqlType := ""
+	for _, value := range values {
+		valueType := sqlType_String
+		switch value := value.(type) {
+		case nil:
+			continue
+		case json.Number:
+			valueType = sqlType_Double
+			if _, err := value.Int64(); err == nil {
+				valueType = sqlType_Bigint
+			}
+		case bool:
+			valueType = sqlType_Boolean
+		case string:
+			if value == "" {
+				continue
+			}
+			valueType = textType(value)
+		}
+		switch {
+		case sqlType == "" || sqlType == valueType:
+			sqlType = valueType
+		case sqlType == sqlType_Bigint && valueType == sqlType_Double || sqlType == sqlType_Double && valueType == sqlType_Bigint:
+			sqlType = sqlType_Double
+		case sqlType == sqlType_Date && valueType == sqlType_Timestamp || sqlType == sqlType_Timestamp && valueType == sqlType_Date:
+			sqlType = sqlType_Timestamp
+		default:
+			return sqlType_String
+		}
+	}
+	if sqlType == "" {
+		return sqlType_String
+	}
+	return sqlType
+}
+
+// textType returns the SQL type of a value of CSV results.
+func textType(text string) string {
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return sqlType_Bigint
+	}
+	if _, err := strconv.ParseFloat(text, 64); err == nil {
+		return sqlType_Double
+	}
+	if text == "true" || text == "false" {
+		return sqlType_Boolean
+	}
+	if _, err := time.Parse("2006-01-02", text); err == nil {
+		return sqlType_Date
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999"} {
+		if _, err := time.Parse(layout, text); err == nil {
+			return sqlType_Timestamp
+		}
+	}
+	return sqlType_String
+}
@@ -0,0 +1,91 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package results
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func schemaJSON(t *testing.T, columns []sqlv2.ColumnInformation) string {
+	data, err := json.Marshal(columns)
+	require.Nil(t, err)
+	return string(data)
+}
+
+func TestResultSchemaCSV(t *testing.T) {
+	server, storage := newStorage(t)
+	putPartitionedParts(server)
+
+	columns, err := GetResultSchema(context.Background(), storage, newJob("csv", 30), nil)
+	require.Nil(t, err)
+	assert.Equal(t, `[{"name":"id","type":"bigint","nullable":true},{"name":"name","type":"string","nullable":true},`+
+		`{"name":"day","type":"string","nullable":true},{"name":"country","type":"string","nullable":true}]`, schemaJSON(t, columns))
+
+	// The schema is stored as JSON and read back like the columns of GetTable.
+	var stored []sqlv2.ColumnInformation
+	require.Nil(t, json.Unmarshal([]byte(schemaJSON(t, columns)), &stored))
+	assert.Equal(t, columns, stored)
+
+	server.PutObject("bucket", "results/jobid=job2/part-00000.csv", []byte("a,b,c,d,e\n1,1,2022-01-31,x,true\n,1.5,2022-01-31 10:00:00,1,false\n"))
+	job := newJob("csv", 2)
+	job.ResultsetLocation = core.StringPtr("cos://us-geo/bucket/results/jobid=job2")
+	columns, err = GetResultSchema(context.Background(), storage, job, &SchemaOptions{SampleRows: 1})
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bigint", "bigint", "date", "string", "boolean"}, columnTypes(columns))
+	columns, err = GetResultSchema(context.Background(), storage, job, nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"bigint", "double", "timestamp", "string", "boolean"}, columnTypes(columns))
+}
+
+func TestResultSchemaJSON(t *testing.T) {
+	server, storage := newStorage(t)
+	server.PutObject("bucket", "results/jobid=job1/part-00000.json", []byte(`{"id":1,"tags":["a"]}`+"\n"+`{"id":2,"score":0.5,"ok":true}`+"\n"))
+
+	columns, err := GetResultSchema(context.Background(), storage, newJob("json", 2), nil)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"id", "tags", "score", "ok"}, columnNames(columns))
+	assert.Equal(t, []string{"bigint", "string", "double", "boolean"}, columnTypes(columns))
+}
+
+func TestResultSchemaParquet(t *testing.T) {
+	_, storage := newStorage(t)
+	_, err := GetResultSchema(context.Background(), storage, newJob("parquet", 4), nil)
+	assert.NotNil(t, err)
+}
+
+func columnNames(columns []sqlv2.ColumnInformation) []string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = *column.Name
+	}
+	return names
+}
+
+func columnTypes(columns []sqlv2.ColumnInformation) []string {
+	types := make([]string, len(columns))
+	for i, column := range columns {
+		types[i] = *column.Type
+	}
+	return types
+}
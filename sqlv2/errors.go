@@ -0,0 +1,150 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// traceHeaders are the response headers that may carry the trace ID of a request, in order of preference.
+var traceHeaders = []string{"X-Global-Transaction-Id", "X-Request-Id", "X-Correlation-Id"}
+
+// APIError : An error response of the service. The operations return one of the more specific error types
+// below, which all embed APIError, so that errors.As can match either the specific type or *APIError.
+type APIError struct {
+	// The ID of the operation that failed.
+	Operation string
+
+	// The HTTP status code of the response.
+	StatusCode int
+
+	// The error code reported by the service, if any.
+	Code string
+
+	// The error message.
+	Message string
+
+	// A link to more information about the error, if any.
+	MoreInfo string
+
+	// The ID identifying the request in the service logs, if any.
+	TraceID string
+
+	// The response of the service.
+	Response *core.DetailedResponse
+}
+
+// Error returns the error message.
+func (err *APIError) Error() string {
+	return err.Message
+}
+
+// As makes errors.As match *APIError on the error types embedding it.
+func (err *APIError) As(target interface{}) bool {
+	if apiErr, ok := target.(**APIError); ok {
+		*apiErr = err
+		return true
+	}
+	return false
+}
+
+// ErrNotFound : The job or the table doesn't exist (404).
+type ErrNotFound struct{ APIError }
+
+// ErrUnauthorized : The credentials are missing or invalid (401).
+type ErrUnauthorized struct{ APIError }
+
+// ErrForbidden : The credentials don't grant access to the instance (403).
+type ErrForbidden struct{ APIError }
+
+// ErrRateLimited : Too many requests were sent (429).
+type ErrRateLimited struct {
+	APIError
+
+	// How long to wait before sending another request, from the Retry-After header. Zero if not specified.
+	RetryAfter time.Duration
+}
+
+// ErrInvalidStatement : The submitted statement was rejected (400 on SubmitSqlJob).
+type ErrInvalidStatement struct{ APIError }
+
+// ErrServiceUnavailable : The service is temporarily unable to handle requests (502, 503 or 504).
+type ErrServiceUnavailable struct{ APIError }
+
+// newAPIError returns the typed error of a failed call of operation "id". "err" is the error returned by
+// the BaseService, whose message is kept.
+func newAPIError(id string, response *core.DetailedResponse, err error) error {
+	apiErr := APIError{
+		Operation:  id,
+		StatusCode: response.StatusCode,
+		Message:    err.Error(),
+		Response:   response,
+	}
+	if body, ok := response.Result.(map[string]interface{}); ok {
+		if errorList, ok := body["errors"].([]interface{}); ok && len(errorList) > 0 {
+			if first, ok := errorList[0].(map[string]interface{}); ok {
+				apiErr.Code, _ = first["code"].(string)
+				apiErr.MoreInfo, _ = first["more_info"].(string)
+			}
+		}
+		if apiErr.Code == "" {
+			apiErr.Code, _ = body["code"].(string)
+		}
+		apiErr.TraceID, _ = body["trace"].(string)
+	}
+	for _, header := range traceHeaders {
+		if apiErr.TraceID != "" {
+			break
+		}
+		apiErr.TraceID = response.Headers.Get(header)
+	}
+
+	switch response.StatusCode {
+	case http.StatusNotFound:
+		return &ErrNotFound{apiErr}
+	case http.StatusUnauthorized:
+		return &ErrUnauthorized{apiErr}
+	case http.StatusForbidden:
+		return &ErrForbidden{apiErr}
+	case http.StatusTooManyRequests:
+		return &ErrRateLimited{APIError: apiErr, RetryAfter: parseRetryAfter(response.Headers.Get("Retry-After"))}
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return &ErrServiceUnavailable{apiErr}
+	case http.StatusBadRequest:
+		if id == OperationID_SubmitSqlJob {
+			return &ErrInvalidStatement{apiErr}
+		}
+	}
+	return &apiErr
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number of seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
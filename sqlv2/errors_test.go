@@ -0,0 +1,129 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Errors`, func() {
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var statusCode int
+	var headers map[string]string
+	var body string
+
+	BeforeEach(func() {
+		headers = map[string]string{}
+		body = ""
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			res.Header().Set("Content-type", "application/json")
+			for name, value := range headers {
+				res.Header().Set(name, value)
+			}
+			res.WriteHeader(statusCode)
+			fmt.Fprint(res, body)
+		}))
+
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Return ErrNotFound for unknown jobs`, func() {
+		statusCode = 404
+		body = `{"errors": [{"code": "job_not_found", "message": "Job abc not found", "more_info": "https://cloud.ibm.com/docs"}], "trace": "trace-1"}`
+		_, response, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("abc"))
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(Equal("Job abc not found"))
+
+		var notFound *sqlv2.ErrNotFound
+		Expect(errors.As(err, &notFound)).To(BeTrue())
+		Expect(notFound.Operation).To(Equal(sqlv2.OperationID_GetSqlJob))
+		Expect(notFound.StatusCode).To(Equal(404))
+		Expect(notFound.Code).To(Equal("job_not_found"))
+		Expect(notFound.MoreInfo).To(Equal("https://cloud.ibm.com/docs"))
+		Expect(notFound.TraceID).To(Equal("trace-1"))
+		Expect(notFound.Response).To(BeIdenticalTo(response))
+
+		var apiErr *sqlv2.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(404))
+		var forbidden *sqlv2.ErrForbidden
+		Expect(errors.As(err, &forbidden)).To(BeFalse())
+	})
+
+	It(`Return ErrRateLimited with the Retry-After delay`, func() {
+		statusCode = 429
+		headers["Retry-After"] = "7"
+		headers["X-Global-Transaction-Id"] = "txn-1"
+		body = `{"message": "too many requests"}`
+		_, _, err := sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions())
+
+		var rateLimited *sqlv2.ErrRateLimited
+		Expect(errors.As(fmt.Errorf("wrapped: %w", err), &rateLimited)).To(BeTrue())
+		Expect(rateLimited.RetryAfter).To(Equal(7 * time.Second))
+		Expect(rateLimited.TraceID).To(Equal("txn-1"))
+		Expect(rateLimited.Message).To(Equal("too many requests"))
+	})
+
+	It(`Return ErrInvalidStatement for rejected submissions only`, func() {
+		statusCode = 400
+		body = `{"errors": [{"code": "bad_request", "message": "Syntax error"}]}`
+		_, _, err := sqlService.SubmitSqlJob(sqlService.NewSubmitSqlJobOptions("SELEC 1"))
+		var invalid *sqlv2.ErrInvalidStatement
+		Expect(errors.As(err, &invalid)).To(BeTrue())
+		Expect(invalid.Code).To(Equal("bad_request"))
+
+		_, _, err = sqlService.ListTables(sqlService.NewListTablesOptions())
+		Expect(errors.As(err, &invalid)).To(BeFalse())
+		var apiErr *sqlv2.APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(400))
+	})
+
+	It(`Map the other status codes`, func() {
+		var unauthorized *sqlv2.ErrUnauthorized
+		var forbidden *sqlv2.ErrForbidden
+		var unavailable *sqlv2.ErrServiceUnavailable
+		for code, target := range map[int]interface{}{401: &unauthorized, 403: &forbidden, 503: &unavailable, 504: &unavailable} {
+			statusCode = code
+			body = "not json"
+			headers["Content-type"] = "text/plain"
+			_, _, err := sqlService.GetTable(sqlService.NewGetTableOptions("t"))
+			Expect(errors.As(err, target)).To(BeTrue(), fmt.Sprint(code))
+		}
+	})
+})
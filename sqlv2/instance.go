@@ -0,0 +1,66 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"fmt"
+
+	"github.com/IBM/sql-query-go-sdk/crn"
+)
+
+// instanceCrn returns the CRN of the instance an operation is sent to: "override" if set, after checking
+// that it is well formed, or else the default instance of the client.
+func (sql *SqlV2) instanceCrn(override *string) (*string, error) {
+	if override != nil {
+		if err := ValidateInstanceCrn(*override); err != nil {
+			return nil, err
+		}
+		return override, nil
+	}
+	if sql.InstanceCrn == nil {
+		return nil, fmt.Errorf("no instance CRN: set InstanceCrn on the client or on the options")
+	}
+	return sql.InstanceCrn, nil
+}
+
+// ValidateInstanceCrn returns an error if "instanceCrn" isn't a well-formed CRN of a SQL Query instance:
+// crn:v1:<cname>:<ctype>:sql-query:<region>:<scope>:<service-instance>::.
+func ValidateInstanceCrn(instanceCrn string) error {
+	_, err := crn.ParseInstance(instanceCrn)
+	if err != nil {
+		return fmt.Errorf("invalid instance CRN %q: %w", RedactCrn(instanceCrn), err)
+	}
+	return nil
+}
+
+// Region returns the region of the default instance of the client, or "" if the client has no default
+// instance.
+func (sql *SqlV2) Region() string {
+	return instanceRegion(sql.InstanceCrn)
+}
+
+// instanceRegion returns the region of the instance "instanceCrn", or "" if it isn't a valid instance CRN.
+func instanceRegion(instanceCrn *string) string {
+	if instanceCrn == nil {
+		return ""
+	}
+	parsed, err := crn.ParseInstance(*instanceCrn)
+	if err != nil {
+		return ""
+	}
+	return parsed.Region()
+}
@@ -0,0 +1,147 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Instance override`, func() {
+	const otherCrn = "crn:v1:bluemix:public:sql-query:eu-de:a/123:other-instance::"
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var instanceCrns []string
+
+	BeforeEach(func() {
+		instanceCrns = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			instanceCrns = append(instanceCrns, req.URL.Query().Get("instance_crn"))
+			res.Header().Set("Content-type", "application/json")
+			switch req.URL.EscapedPath() {
+			case "/tables":
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"tables": [], "tables_metadata": []}`)
+			case "/tables/t":
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"name": "t", "type": "TABLE", "columns": []}`)
+			case "/sql_jobs":
+				if req.Method == http.MethodPost {
+					res.WriteHeader(201)
+					fmt.Fprintf(res, `{"job_id": "job1", "status": "queued"}`)
+					return
+				}
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"jobs": []}`)
+			default:
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"job_id": "job1", "status": "running"}`)
+			}
+		}))
+
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Send every operation to the overriding instance`, func() {
+		_, _, err := sqlService.ListTables(sqlService.NewListTablesOptions().SetInstanceCrn(otherCrn))
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.GetTable(sqlService.NewGetTableOptions("t").SetInstanceCrn(otherCrn))
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.SubmitSqlJob(sqlService.NewSubmitSqlJobOptions("SELECT 1").SetInstanceCrn(otherCrn))
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions().SetInstanceCrn(otherCrn))
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn(otherCrn))
+		Expect(err).To(BeNil())
+		Expect(instanceCrns).To(Equal([]string{otherCrn, otherCrn, otherCrn, otherCrn, otherCrn}))
+
+		_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		Expect(instanceCrns[5]).To(Equal("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"))
+	})
+
+	It(`Reject malformed overrides without calling the service`, func() {
+		for _, instanceCrn := range []string{
+			"",
+			"other-instance",
+			"crn:v1:bluemix:public:sql-query:eu-de:a/123:other-instance",
+			"crn:v2:bluemix:public:sql-query:eu-de:a/123:other-instance::",
+			"crn:v1:bluemix:public::eu-de:a/123:other-instance::",
+			"crn:v1:bluemix:public:sql-query:eu-de:a/123:::",
+		} {
+			_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn(instanceCrn))
+			Expect(err).ToNot(BeNil(), instanceCrn)
+		}
+		Expect(instanceCrns).To(BeEmpty())
+		Expect(sqlv2.ValidateInstanceCrn(otherCrn)).To(BeNil())
+	})
+
+	It(`Validate the default instance and derive its region`, func() {
+		Expect(sqlService.Region()).To(Equal("us-south"))
+		for _, instanceCrn := range []string{
+			"testString",
+			"crn:v1:bluemix:public:cloud-object-storage:global:a/123:other-instance::",
+			"crn:v1:bluemix:public:sql-query::a/123:other-instance::",
+		} {
+			_, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+				URL:           testServer.URL,
+				Authenticator: &core.NoAuthAuthenticator{},
+				InstanceCrn:   core.StringPtr(instanceCrn),
+			})
+			Expect(err).ToNot(BeNil(), instanceCrn)
+		}
+		Expect(sqlv2.ValidateInstanceCrn("crn:v1:bluemix:public:cloud-object-storage:global:a/123:other-instance::")).
+			To(MatchError(`invalid instance CRN "crn:v1:bluemix:public:cloud-object-storage:global:a/***:***ance::": ` +
+				`crn: the service name is "cloud-object-storage" instead of "sql-query"`))
+	})
+
+	It(`Use the global endpoint whatever the region`, func() {
+		for _, instanceCrn := range []string{otherCrn, "crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"} {
+			service, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+				Authenticator: &core.NoAuthAuthenticator{},
+				InstanceCrn:   core.StringPtr(instanceCrn),
+			})
+			Expect(err).To(BeNil())
+			Expect(service.Service.GetServiceURL()).To(Equal(sqlv2.DefaultServiceURL))
+		}
+	})
+
+	It(`Fail without any instance`, func() {
+		sqlService.InstanceCrn = nil
+		_, _, err := sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions())
+		Expect(err).ToNot(BeNil())
+		_, _, err = sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions().SetInstanceCrn(otherCrn))
+		Expect(err).To(BeNil())
+	})
+})
@@ -0,0 +1,230 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/cos"
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+)
+
+// Logger : A structured logger. A *slog.Logger of the log/slog package satisfies it; the arguments are
+// alternating keys and values.
+type Logger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	InfoContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+	ErrorContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// LogVerbosity : How much LoggingMiddleware logs about an operation.
+type LogVerbosity int
+
+// The verbosities of LoggingMiddleware.
+const (
+	// Use the default verbosity.
+	LogVerbosity_Unset LogVerbosity = iota
+
+	// Log nothing.
+	LogVerbosity_Off
+
+	// Log failed calls only, at the error level.
+	LogVerbosity_Errors
+
+	// Also log successful calls at the info level.
+	LogVerbosity_Info
+
+	// Also log the redacted request details, such as the statement, at the debug level.
+	LogVerbosity_Debug
+)
+
+// The message of the log records written by LoggingMiddleware.
+const (
+	LogMessage_Request   = "SQL Query request"
+	LogMessage_Completed = "SQL Query operation completed"
+	LogMessage_Failed    = "SQL Query operation failed"
+)
+
+// LoggingOptions : The LoggingMiddleware options.
+type LoggingOptions struct {
+	// The verbosity of the operations that have none in Verbosity. Defaults to LogVerbosity_Info.
+	DefaultVerbosity LogVerbosity
+
+	// The verbosity of each operation, keyed by operation ID such as OperationID_GetSqlJob.
+	Verbosity map[string]LogVerbosity
+
+	// Replace the bucket names of resultset targets with a hash when logging them.
+	HashTargetBuckets bool
+
+	// Log statements without masking their literals. Intended for troubleshooting only.
+	KeepLiterals bool
+}
+
+// verbosity returns the verbosity of operation "id".
+func (options *LoggingOptions) verbosity(id string) LogVerbosity {
+	if verbosity := options.Verbosity[id]; verbosity != LogVerbosity_Unset {
+		return verbosity
+	}
+	if options.DefaultVerbosity != LogVerbosity_Unset {
+		return options.DefaultVerbosity
+	}
+	return LogVerbosity_Info
+}
+
+// LoggingMiddleware returns a Middleware logging every operation with "logger". Records carry the operation
+// ID, the redacted instance CRN, the HTTP status code, the duration, the retry count and the job ID. At
+// LogVerbosity_Debug, the request details are logged before the call; statements are always redacted with
+// RedactStatement. "options" may be nil.
+//
+// Unlike the debug logging of go-sdk-core, LoggingMiddleware never logs request or response bodies.
+func LoggingMiddleware(logger Logger, options *LoggingOptions) Middleware {
+	if options == nil {
+		options = &LoggingOptions{}
+	}
+	return func(next Handler) Handler {
+		return func(op *Operation) (*core.DetailedResponse, error) {
+			verbosity := options.verbosity(op.ID)
+			if verbosity == LogVerbosity_Off {
+				return next(op)
+			}
+
+			ctx := op.Request.Context()
+			attributes := []interface{}{
+				"operation", op.ID,
+				"instance_crn", RedactCrn(op.Request.URL.Query().Get("instance_crn")),
+			}
+			if verbosity >= LogVerbosity_Debug {
+				logger.DebugContext(ctx, LogMessage_Request, append(attributes, options.requestDetails(op)...)...)
+			}
+
+			request, attempts := countAttempts(op.Request)
+			op.Request = request
+			start := time.Now()
+			response, err := next(op)
+
+			retries := atomic.LoadInt32(attempts) - 1
+			if retries < 0 {
+				retries = 0
+			}
+			attributes = append(attributes, "duration", time.Since(start), "retry_count", int(retries))
+			if response != nil {
+				attributes = append(attributes, "status_code", response.StatusCode)
+			}
+			if jobID := operationJobID(op); jobID != "" {
+				attributes = append(attributes, "job_id", jobID)
+			}
+			if err != nil {
+				logger.ErrorContext(ctx, LogMessage_Failed, append(attributes, "error", err.Error())...)
+			} else if verbosity >= LogVerbosity_Info {
+				logger.InfoContext(ctx, LogMessage_Completed, attributes...)
+			}
+			return response, err
+		}
+	}
+}
+
+// requestDetails returns the redacted options of an operation as log attributes.
+func (options *LoggingOptions) requestDetails(op *Operation) (attributes []interface{}) {
+	switch operationOptions := op.Options.(type) {
+	case *SubmitSqlJobOptions:
+		if operationOptions.Statement != nil {
+			statement := *operationOptions.Statement
+			if options.KeepLiterals {
+				statement = redactTargets(statement, options.HashTargetBuckets)
+			} else {
+				statement = RedactStatement(statement, options.HashTargetBuckets)
+			}
+			attributes = append(attributes, "statement", statement)
+		}
+		if operationOptions.ResultsetTarget != nil {
+			target := *operationOptions.ResultsetTarget
+			if options.HashTargetBuckets {
+				target = HashBucket(target)
+			}
+			attributes = append(attributes, "resultset_target", target)
+		}
+	case *GetTableOptions:
+		if operationOptions.TableName != nil {
+			attributes = append(attributes, "table_name", *operationOptions.TableName)
+		}
+	case *ListTablesOptions:
+		if operationOptions.NamePattern != nil {
+			attributes = append(attributes, "name_pattern", *operationOptions.NamePattern)
+		}
+	}
+	return
+}
+
+// RedactStatement returns "statement" with its literals replaced by "?", its comments removed and its
+// whitespace collapsed, so that it can be logged. If "hashTargetBuckets" is true, the bucket names of the
+// INTO clause are replaced with a hash, see HashBucket.
+func RedactStatement(statement string, hashTargetBuckets bool) string {
+	return rewriteStatement(statement, true, hashTargetBuckets)
+}
+
+// redactTargets only collapses whitespace, removes comments and hashes the INTO buckets if requested.
+func redactTargets(statement string, hashTargetBuckets bool) string {
+	return rewriteStatement(statement, false, hashTargetBuckets)
+}
+
+func rewriteStatement(statement string, maskLiterals bool, hashTargetBuckets bool) string {
+	var builder strings.Builder
+	var previous string
+	space := false
+	for _, token := range fingerprint.Tokenize(statement) {
+		text := token.Text
+		switch token.Kind {
+		case fingerprint.TokenKind_Whitespace, fingerprint.TokenKind_Comment:
+			space = builder.Len() > 0
+			continue
+		case fingerprint.TokenKind_String, fingerprint.TokenKind_Number:
+			if maskLiterals {
+				text = fingerprint.Placeholder
+			}
+		case fingerprint.TokenKind_URI:
+			if hashTargetBuckets && strings.EqualFold(previous, "into") {
+				text = HashBucket(text)
+			}
+		}
+		if space {
+			builder.WriteByte(' ')
+			space = false
+		}
+		builder.WriteString(text)
+		previous = token.Text
+	}
+	return builder.String()
+}
+
+// HashBucket replaces the bucket name of a cos:// URI with the first 12 hex digits of its SHA-256 hash, such
+// as "cos://us-geo/bucket-3a1f0c9e2b7d/results/". Values that are not cos:// URIs are returned unchanged.
+func HashBucket(uri string) string {
+	location, err := cos.ParseURI(uri)
+	if err != nil {
+		return uri
+	}
+	sum := sha256.Sum256([]byte(location.Bucket))
+	location.Bucket = "bucket-" + hex.EncodeToString(sum[:6])
+	return location.String()
+}
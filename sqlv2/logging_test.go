@@ -0,0 +1,169 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// logRecord : A record written to a recordingLogger.
+type logRecord struct {
+	level      string
+	msg        string
+	attributes map[string]interface{}
+}
+
+// recordingLogger : A sqlv2.Logger keeping every record.
+type recordingLogger struct {
+	mutex   sync.Mutex
+	records []logRecord
+}
+
+func (logger *recordingLogger) log(level string, msg string, args []interface{}) {
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	record := logRecord{level: level, msg: msg, attributes: map[string]interface{}{}}
+	for i := 0; i+1 < len(args); i += 2 {
+		record.attributes[args[i].(string)] = args[i+1]
+	}
+	logger.records = append(logger.records, record)
+}
+
+func (logger *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	logger.log("DEBUG", msg, args)
+}
+
+func (logger *recordingLogger) InfoContext(ctx context.Context, msg string, args ...interface{}) {
+	logger.log("INFO", msg, args)
+}
+
+func (logger *recordingLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	logger.log("WARN", msg, args)
+}
+
+func (logger *recordingLogger) ErrorContext(ctx context.Context, msg string, args ...interface{}) {
+	logger.log("ERROR", msg, args)
+}
+
+var _ = Describe(`Logging`, func() {
+	var testServer *httptest.Server
+	var logger *recordingLogger
+
+	newService := func(options *sqlv2.LoggingOptions) *sqlv2.SqlV2 {
+		sqlService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+			Logger:        logger,
+			Logging:       options,
+		})
+		Expect(err).To(BeNil())
+		return sqlService
+	}
+
+	BeforeEach(func() {
+		logger = &recordingLogger{}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			res.Header().Set("Content-type", "application/json")
+			if req.URL.EscapedPath() == "/sql_jobs/missing" {
+				res.WriteHeader(404)
+				fmt.Fprintf(res, `{"errors": [{"code": "not_found", "message": "job not found"}]}`)
+				return
+			}
+			if req.Method == http.MethodPost {
+				res.WriteHeader(201)
+				fmt.Fprintf(res, `{"job_id": "job1", "status": "queued"}`)
+				return
+			}
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"job_id": "job1", "status": "running"}`)
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Log operations at the info level by default`, func() {
+		sqlService := newService(nil)
+		_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("missing"))
+		Expect(err).ToNot(BeNil())
+
+		Expect(logger.records).To(HaveLen(2))
+		Expect(logger.records[0].level).To(Equal("INFO"))
+		Expect(logger.records[0].msg).To(Equal(sqlv2.LogMessage_Completed))
+		Expect(logger.records[0].attributes).To(HaveKeyWithValue("operation", "GetSqlJob"))
+		Expect(logger.records[0].attributes).To(HaveKeyWithValue("instance_crn", "crn:v1:bluemix:public:sql-query:us-south:a/***:***1234::"))
+		Expect(logger.records[0].attributes).To(HaveKeyWithValue("status_code", 200))
+		Expect(logger.records[0].attributes).To(HaveKeyWithValue("retry_count", 0))
+		Expect(logger.records[0].attributes).To(HaveKeyWithValue("job_id", "job1"))
+		Expect(logger.records[0].attributes).To(HaveKey("duration"))
+		Expect(logger.records[1].level).To(Equal("ERROR"))
+		Expect(logger.records[1].msg).To(Equal(sqlv2.LogMessage_Failed))
+		Expect(logger.records[1].attributes).To(HaveKeyWithValue("status_code", 404))
+		Expect(logger.records[1].attributes).To(HaveKey("error"))
+	})
+
+	It(`Apply the verbosity of each operation`, func() {
+		sqlService := newService(&sqlv2.LoggingOptions{
+			DefaultVerbosity: sqlv2.LogVerbosity_Errors,
+			Verbosity: map[string]sqlv2.LogVerbosity{
+				sqlv2.OperationID_SubmitSqlJob: sqlv2.LogVerbosity_Debug,
+				sqlv2.OperationID_ListSqlJobs:  sqlv2.LogVerbosity_Off,
+			},
+			HashTargetBuckets: true,
+		})
+		_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions())
+		Expect(err).To(BeNil())
+		Expect(logger.records).To(BeEmpty())
+
+		options := sqlService.NewSubmitSqlJobOptions("SELECT * FROM cos://us-geo/data/t.csv WHERE name = 'secret' AND id = 42 -- note\n" +
+			"INTO cos://us-geo/results/out STORED AS CSV")
+		options.SetResultsetTarget("cos://us-geo/results/prefix/")
+		_, _, err = sqlService.SubmitSqlJob(options)
+		Expect(err).To(BeNil())
+
+		Expect(logger.records).To(HaveLen(2))
+		Expect(logger.records[0].level).To(Equal("DEBUG"))
+		Expect(logger.records[0].msg).To(Equal(sqlv2.LogMessage_Request))
+		Expect(logger.records[0].attributes["statement"]).To(Equal(
+			"SELECT * FROM cos://us-geo/data/t.csv WHERE name = ? AND id = ? INTO cos://us-geo/bucket-c099142bc318/out STORED AS CSV"))
+		Expect(logger.records[0].attributes["resultset_target"]).To(Equal("cos://us-geo/bucket-c099142bc318/prefix/"))
+		Expect(logger.records[1].level).To(Equal("INFO"))
+		Expect(logger.records[1].attributes).To(HaveKeyWithValue("job_id", "job1"))
+	})
+
+	It(`Redact statements`, func() {
+		Expect(sqlv2.RedactStatement("select x'0a', \"s\"  from t /* c */ where a > 1.5e3", false)).To(Equal("select ?, ? from t where a > ?"))
+		Expect(sqlv2.RedactStatement("SELECT 1 INTO cos://us-geo/b/", false)).To(Equal("SELECT ? INTO cos://us-geo/b/"))
+		Expect(sqlv2.HashBucket("not a uri")).To(Equal("not a uri"))
+	})
+})
@@ -0,0 +1,115 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/metrics"
+)
+
+// maxTrackedJobs bounds the number of jobs remembered by MetricsMiddleware to avoid recording a job twice.
+const maxTrackedJobs = 10000
+
+// MetricsMiddleware returns a Middleware recording every operation with "recorder". The jobs returned by
+// GetSqlJob are recorded once when they are first seen completed or failed; their queue time is measured
+// when they are seen running, so it is only known for jobs polled while running.
+func MetricsMiddleware(recorder metrics.Recorder) Middleware {
+	tracker := &jobTracker{
+		running:  make(map[string]time.Time),
+		finished: make(map[string]bool),
+	}
+	return func(next Handler) Handler {
+		return func(op *Operation) (*core.DetailedResponse, error) {
+			start := time.Now()
+			response, err := next(op)
+			request := metrics.Request{
+				Operation: op.ID,
+				Duration:  time.Since(start),
+				Failed:    err != nil,
+			}
+			if response != nil {
+				request.StatusCode = response.StatusCode
+			}
+			recorder.ObserveRequest(request)
+
+			if err == nil && op.ID == OperationID_GetSqlJob {
+				if rawResponse, ok := op.Result.(*map[string]json.RawMessage); ok {
+					var job *SqlJobInfoFull
+					if core.UnmarshalModel(*rawResponse, "", &job, UnmarshalSqlJobInfoFull) == nil && job != nil {
+						tracker.observe(recorder, job)
+					}
+				}
+			}
+			return response, err
+		}
+	}
+}
+
+// jobTracker : The jobs seen by a MetricsMiddleware.
+type jobTracker struct {
+	mutex    sync.Mutex
+	running  map[string]time.Time
+	finished map[string]bool
+	order    []string
+}
+
+// observe records "job" if it has just been seen finishing.
+func (tracker *jobTracker) observe(recorder metrics.Recorder, job *SqlJobInfoFull) {
+	if job.JobID == nil || job.Status == nil {
+		return
+	}
+	jobID := *job.JobID
+	tracker.mutex.Lock()
+	defer tracker.mutex.Unlock()
+
+	switch *job.Status {
+	case SqlJobInfoFull_Status_Running:
+		if _, ok := tracker.running[jobID]; !ok && len(tracker.running) < maxTrackedJobs {
+			tracker.running[jobID] = time.Now()
+		}
+	case SqlJobInfoFull_Status_Completed, SqlJobInfoFull_Status_Failed:
+		if tracker.finished[jobID] {
+			return
+		}
+		tracker.finished[jobID] = true
+		tracker.order = append(tracker.order, jobID)
+		if len(tracker.order) > maxTrackedJobs {
+			delete(tracker.finished, tracker.order[0])
+			tracker.order = tracker.order[1:]
+		}
+
+		observation := metrics.Job{Status: *job.Status}
+		if job.BytesRead != nil {
+			observation.BytesRead = int64(*job.BytesRead)
+		}
+		if job.SubmitTime != nil {
+			submitted := time.Time(*job.SubmitTime)
+			if running, ok := tracker.running[jobID]; ok && running.After(submitted) {
+				observation.QueueTime = running.Sub(submitted)
+			}
+			if job.EndTime != nil {
+				observation.RunTime = time.Time(*job.EndTime).Sub(submitted)
+			}
+		}
+		delete(tracker.running, jobID)
+		recorder.ObserveJob(observation)
+	}
+}
@@ -0,0 +1,136 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/metrics"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// recordingRecorder : A metrics.Recorder keeping every observation.
+type recordingRecorder struct {
+	mutex    sync.Mutex
+	requests []metrics.Request
+	jobs     []metrics.Job
+}
+
+func (recorder *recordingRecorder) ObserveRequest(request metrics.Request) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.requests = append(recorder.requests, request)
+}
+
+func (recorder *recordingRecorder) ObserveJob(job metrics.Job) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.jobs = append(recorder.jobs, job)
+}
+
+var _ = Describe(`Metrics`, func() {
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var recorder *recordingRecorder
+	var status string
+	var submitTime time.Time
+
+	BeforeEach(func() {
+		status = "running"
+		submitTime = time.Now().Add(-time.Minute).UTC()
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			res.Header().Set("Content-type", "application/json")
+			if req.URL.EscapedPath() == "/sql_jobs/missing" {
+				res.WriteHeader(404)
+				fmt.Fprintf(res, `{"errors": [{"code": "not_found", "message": "job not found"}]}`)
+				return
+			}
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"job_id": "job1", "status": "%s", "submit_time": "%s", "end_time": "%s", "bytes_read": 4096}`,
+				status, submitTime.Format("2006-01-02T15:04:05.000Z"), submitTime.Add(90*time.Second).Format("2006-01-02T15:04:05.000Z"))
+		}))
+
+		recorder = &recordingRecorder{}
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+			Metrics:       recorder,
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Record requests`, func() {
+		_, _, err := sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions())
+		Expect(err).To(BeNil())
+		_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("missing"))
+		Expect(err).ToNot(BeNil())
+
+		Expect(recorder.requests).To(HaveLen(2))
+		Expect(recorder.requests[0].Operation).To(Equal("ListSqlJobs"))
+		Expect(recorder.requests[0].StatusCode).To(Equal(200))
+		Expect(recorder.requests[0].Failed).To(BeFalse())
+		Expect(recorder.requests[0].Duration).To(BeNumerically(">", 0))
+		Expect(recorder.requests[1].StatusCode).To(Equal(404))
+		Expect(recorder.requests[1].Failed).To(BeTrue())
+		Expect(recorder.jobs).To(BeEmpty())
+	})
+
+	It(`Record finished jobs once`, func() {
+		_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		Expect(recorder.jobs).To(BeEmpty())
+
+		status = "completed"
+		for i := 0; i < 2; i++ {
+			_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).To(BeNil())
+		}
+		Expect(recorder.jobs).To(HaveLen(1))
+		job := recorder.jobs[0]
+		Expect(job.Status).To(Equal("completed"))
+		Expect(job.RunTime).To(Equal(90 * time.Second))
+		Expect(job.BytesRead).To(Equal(int64(4096)))
+		Expect(job.QueueTime).To(BeNumerically("~", time.Minute, 5*time.Second))
+	})
+
+	It(`Expose Prometheus metrics`, func() {
+		prometheus := metrics.NewPrometheus(nil)
+		sqlService.Use(sqlv2.MetricsMiddleware(prometheus))
+		status = "failed"
+		_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+
+		res := httptest.NewRecorder()
+		prometheus.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(res.Body.String()).To(ContainSubstring(`sql_query_requests_total{operation="GetSqlJob",code="200"} 1`))
+		Expect(res.Body.String()).To(ContainSubstring(`sql_query_job_run_seconds_sum{status="failed"} 90`))
+	})
+})
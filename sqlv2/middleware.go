@@ -0,0 +1,127 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"net/http"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/metrics"
+)
+
+// The IDs of the operations of the service, as passed to common.GetSdkHeaders.
+const (
+	OperationID_ListTables   = "ListTables"
+	OperationID_GetTable     = "GetTable"
+	OperationID_SubmitSqlJob = "SubmitSqlJob"
+	OperationID_ListSqlJobs  = "ListSqlJobs"
+	OperationID_GetSqlJob    = "GetSqlJob"
+)
+
+// Operation : A single call of a service operation, as seen by a Middleware.
+type Operation struct {
+	// The ID of the operation, one of the OperationID_* constants.
+	ID string
+
+	// The options passed to the operation, such as a *ListTablesOptions. For SubmitSqlJob, a copy of the
+	// *SubmitSqlJobOptions with the ResultsetTarget the job is submitted with.
+	Options interface{}
+
+	// The request built for the operation. A middleware may replace it, for example to add headers; the
+	// context of the call is available with Request.Context().
+	Request *http.Request
+
+	// The value the response body is unmarshalled into before it is converted to the result model.
+	Result interface{}
+}
+
+// Handler sends the request of an operation and returns the service response. When the service returns an
+// error status, both the response and the error are set.
+type Handler func(op *Operation) (*core.DetailedResponse, error)
+
+// Middleware wraps a Handler with additional behaviour. A middleware typically calls "next" and inspects
+// or adjusts the operation and the response around it, but may also answer without calling it.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the chain wrapping every operation of "sql". The first middleware added is
+// the outermost one. Use is not safe to call concurrently with operations; configure the chain before
+// sharing the client.
+func (sql *SqlV2) Use(middleware ...Middleware) {
+	sql.middleware = append(sql.middleware, middleware...)
+}
+
+// newOptionsMiddleware returns the middleware configured by "options", outermost first.
+func newOptionsMiddleware(options *SqlV2Options) ([]Middleware, error) {
+	var middleware []Middleware
+	if options.Tracer != nil {
+		middleware = append(middleware, TracingMiddleware(options.Tracer))
+	}
+	if options.Metrics != nil && options.Metrics != (metrics.Noop{}) {
+		middleware = append(middleware, MetricsMiddleware(options.Metrics))
+	}
+	if options.Logger != nil {
+		middleware = append(middleware, LoggingMiddleware(options.Logger, options.Logging))
+	}
+	if len(options.RateLimits) > 0 {
+		rateLimit, err := RateLimitMiddleware(options.RateLimits)
+		if err != nil {
+			return nil, err
+		}
+		middleware = append(middleware, rateLimit)
+	}
+	if options.CircuitBreaker != nil {
+		middleware = append(middleware, NewCircuitBreaker(options.CircuitBreaker).Middleware())
+	}
+	return middleware, nil
+}
+
+// ownMiddleware replaces the middleware configured by the SqlV2Options of "sql" with new instances, so that
+// a clone doesn't share the state of the rate limiters and of the circuit breaker of the client it was
+// cloned from. The middleware added with Use is kept.
+func (sql *SqlV2) ownMiddleware() error {
+	if sql.newMiddleware == nil {
+		return nil
+	}
+	middleware, err := sql.newMiddleware()
+	if err != nil {
+		return err
+	}
+	sql.middleware = append(middleware, sql.middleware[sql.optionsMiddleware:]...)
+	sql.optionsMiddleware = len(middleware)
+	return nil
+}
+
+// invoke sends the request of an operation through the middleware chain. Error responses are returned as
+// the typed errors of errors.go.
+func (sql *SqlV2) invoke(id string, options interface{}, request *http.Request, result interface{}) (*core.DetailedResponse, error) {
+	handler := Handler(func(op *Operation) (*core.DetailedResponse, error) {
+		response, err := sql.Service.Request(op.Request, op.Result)
+		if err != nil && response != nil && response.StatusCode >= 400 {
+			err = newAPIError(op.ID, response, err)
+		}
+		return response, err
+	})
+	for i := len(sql.middleware) - 1; i >= 0; i-- {
+		handler = sql.middleware[i](handler)
+	}
+	return handler(&Operation{
+		ID:      id,
+		Options: options,
+		Request: request,
+		Result:  result,
+	})
+}
@@ -0,0 +1,145 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Middleware`, func() {
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var requestHeaders []http.Header
+
+	BeforeEach(func() {
+		requestHeaders = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			requestHeaders = append(requestHeaders, req.Header)
+			res.Header().Set("Content-type", "application/json")
+			if req.URL.EscapedPath() == "/sql_jobs/missing" {
+				res.WriteHeader(404)
+				fmt.Fprintf(res, `{"errors": [{"code": "not_found", "message": "job not found"}]}`)
+				return
+			}
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"job_id": "job1", "status": "completed"}`)
+		}))
+
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Invoke middleware in order around every operation`, func() {
+		var calls []string
+		trace := func(name string) sqlv2.Middleware {
+			return func(next sqlv2.Handler) sqlv2.Handler {
+				return func(op *sqlv2.Operation) (*core.DetailedResponse, error) {
+					calls = append(calls, name+" before "+op.ID)
+					response, err := next(op)
+					calls = append(calls, fmt.Sprintf("%s after %s %d", name, op.ID, response.StatusCode))
+					return response, err
+				}
+			}
+		}
+		sqlService.Use(trace("outer"), trace("inner"))
+
+		result, response, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		Expect(response).ToNot(BeNil())
+		Expect(*result.JobID).To(Equal("job1"))
+		Expect(calls).To(Equal([]string{
+			"outer before GetSqlJob", "inner before GetSqlJob",
+			"inner after GetSqlJob 200", "outer after GetSqlJob 200",
+		}))
+
+		calls = nil
+		_, _, err = sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions())
+		Expect(err).To(BeNil())
+		Expect(calls[0]).To(Equal("outer before ListSqlJobs"))
+	})
+
+	It(`Expose the options, request and error response`, func() {
+		var operation *sqlv2.Operation
+		var detailedResponse *core.DetailedResponse
+		sqlService.Use(func(next sqlv2.Handler) sqlv2.Handler {
+			return func(op *sqlv2.Operation) (*core.DetailedResponse, error) {
+				op.Request.Header.Set("X-Test", "middleware")
+				operation = op
+				response, err := next(op)
+				detailedResponse = response
+				return response, err
+			}
+		})
+
+		options := sqlService.NewGetSqlJobOptions("missing")
+		_, _, err := sqlService.GetSqlJob(options)
+		Expect(err).ToNot(BeNil())
+		Expect(operation.Options).To(BeIdenticalTo(options))
+		Expect(operation.Request.URL.Query().Get("instance_crn")).To(Equal("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"))
+		Expect(detailedResponse.StatusCode).To(Equal(404))
+		Expect(requestHeaders[0].Get("X-Test")).To(Equal("middleware"))
+	})
+
+	It(`Answer without calling the service`, func() {
+		sqlService.Use(func(next sqlv2.Handler) sqlv2.Handler {
+			return func(op *sqlv2.Operation) (*core.DetailedResponse, error) {
+				return &core.DetailedResponse{StatusCode: 503}, fmt.Errorf("injected fault")
+			}
+		})
+		_, response, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(MatchError("injected fault"))
+		Expect(response.StatusCode).To(Equal(503))
+		Expect(requestHeaders).To(BeEmpty())
+	})
+
+	It(`Copy the chain when cloning`, func() {
+		var count int
+		counter := func(next sqlv2.Handler) sqlv2.Handler {
+			return func(op *sqlv2.Operation) (*core.DetailedResponse, error) {
+				count++
+				return next(op)
+			}
+		}
+		sqlService.Use(counter)
+		clone := sqlService.Clone()
+		clone.Use(counter)
+
+		_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(1))
+		_, _, err = clone.GetSqlJob(clone.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(3))
+	})
+})
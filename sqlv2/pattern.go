@@ -0,0 +1,87 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateNamePattern returns an error if "pattern" isn't a valid table name pattern of ListTablesOptions.
+//
+// A pattern is one or more alternatives separated by vertical bars, like "sales_*|costs". The alternatives
+// are made of letters, digits, underscores, dots and asterisks, which match any sequence of characters. The
+// spaces around the vertical bars are ignored.
+func ValidateNamePattern(pattern string) error {
+	for _, alternative := range strings.Split(pattern, "|") {
+		alternative = strings.TrimSpace(alternative)
+		if alternative == "" {
+			return fmt.Errorf("invalid name pattern %q: empty alternative", pattern)
+		}
+		for _, c := range alternative {
+			if !isPatternChar(c) {
+				return fmt.Errorf("invalid name pattern %q: unexpected character %q", pattern, c)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchNamePattern reports whether the table name "name" matches "pattern", ignoring case, the way the
+// service evaluates the NamePattern of ListTablesOptions. An invalid pattern matches no name.
+func MatchNamePattern(pattern string, name string) bool {
+	if ValidateNamePattern(pattern) != nil {
+		return false
+	}
+	name = strings.ToLower(name)
+	for _, alternative := range strings.Split(pattern, "|") {
+		if matchWildcards(strings.ToLower(strings.TrimSpace(alternative)), name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPatternChar(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '*'
+}
+
+// matchWildcards reports whether "name" matches "pattern", in which the asterisks match any sequence of
+// characters. It backtracks to the last asterisk only, which is enough since an asterisk matches anything.
+func matchWildcards(pattern string, name string) bool {
+	p, n := 0, 0
+	star, next := -1, 0
+	for n < len(name) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, next = p, n
+			p++
+		case p < len(pattern) && pattern[p] == name[n]:
+			p++
+			n++
+		case star >= 0:
+			next++
+			p, n = star+1, next
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
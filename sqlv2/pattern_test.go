@@ -0,0 +1,85 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Name patterns`, func() {
+	It(`Match the names like the service`, func() {
+		for _, test := range []struct {
+			pattern string
+			name    string
+			match   bool
+		}{
+			{"sales", "sales", true},
+			{"sales", "SALES", true},
+			{"sales", "sales_2022", false},
+			{"sales*", "sales_2022", true},
+			{"*_2022", "sales_2022", true},
+			{"s*s*2", "sales_2022", true},
+			{"s*s*1", "sales_2022", false},
+			{"*", "costs", true},
+			{"sales_* | costs", "costs", true},
+			{"sales_*|costs", "costs_2022", false},
+			{"db.*", "db.sales", true},
+			{"sales%", "sales", false},
+		} {
+			Expect(sqlv2.MatchNamePattern(test.pattern, test.name)).To(Equal(test.match), test.pattern+" "+test.name)
+		}
+	})
+
+	It(`Reject the invalid patterns`, func() {
+		for _, pattern := range []string{"", "sales|", "|sales", "sales||costs", "sales%", "sales?", "`sales`", "sa les"} {
+			Expect(sqlv2.ValidateNamePattern(pattern)).ToNot(BeNil(), pattern)
+		}
+		Expect(sqlv2.ValidateNamePattern("sales|")).To(MatchError(`invalid name pattern "sales|": empty alternative`))
+		Expect(sqlv2.ValidateNamePattern("sales?")).To(MatchError(`invalid name pattern "sales?": unexpected character '?'`))
+		Expect(sqlv2.ValidateNamePattern("Sales_* | db.costs_2022")).To(BeNil())
+	})
+
+	It(`Validate the pattern of ListTables without calling the service`, func() {
+		calls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			calls++
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(200)
+			_, _ = res.Write([]byte(`{"tables": []}`))
+		}))
+		defer testServer.Close()
+		sqlService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"),
+		})
+		Expect(err).To(BeNil())
+
+		_, _, err = sqlService.ListTables(sqlService.NewListTablesOptions().SetNamePattern("sales%"))
+		Expect(err).ToNot(BeNil())
+		Expect(calls).To(Equal(0))
+		_, _, err = sqlService.ListTables(sqlService.NewListTablesOptions().SetNamePattern("sales*"))
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal(1))
+	})
+})
@@ -0,0 +1,357 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/go-openapi/strfmt"
+)
+
+// Pool : Clients for several SQL Query instances, each known by an alias. The clients are clones of one
+// SqlV2, so they share its authenticator, HTTP transport, retry settings and the middleware added with
+// Use. Each client has its own middleware configured by the SqlV2Options, so that the rate limits and the
+// circuit breaker of an instance don't affect the others. The operations are routed to an instance by the
+// per-call InstanceCrn override of their options, set to the alias or the CRN of the instance. A Pool is
+// safe for concurrent use.
+type Pool struct {
+	base    *SqlV2
+	mutex   sync.RWMutex
+	clients map[string]*SqlV2
+	aliases map[string]string
+}
+
+// NewPool : constructs a Pool of the instances in "instances", which maps aliases to instance CRNs. The
+// clients of the pool are clones of "sql"; its own InstanceCrn is not used.
+func NewPool(sql *SqlV2, instances map[string]string) (*Pool, error) {
+	err := core.ValidateNotNil(sql, "sql cannot be nil")
+	if err != nil {
+		return nil, err
+	}
+	pool := &Pool{
+		base:    sql,
+		clients: make(map[string]*SqlV2),
+		aliases: make(map[string]string),
+	}
+	for alias, instanceCrn := range instances {
+		if err = pool.Add(alias, instanceCrn); err != nil {
+			return nil, err
+		}
+	}
+	return pool, nil
+}
+
+// Add adds an instance to the pool. "instanceCrn" must be a well-formed instance CRN, see
+// ValidateInstanceCrn, and "alias" must not look like one.
+func (pool *Pool) Add(alias string, instanceCrn string) error {
+	if alias == "" {
+		return fmt.Errorf("the alias of an instance must not be empty")
+	}
+	if strings.HasPrefix(alias, "crn:") {
+		return fmt.Errorf("invalid instance alias %q: an alias must not look like a CRN", RedactCrn(alias))
+	}
+	if err := ValidateInstanceCrn(instanceCrn); err != nil {
+		return err
+	}
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	if _, ok := pool.clients[alias]; ok {
+		return fmt.Errorf("instance alias %q is already in use", alias)
+	}
+	if other, ok := pool.aliases[instanceCrn]; ok {
+		return fmt.Errorf("instance %s is already in the pool as %q", RedactCrn(instanceCrn), other)
+	}
+	client := pool.base.Clone()
+	client.InstanceCrn = core.StringPtr(instanceCrn)
+	if err := client.ownMiddleware(); err != nil {
+		return err
+	}
+	pool.clients[alias] = client
+	pool.aliases[instanceCrn] = alias
+	return nil
+}
+
+// Aliases returns the aliases of the instances of the pool, in order.
+func (pool *Pool) Aliases() []string {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+	aliases := make([]string, 0, len(pool.clients))
+	for alias := range pool.clients {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// Instance returns the client of an instance, known by its alias or its CRN.
+func (pool *Pool) Instance(instance string) (*SqlV2, error) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+	if client, ok := pool.clients[instance]; ok {
+		return client, nil
+	}
+	if alias, ok := pool.aliases[instance]; ok {
+		return pool.clients[alias], nil
+	}
+	return nil, fmt.Errorf("unknown instance %q", RedactCrn(instance))
+}
+
+// route returns the client of the instance named by the per-call override "instance", an alias or a CRN
+// of the pool.
+func (pool *Pool) route(instance *string) (*SqlV2, error) {
+	if instance == nil {
+		return nil, fmt.Errorf("no instance: set InstanceCrn on the options to the alias or the CRN of an instance of the pool")
+	}
+	return pool.Instance(*instance)
+}
+
+// ListTables : List catalog tables of the instance of the options
+// See SqlV2.ListTables.
+func (pool *Pool) ListTables(listTablesOptions *ListTablesOptions) (result *TableList, response *core.DetailedResponse, err error) {
+	return pool.ListTablesWithContext(context.Background(), listTablesOptions)
+}
+
+// ListTablesWithContext is an alternate form of the ListTables method which supports a Context parameter
+func (pool *Pool) ListTablesWithContext(ctx context.Context, listTablesOptions *ListTablesOptions) (result *TableList, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(listTablesOptions, "listTablesOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	client, err := pool.route(listTablesOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	routed := *listTablesOptions
+	routed.InstanceCrn = nil
+	return client.ListTablesWithContext(ctx, &routed)
+}
+
+// GetTable : Get information about a specific catalog table of the instance of the options
+// See SqlV2.GetTable.
+func (pool *Pool) GetTable(getTableOptions *GetTableOptions) (result *TableInformation, response *core.DetailedResponse, err error) {
+	return pool.GetTableWithContext(context.Background(), getTableOptions)
+}
+
+// GetTableWithContext is an alternate form of the GetTable method which supports a Context parameter
+func (pool *Pool) GetTableWithContext(ctx context.Context, getTableOptions *GetTableOptions) (result *TableInformation, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(getTableOptions, "getTableOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	client, err := pool.route(getTableOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	routed := *getTableOptions
+	routed.InstanceCrn = nil
+	return client.GetTableWithContext(ctx, &routed)
+}
+
+// SubmitSqlJob : Run an SQL job on the instance of the options
+// See SqlV2.SubmitSqlJob.
+func (pool *Pool) SubmitSqlJob(submitSqlJobOptions *SubmitSqlJobOptions) (result *SqlJobInfoShort, response *core.DetailedResponse, err error) {
+	return pool.SubmitSqlJobWithContext(context.Background(), submitSqlJobOptions)
+}
+
+// SubmitSqlJobWithContext is an alternate form of the SubmitSqlJob method which supports a Context parameter
+func (pool *Pool) SubmitSqlJobWithContext(ctx context.Context, submitSqlJobOptions *SubmitSqlJobOptions) (result *SqlJobInfoShort, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(submitSqlJobOptions, "submitSqlJobOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	client, err := pool.route(submitSqlJobOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	routed := *submitSqlJobOptions
+	routed.InstanceCrn = nil
+	return client.SubmitSqlJobWithContext(ctx, &routed)
+}
+
+// ListSqlJobs : List jobs of the instance of the options
+// See SqlV2.ListSqlJobs.
+func (pool *Pool) ListSqlJobs(listSqlJobsOptions *ListSqlJobsOptions) (result *SqlJobInfoList, response *core.DetailedResponse, err error) {
+	return pool.ListSqlJobsWithContext(context.Background(), listSqlJobsOptions)
+}
+
+// ListSqlJobsWithContext is an alternate form of the ListSqlJobs method which supports a Context parameter
+func (pool *Pool) ListSqlJobsWithContext(ctx context.Context, listSqlJobsOptions *ListSqlJobsOptions) (result *SqlJobInfoList, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(listSqlJobsOptions, "listSqlJobsOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	client, err := pool.route(listSqlJobsOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	routed := *listSqlJobsOptions
+	routed.InstanceCrn = nil
+	return client.ListSqlJobsWithContext(ctx, &routed)
+}
+
+// GetSqlJob : Get information about a job of the instance of the options
+// See SqlV2.GetSqlJob.
+func (pool *Pool) GetSqlJob(getSqlJobOptions *GetSqlJobOptions) (result *SqlJobInfoFull, response *core.DetailedResponse, err error) {
+	return pool.GetSqlJobWithContext(context.Background(), getSqlJobOptions)
+}
+
+// GetSqlJobWithContext is an alternate form of the GetSqlJob method which supports a Context parameter
+func (pool *Pool) GetSqlJobWithContext(ctx context.Context, getSqlJobOptions *GetSqlJobOptions) (result *SqlJobInfoFull, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(getSqlJobOptions, "getSqlJobOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	client, err := pool.route(getSqlJobOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	routed := *getSqlJobOptions
+	routed.InstanceCrn = nil
+	return client.GetSqlJobWithContext(ctx, &routed)
+}
+
+// InstanceSqlJob : A job returned by Pool.ListAllSqlJobs, with the alias of its instance.
+type InstanceSqlJob struct {
+	Instance string
+	SqlJobInfoShort
+}
+
+// InstanceTable : A table returned by Pool.ListAllTables, with the alias of its instance.
+type InstanceTable struct {
+	Instance string
+	TableMetadata
+}
+
+// PoolError : The errors of the instances that failed during a fan-out call, keyed by alias.
+type PoolError struct {
+	Errors map[string]error
+}
+
+// Error returns the error message.
+func (err *PoolError) Error() string {
+	aliases := make([]string, 0, len(err.Errors))
+	for alias := range err.Errors {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+	messages := make([]string, len(aliases))
+	for i, alias := range aliases {
+		messages[i] = alias + ": " + err.Errors[alias].Error()
+	}
+	return fmt.Sprintf("%d instance(s) failed: %s", len(aliases), strings.Join(messages, "; "))
+}
+
+// ListAllSqlJobs calls ListSqlJobs on every instance concurrently and merges the jobs, most recently
+// submitted first. The InstanceCrn of the options is ignored. If some instances fail, the jobs of the
+// others are returned with a *PoolError.
+func (pool *Pool) ListAllSqlJobs(ctx context.Context, listSqlJobsOptions *ListSqlJobsOptions) ([]InstanceSqlJob, error) {
+	routed := &ListSqlJobsOptions{}
+	if listSqlJobsOptions != nil {
+		*routed = *listSqlJobsOptions
+		routed.InstanceCrn = nil
+	}
+	var jobs []InstanceSqlJob
+	err := pool.fanOut(func(alias string, client *SqlV2, mutex *sync.Mutex) error {
+		result, _, err := client.ListSqlJobsWithContext(ctx, routed)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, job := range result.Jobs {
+			jobs = append(jobs, InstanceSqlJob{Instance: alias, SqlJobInfoShort: job})
+		}
+		return nil
+	})
+	sort.SliceStable(jobs, func(i, j int) bool {
+		ti, tj := submitTime(jobs[i].SubmitTime), submitTime(jobs[j].SubmitTime)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return jobs[i].Instance < jobs[j].Instance
+	})
+	return jobs, err
+}
+
+// ListAllTables calls ListTables on every instance concurrently and merges the tables, ordered by instance
+// alias. The InstanceCrn of the options is ignored. If some instances fail, the tables of the others are
+// returned with a *PoolError.
+func (pool *Pool) ListAllTables(ctx context.Context, listTablesOptions *ListTablesOptions) ([]InstanceTable, error) {
+	routed := &ListTablesOptions{}
+	if listTablesOptions != nil {
+		*routed = *listTablesOptions
+		routed.InstanceCrn = nil
+	}
+	var tables []InstanceTable
+	err := pool.fanOut(func(alias string, client *SqlV2, mutex *sync.Mutex) error {
+		result, _, err := client.ListTablesWithContext(ctx, routed)
+		if err != nil {
+			return err
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, table := range result.TablesMetadata {
+			tables = append(tables, InstanceTable{Instance: alias, TableMetadata: table})
+		}
+		return nil
+	})
+	sort.SliceStable(tables, func(i, j int) bool {
+		return tables[i].Instance < tables[j].Instance
+	})
+	return tables, err
+}
+
+// fanOut calls "call" concurrently for every instance. "mutex" serializes the merging of the results.
+func (pool *Pool) fanOut(call func(alias string, client *SqlV2, mutex *sync.Mutex) error) error {
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[string]error)
+	for _, alias := range pool.Aliases() {
+		client, err := pool.Instance(alias)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(alias string, client *SqlV2) {
+			defer wg.Done()
+			if err := call(alias, client, &mutex); err != nil {
+				mutex.Lock()
+				errs[alias] = err
+				mutex.Unlock()
+			}
+		}(alias, client)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return &PoolError{Errors: errs}
+	}
+	return nil
+}
+
+// submitTime returns the time of "dateTime", or the zero time if nil.
+func submitTime(dateTime *strfmt.DateTime) time.Time {
+	if dateTime == nil {
+		return time.Time{}
+	}
+	return time.Time(*dateTime)
+}
@@ -0,0 +1,174 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Pool`, func() {
+	const devCrn = "crn:v1:bluemix:public:sql-query:us-south:a/123:dev-instance::"
+	const prodCrn = "crn:v1:bluemix:public:sql-query:eu-de:a/123:prod-instance::"
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var pool *sqlv2.Pool
+
+	BeforeEach(func() {
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			Expect(req.Header.Get("Authorization")).To(Equal("Basic dXNlcjpwYXNz"))
+			instanceCrn := req.URL.Query().Get("instance_crn")
+			res.Header().Set("Content-type", "application/json")
+			switch {
+			case instanceCrn == "crn:v1:bluemix:public:sql-query:us-east:a/123:broken::":
+				res.WriteHeader(500)
+				fmt.Fprintf(res, `{"errors": [{"code": "internal", "message": "broken"}]}`)
+			case req.URL.EscapedPath() == "/sql_jobs" && instanceCrn == devCrn:
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"jobs": [{"job_id": "dev1", "status": "completed", "submit_time": "2022-01-01T10:00:00.000Z"}]}`)
+			case req.URL.EscapedPath() == "/sql_jobs" && instanceCrn == prodCrn:
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"jobs": [{"job_id": "prod2", "status": "running", "submit_time": "2022-01-01T12:00:00.000Z"}, `+
+					`{"job_id": "prod1", "status": "completed", "submit_time": "2022-01-01T09:00:00.000Z"}]}`)
+			case req.URL.EscapedPath() == "/tables":
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"tables": ["t"], "tables_metadata": [{"name": "t", "type": "TABLE"}]}`)
+			default:
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"job_id": "%s", "status": "running"}`, instanceCrn)
+			}
+		}))
+
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.BasicAuthenticator{Username: "user", Password: "pass"},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+		})
+		Expect(err).To(BeNil())
+		pool, err = sqlv2.NewPool(sqlService, map[string]string{"dev": devCrn, "prod": prodCrn})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Route calls by the alias or the CRN of the instance override`, func() {
+		Expect(pool.Aliases()).To(Equal([]string{"dev", "prod"}))
+
+		options := sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn("prod")
+		result, _, err := pool.GetSqlJob(options)
+		Expect(err).To(BeNil())
+		Expect(*result.JobID).To(Equal(prodCrn))
+		Expect(*options.InstanceCrn).To(Equal("prod"))
+		result, _, err = pool.GetSqlJob(sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn(devCrn))
+		Expect(err).To(BeNil())
+		Expect(*result.JobID).To(Equal(devCrn))
+		jobs, _, err := pool.ListSqlJobs(sqlService.NewListSqlJobsOptions().SetInstanceCrn("dev"))
+		Expect(err).To(BeNil())
+		Expect(*jobs.Jobs[0].JobID).To(Equal("dev1"))
+
+		_, _, err = pool.GetSqlJob(sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn("test"))
+		Expect(err).To(MatchError(`unknown instance "***"`))
+		_, _, err = pool.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).ToNot(BeNil())
+		_, _, err = pool.ListSqlJobs(nil)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It(`Share the transport and the authenticator`, func() {
+		dev, err := pool.Instance("dev")
+		Expect(err).To(BeNil())
+		prod, err := pool.Instance("prod")
+		Expect(err).To(BeNil())
+		Expect(dev.Service.Client).To(BeIdenticalTo(sqlService.Service.Client))
+		Expect(prod.Service.Options.Authenticator).To(BeIdenticalTo(sqlService.Service.Options.Authenticator))
+		Expect(*sqlService.InstanceCrn).To(Equal("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"))
+	})
+
+	It(`Reject duplicate instances`, func() {
+		Expect(pool.Add("dev", "crn:v1:bluemix:public:sql-query:us-south:a/123:other::")).ToNot(BeNil())
+		Expect(pool.Add("dev2", devCrn)).ToNot(BeNil())
+		Expect(pool.Add("", devCrn)).ToNot(BeNil())
+		Expect(pool.Add("other", "other-instance")).ToNot(BeNil())
+		Expect(pool.Add("other", "crn:v1:bluemix:public:cloud-object-storage:global:a/123:other::")).ToNot(BeNil())
+		Expect(pool.Add("crn:v1:bluemix:public:sql-query:us-south:a/123:x::", "crn:v1:bluemix:public:sql-query:us-south:a/123:other::")).ToNot(BeNil())
+		Expect(pool.Aliases()).To(Equal([]string{"dev", "prod"}))
+		_, err := sqlv2.NewPool(nil, nil)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It(`Merge the jobs and tables of every instance`, func() {
+		jobs, err := pool.ListAllSqlJobs(context.Background(), sqlService.NewListSqlJobsOptions())
+		Expect(err).To(BeNil())
+		Expect(jobs).To(HaveLen(3))
+		Expect(jobs[0].Instance).To(Equal("prod"))
+		Expect(*jobs[0].JobID).To(Equal("prod2"))
+		Expect(jobs[1].Instance).To(Equal("dev"))
+		Expect(*jobs[2].JobID).To(Equal("prod1"))
+
+		tables, err := pool.ListAllTables(context.Background(), sqlService.NewListTablesOptions())
+		Expect(err).To(BeNil())
+		Expect(tables).To(HaveLen(2))
+		Expect(tables[0].Instance).To(Equal("dev"))
+		Expect(*tables[1].Name).To(Equal("t"))
+	})
+
+	It(`Give every instance its own circuit breaker`, func() {
+		breakerService, err := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:            testServer.URL,
+			Authenticator:  &core.BasicAuthenticator{Username: "user", Password: "pass"},
+			InstanceCrn:    core.StringPtr(devCrn),
+			CircuitBreaker: &sqlv2.CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Hour},
+		})
+		Expect(err).To(BeNil())
+		pool, err = sqlv2.NewPool(breakerService, map[string]string{"dev": devCrn, "broken": "crn:v1:bluemix:public:sql-query:us-east:a/123:broken::"})
+		Expect(err).To(BeNil())
+
+		for i := 0; i < 2; i++ {
+			_, _, err = pool.GetSqlJob(sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn("broken"))
+			Expect(err).ToNot(BeNil())
+		}
+		var openErr *sqlv2.CircuitOpenError
+		Expect(errors.As(err, &openErr)).To(BeTrue())
+		_, _, err = pool.GetSqlJob(sqlService.NewGetSqlJobOptions("job1").SetInstanceCrn("dev"))
+		Expect(err).To(BeNil())
+		_, _, err = breakerService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+	})
+
+	It(`Return partial results when instances fail`, func() {
+		Expect(pool.Add("broken", "crn:v1:bluemix:public:sql-query:us-east:a/123:broken::")).To(BeNil())
+		jobs, err := pool.ListAllSqlJobs(context.Background(), sqlService.NewListSqlJobsOptions())
+		Expect(jobs).To(HaveLen(3))
+		var poolErr *sqlv2.PoolError
+		Expect(errors.As(err, &poolErr)).To(BeTrue())
+		Expect(poolErr.Errors).To(HaveKey("broken"))
+		Expect(err.Error()).To(Equal("1 instance(s) failed: broken: broken"))
+	})
+})
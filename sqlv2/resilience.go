@@ -0,0 +1,321 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+)
+
+// RateLimitAllOperations is the key of SqlV2Options.RateLimits applying to the operations without a limit
+// of their own. Each operation still gets a separate bucket.
+const RateLimitAllOperations = "*"
+
+// RateLimit : The configuration of a token bucket.
+type RateLimit struct {
+	// The number of calls allowed per second, on average. It must be positive.
+	Rate float64
+
+	// The number of calls allowed in a burst. Defaults to 1 when zero, and must not be negative.
+	Burst int
+}
+
+// RateLimiter is a token bucket. It is safe for concurrent use.
+type RateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter : constructs a RateLimiter with a full bucket. It returns an error if the rate of "limit"
+// isn't positive, since the calls would wait forever, or if its burst is negative.
+func NewRateLimiter(limit RateLimit) (*RateLimiter, error) {
+	if !(limit.Rate > 0) || math.IsInf(limit.Rate, 1) {
+		return nil, fmt.Errorf("invalid rate limit: the rate %g isn't a positive number", limit.Rate)
+	}
+	if limit.Burst < 0 {
+		return nil, fmt.Errorf("invalid rate limit: the burst %d is negative", limit.Burst)
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}, nil
+}
+
+// Wait blocks until a call is allowed or "ctx" is done.
+func (limiter *RateLimiter) Wait(ctx context.Context) error {
+	delay := limiter.reserve()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		limiter.cancel()
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, possibly going into debt, and returns how long to wait for it.
+func (limiter *RateLimiter) reserve() time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	now := time.Now()
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+now.Sub(limiter.last).Seconds()*limiter.rate)
+	limiter.last = now
+	limiter.tokens--
+	if limiter.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-limiter.tokens / limiter.rate * float64(time.Second))
+}
+
+// cancel gives back a token reserved by a call that stopped waiting.
+func (limiter *RateLimiter) cancel() {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+	limiter.tokens = math.Min(limiter.burst, limiter.tokens+1)
+}
+
+// RateLimitMiddleware returns a Middleware delaying the calls of each operation to respect its limit in
+// "limits", keyed by operation ID or RateLimitAllOperations. Operations without a limit are not delayed.
+// A call whose context is done while waiting fails with the error of the context. It returns an error if
+// one of the limits is invalid, see NewRateLimiter.
+func RateLimitMiddleware(limits map[string]RateLimit) (Middleware, error) {
+	for id, limit := range limits {
+		if _, err := NewRateLimiter(limit); err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	limiters := make(map[string]*RateLimiter)
+	for _, id := range []string{OperationID_ListTables, OperationID_GetTable, OperationID_SubmitSqlJob, OperationID_ListSqlJobs, OperationID_GetSqlJob} {
+		limit, ok := limits[id]
+		if !ok {
+			limit, ok = limits[RateLimitAllOperations]
+		}
+		if ok {
+			// The limits are valid, see above.
+			limiters[id], _ = NewRateLimiter(limit)
+		}
+	}
+	return func(next Handler) Handler {
+		return func(op *Operation) (*core.DetailedResponse, error) {
+			if limiter, ok := limiters[op.ID]; ok {
+				if err := limiter.Wait(op.Request.Context()); err != nil {
+					return nil, err
+				}
+			}
+			return next(op)
+		}
+	}, nil
+}
+
+// CircuitState : The state of a CircuitBreaker.
+type CircuitState int
+
+// The states of a CircuitBreaker.
+const (
+	// Calls go through; failures are counted.
+	CircuitState_Closed CircuitState = iota
+
+	// Calls fail fast with a *CircuitOpenError.
+	CircuitState_Open
+
+	// A limited number of probe calls go through to test whether the service recovered.
+	CircuitState_HalfOpen
+)
+
+// String returns the name of the state.
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitState_Closed:
+		return "closed"
+	case CircuitState_Open:
+		return "open"
+	case CircuitState_HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(state))
+}
+
+// The defaults of CircuitBreakerOptions.
+const (
+	DefaultCircuitFailureThreshold = 5
+	DefaultCircuitOpenTimeout      = 30 * time.Second
+	DefaultCircuitHalfOpenProbes   = 1
+)
+
+// CircuitBreakerOptions : The CircuitBreaker options.
+type CircuitBreakerOptions struct {
+	// The number of consecutive failures that open the circuit. Defaults to DefaultCircuitFailureThreshold.
+	FailureThreshold int
+
+	// How long the circuit stays open before probing the service. Defaults to DefaultCircuitOpenTimeout.
+	OpenTimeout time.Duration
+
+	// The number of concurrent probe calls allowed while half-open. Defaults to DefaultCircuitHalfOpenProbes.
+	HalfOpenProbes int
+}
+
+// CircuitOpenError is returned, without calling the service, by the operations of a client whose circuit
+// breaker is open.
+type CircuitOpenError struct {
+	// The ID of the operation that was rejected.
+	Operation string
+
+	// When the circuit breaker will let a probe call through.
+	RetryAt time.Time
+}
+
+// Error returns the error message.
+func (err *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s rejected: the circuit breaker is open until %s", err.Operation, err.RetryAt.Format(time.RFC3339))
+}
+
+// CircuitBreaker stops calling the service after consecutive failures. A failure is a response with a
+// 5xx or 429 status code, or a request that couldn't be sent for a reason other than its context. When
+// automatic retries are enabled, only the outcome of the last attempt counts. It is safe for concurrent use.
+type CircuitBreaker struct {
+	options  CircuitBreakerOptions
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// NewCircuitBreaker : constructs a closed CircuitBreaker. "options" may be nil.
+func NewCircuitBreaker(options *CircuitBreakerOptions) *CircuitBreaker {
+	breaker := &CircuitBreaker{}
+	if options != nil {
+		breaker.options = *options
+	}
+	if breaker.options.FailureThreshold <= 0 {
+		breaker.options.FailureThreshold = DefaultCircuitFailureThreshold
+	}
+	if breaker.options.OpenTimeout <= 0 {
+		breaker.options.OpenTimeout = DefaultCircuitOpenTimeout
+	}
+	if breaker.options.HalfOpenProbes <= 0 {
+		breaker.options.HalfOpenProbes = DefaultCircuitHalfOpenProbes
+	}
+	return breaker
+}
+
+// State returns the current state of the breaker.
+func (breaker *CircuitBreaker) State() CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if breaker.state == CircuitState_Open && time.Since(breaker.openedAt) >= breaker.options.OpenTimeout {
+		return CircuitState_HalfOpen
+	}
+	return breaker.state
+}
+
+// Middleware returns a Middleware guarding every operation with the breaker.
+func (breaker *CircuitBreaker) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(op *Operation) (*core.DetailedResponse, error) {
+			probe, err := breaker.allow(op.ID)
+			if err != nil {
+				return nil, err
+			}
+			response, err := next(op)
+			breaker.record(probe, isCircuitFailure(op.Request.Context(), response, err))
+			return response, err
+		}
+	}
+}
+
+// allow returns whether a call may go through, and if so whether it is a probe.
+func (breaker *CircuitBreaker) allow(operation string) (probe bool, err error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if breaker.state == CircuitState_Open {
+		retryAt := breaker.openedAt.Add(breaker.options.OpenTimeout)
+		if time.Now().Before(retryAt) {
+			return false, &CircuitOpenError{Operation: operation, RetryAt: retryAt}
+		}
+		breaker.state = CircuitState_HalfOpen
+		breaker.probes = 0
+	}
+	if breaker.state == CircuitState_HalfOpen {
+		if breaker.probes >= breaker.options.HalfOpenProbes {
+			return false, &CircuitOpenError{Operation: operation, RetryAt: time.Now().Add(breaker.options.OpenTimeout)}
+		}
+		breaker.probes++
+		return true, nil
+	}
+	return false, nil
+}
+
+// record updates the breaker with the outcome of a call.
+func (breaker *CircuitBreaker) record(probe bool, failure bool) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if probe {
+		breaker.probes--
+		if breaker.state != CircuitState_HalfOpen {
+			return
+		}
+		if failure {
+			breaker.state = CircuitState_Open
+			breaker.openedAt = time.Now()
+		} else {
+			breaker.state = CircuitState_Closed
+			breaker.failures = 0
+		}
+		return
+	}
+	if breaker.state != CircuitState_Closed {
+		return
+	}
+	if !failure {
+		breaker.failures = 0
+		return
+	}
+	breaker.failures++
+	if breaker.failures >= breaker.options.FailureThreshold {
+		breaker.state = CircuitState_Open
+		breaker.openedAt = time.Now()
+	}
+}
+
+// isCircuitFailure returns true if the outcome of a call shows that the service is degraded.
+func isCircuitFailure(ctx context.Context, response *core.DetailedResponse, err error) bool {
+	if response != nil && response.StatusCode != 0 {
+		return response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+	}
+	return err != nil && ctx.Err() == nil
+}
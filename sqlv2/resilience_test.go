@@ -0,0 +1,206 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Resilience`, func() {
+	var testServer *httptest.Server
+	var mutex sync.Mutex
+	var statusCode int
+	var requests int
+
+	newService := func(options *sqlv2.SqlV2Options) *sqlv2.SqlV2 {
+		options.URL = testServer.URL
+		options.Authenticator = &core.NoAuthAuthenticator{}
+		options.InstanceCrn = core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::")
+		sqlService, err := sqlv2.NewSqlV2(options)
+		Expect(err).To(BeNil())
+		return sqlService
+	}
+	setStatus := func(code int) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		statusCode = code
+	}
+	requestCount := func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests
+	}
+
+	BeforeEach(func() {
+		statusCode = 200
+		requests = 0
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			mutex.Lock()
+			requests++
+			code := statusCode
+			mutex.Unlock()
+			res.Header().Set("Content-type", "application/json")
+			res.Header().Set("Retry-After", "0")
+			res.WriteHeader(code)
+			if code != 200 {
+				fmt.Fprintf(res, `{"errors": [{"code": "error", "message": "status %d"}]}`, code)
+				return
+			}
+			fmt.Fprintf(res, `{"job_id": "job1", "status": "running"}`)
+		}))
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	Describe(`Rate limiter`, func() {
+		It(`Delay the calls beyond the burst of each operation`, func() {
+			sqlService := newService(&sqlv2.SqlV2Options{
+				RateLimits: map[string]sqlv2.RateLimit{
+					sqlv2.OperationID_GetSqlJob:  {Rate: 20, Burst: 2},
+					sqlv2.RateLimitAllOperations: {Rate: 1000, Burst: 1},
+				},
+			})
+
+			start := time.Now()
+			for i := 0; i < 4; i++ {
+				_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+				Expect(err).To(BeNil())
+			}
+			Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+
+			start = time.Now()
+			_, _, err := sqlService.ListSqlJobs(sqlService.NewListSqlJobsOptions())
+			Expect(err).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+		})
+
+		It(`Stop waiting when the context is done`, func() {
+			sqlService := newService(&sqlv2.SqlV2Options{
+				RateLimits: map[string]sqlv2.RateLimit{sqlv2.OperationID_GetSqlJob: {Rate: 0.01}},
+			})
+			_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, _, err = sqlService.GetSqlJobWithContext(ctx, sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(requestCount()).To(Equal(1))
+		})
+
+		It(`Reject the limits that would block the calls forever`, func() {
+			for _, limit := range []sqlv2.RateLimit{{Rate: 0}, {Rate: -1, Burst: 5}, {Rate: 1, Burst: -1}} {
+				_, err := sqlv2.NewRateLimiter(limit)
+				Expect(err).ToNot(BeNil())
+				_, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+					URL:           testServer.URL,
+					Authenticator: &core.NoAuthAuthenticator{},
+					RateLimits:    map[string]sqlv2.RateLimit{sqlv2.OperationID_GetSqlJob: limit},
+				})
+				Expect(err).ToNot(BeNil())
+			}
+			_, err := sqlv2.RateLimitMiddleware(map[string]sqlv2.RateLimit{sqlv2.RateLimitAllOperations: {}})
+			Expect(err).To(MatchError("*: invalid rate limit: the rate 0 isn't a positive number"))
+			limiter, err := sqlv2.NewRateLimiter(sqlv2.RateLimit{Rate: 1})
+			Expect(err).To(BeNil())
+			Expect(limiter.Wait(context.Background())).To(Succeed())
+		})
+	})
+
+	Describe(`Circuit breaker`, func() {
+		It(`Open after consecutive failures and recover through a probe`, func() {
+			breaker := sqlv2.NewCircuitBreaker(&sqlv2.CircuitBreakerOptions{FailureThreshold: 3, OpenTimeout: 50 * time.Millisecond})
+			sqlService := newService(&sqlv2.SqlV2Options{})
+			sqlService.Use(breaker.Middleware())
+
+			setStatus(503)
+			for i := 0; i < 3; i++ {
+				Expect(breaker.State()).To(Equal(sqlv2.CircuitState_Closed))
+				_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+				Expect(err).ToNot(BeNil())
+			}
+			Expect(breaker.State()).To(Equal(sqlv2.CircuitState_Open))
+
+			_, response, err := sqlService.SubmitSqlJob(sqlService.NewSubmitSqlJobOptions("SELECT 1"))
+			Expect(response).To(BeNil())
+			var openErr *sqlv2.CircuitOpenError
+			Expect(errors.As(err, &openErr)).To(BeTrue())
+			Expect(openErr.Operation).To(Equal("SubmitSqlJob"))
+			Expect(requestCount()).To(Equal(3))
+
+			time.Sleep(60 * time.Millisecond)
+			Expect(breaker.State()).To(Equal(sqlv2.CircuitState_HalfOpen))
+			_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).ToNot(BeNil())
+			Expect(breaker.State()).To(Equal(sqlv2.CircuitState_Open))
+
+			time.Sleep(60 * time.Millisecond)
+			setStatus(200)
+			_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).To(BeNil())
+			Expect(breaker.State()).To(Equal(sqlv2.CircuitState_Closed))
+		})
+
+		It(`Count 429 but not other client errors`, func() {
+			breaker := sqlv2.NewCircuitBreaker(&sqlv2.CircuitBreakerOptions{FailureThreshold: 2})
+			sqlService := newService(&sqlv2.SqlV2Options{})
+			sqlService.Use(breaker.Middleware())
+
+			setStatus(429)
+			_, _, _ = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			setStatus(404)
+			_, _, _ = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			setStatus(429)
+			_, _, _ = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(breaker.State()).To(Equal(sqlv2.CircuitState_Closed))
+			_, _, _ = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(breaker.State()).To(Equal(sqlv2.CircuitState_Open))
+		})
+
+		It(`Count the outcome of retried calls once`, func() {
+			sqlService := newService(&sqlv2.SqlV2Options{
+				CircuitBreaker: &sqlv2.CircuitBreakerOptions{FailureThreshold: 2, OpenTimeout: time.Minute},
+			})
+			sqlService.EnableRetries(2, 0)
+			setStatus(503)
+			_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).ToNot(BeNil())
+			Expect(requestCount()).To(Equal(3))
+
+			_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).ToNot(BeNil())
+			_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+			var openErr *sqlv2.CircuitOpenError
+			Expect(errors.As(err, &openErr)).To(BeTrue())
+			Expect(requestCount()).To(Equal(6))
+		})
+	})
+})
@@ -27,10 +27,13 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
+	"text/template"
 	"time"
 
 	"github.com/IBM/go-sdk-core/v5/core"
 	common "github.com/IBM/sql-query-go-sdk/common"
+	"github.com/IBM/sql-query-go-sdk/metrics"
+	"github.com/IBM/sql-query-go-sdk/tracing"
 	"github.com/go-openapi/strfmt"
 )
 
@@ -43,6 +46,17 @@ type SqlV2 struct {
 
 	// The cloud resource name (CRN) of the SQL query service instance.
 	InstanceCrn *string
+
+	// The middleware wrapping every operation, see Use.
+	middleware []Middleware
+
+	// Builds the middleware configured by the SqlV2Options, which are the first "optionsMiddleware" ones of
+	// "middleware", see ownMiddleware.
+	newMiddleware     func() ([]Middleware, error)
+	optionsMiddleware int
+
+	// The template of the resultset target of the jobs submitted without one, see SetDefaultResultsetTarget.
+	defaultResultsetTarget *template.Template
 }
 
 // DefaultServiceURL is the default URL to make service requests to.
@@ -59,6 +73,28 @@ type SqlV2Options struct {
 
 	// The cloud resource name (CRN) of the SQL query service instance.
 	InstanceCrn *string `validate:"required"`
+
+	// If set, a span is created with this tracer for every operation, see TracingMiddleware.
+	Tracer *tracing.Tracer
+
+	// The recorder of request and job metrics, see MetricsMiddleware. Defaults to metrics.Noop.
+	Metrics metrics.Recorder
+
+	// If set, every operation is logged with this logger, see LoggingMiddleware.
+	Logger Logger
+
+	// The logging options, used with Logger.
+	Logging *LoggingOptions
+
+	// The rate limits of the operations, keyed by operation ID or RateLimitAllOperations, see
+	// RateLimitMiddleware.
+	RateLimits map[string]RateLimit
+
+	// If set, the operations are guarded by a circuit breaker with these options, see CircuitBreaker.
+	CircuitBreaker *CircuitBreakerOptions
+
+	// The template of the resultset target of the jobs submitted without one, see SetDefaultResultsetTarget.
+	DefaultResultsetTarget string
 }
 
 // NewSqlV2UsingExternalConfig : constructs an instance of SqlV2 with passed in options and external configuration.
@@ -102,6 +138,11 @@ func NewSqlV2(options *SqlV2Options) (service *SqlV2, err error) {
 		return
 	}
 
+	err = ValidateInstanceCrn(*options.InstanceCrn)
+	if err != nil {
+		return
+	}
+
 	baseService, err := core.NewBaseService(serviceOptions)
 	if err != nil {
 		return
@@ -118,6 +159,20 @@ func NewSqlV2(options *SqlV2Options) (service *SqlV2, err error) {
 		Service:     baseService,
 		InstanceCrn: options.InstanceCrn,
 	}
+	err = service.SetDefaultResultsetTarget(options.DefaultResultsetTarget)
+	if err != nil {
+		service = nil
+		return
+	}
+	middlewareOptions := *options
+	service.newMiddleware = func() ([]Middleware, error) {
+		return newOptionsMiddleware(&middlewareOptions)
+	}
+	err = service.ownMiddleware()
+	if err != nil {
+		service = nil
+		return
+	}
 
 	return
 }
@@ -134,6 +189,7 @@ func (sql *SqlV2) Clone() *SqlV2 {
 	}
 	clone := *sql
 	clone.Service = sql.Service.Clone()
+	clone.middleware = append([]Middleware(nil), sql.middleware...)
 	return &clone
 }
 
@@ -204,8 +260,16 @@ func (sql *SqlV2) ListTablesWithContext(ctx context.Context, listTablesOptions *
 	}
 	builder.AddHeader("Accept", "application/json")
 
-	builder.AddQuery("instance_crn", fmt.Sprint(*sql.InstanceCrn))
+	instanceCrn, err := sql.instanceCrn(listTablesOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	builder.AddQuery("instance_crn", fmt.Sprint(*instanceCrn))
 	if listTablesOptions.NamePattern != nil {
+		err = ValidateNamePattern(*listTablesOptions.NamePattern)
+		if err != nil {
+			return
+		}
 		builder.AddQuery("name_pattern", fmt.Sprint(*listTablesOptions.NamePattern))
 	}
 	if listTablesOptions.Type != nil {
@@ -218,7 +282,7 @@ func (sql *SqlV2) ListTablesWithContext(ctx context.Context, listTablesOptions *
 	}
 
 	var rawResponse map[string]json.RawMessage
-	response, err = sql.Service.Request(request, &rawResponse)
+	response, err = sql.invoke(OperationID_ListTables, listTablesOptions, request, &rawResponse)
 	if err != nil {
 		return
 	}
@@ -270,7 +334,11 @@ func (sql *SqlV2) GetTableWithContext(ctx context.Context, getTableOptions *GetT
 	}
 	builder.AddHeader("Accept", "application/json")
 
-	builder.AddQuery("instance_crn", fmt.Sprint(*sql.InstanceCrn))
+	instanceCrn, err := sql.instanceCrn(getTableOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	builder.AddQuery("instance_crn", fmt.Sprint(*instanceCrn))
 
 	request, err := builder.Build()
 	if err != nil {
@@ -278,7 +346,7 @@ func (sql *SqlV2) GetTableWithContext(ctx context.Context, getTableOptions *GetT
 	}
 
 	var rawResponse map[string]json.RawMessage
-	response, err = sql.Service.Request(request, &rawResponse)
+	response, err = sql.invoke(OperationID_GetTable, getTableOptions, request, &rawResponse)
 	if err != nil {
 		return
 	}
@@ -330,14 +398,24 @@ func (sql *SqlV2) SubmitSqlJobWithContext(ctx context.Context, submitSqlJobOptio
 	builder.AddHeader("Accept", "application/json")
 	builder.AddHeader("Content-Type", "application/json")
 
-	builder.AddQuery("instance_crn", fmt.Sprint(*sql.InstanceCrn))
+	instanceCrn, err := sql.instanceCrn(submitSqlJobOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	builder.AddQuery("instance_crn", fmt.Sprint(*instanceCrn))
 
 	body := make(map[string]interface{})
 	if submitSqlJobOptions.Statement != nil {
 		body["statement"] = submitSqlJobOptions.Statement
 	}
-	if submitSqlJobOptions.ResultsetTarget != nil {
-		body["resultset_target"] = submitSqlJobOptions.ResultsetTarget
+	// The middlewares see the options with the resultset target the job is submitted with.
+	resolved := *submitSqlJobOptions
+	resolved.ResultsetTarget, err = sql.resultsetTarget(submitSqlJobOptions)
+	if err != nil {
+		return
+	}
+	if resolved.ResultsetTarget != nil {
+		body["resultset_target"] = resolved.ResultsetTarget
 	}
 	_, err = builder.SetBodyContentJSON(body)
 	if err != nil {
@@ -350,7 +428,7 @@ func (sql *SqlV2) SubmitSqlJobWithContext(ctx context.Context, submitSqlJobOptio
 	}
 
 	var rawResponse map[string]json.RawMessage
-	response, err = sql.Service.Request(request, &rawResponse)
+	response, err = sql.invoke(OperationID_SubmitSqlJob, &resolved, request, &rawResponse)
 	if err != nil {
 		return
 	}
@@ -394,7 +472,11 @@ func (sql *SqlV2) ListSqlJobsWithContext(ctx context.Context, listSqlJobsOptions
 	}
 	builder.AddHeader("Accept", "application/json")
 
-	builder.AddQuery("instance_crn", fmt.Sprint(*sql.InstanceCrn))
+	instanceCrn, err := sql.instanceCrn(listSqlJobsOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	builder.AddQuery("instance_crn", fmt.Sprint(*instanceCrn))
 
 	request, err := builder.Build()
 	if err != nil {
@@ -402,7 +484,7 @@ func (sql *SqlV2) ListSqlJobsWithContext(ctx context.Context, listSqlJobsOptions
 	}
 
 	var rawResponse map[string]json.RawMessage
-	response, err = sql.Service.Request(request, &rawResponse)
+	response, err = sql.invoke(OperationID_ListSqlJobs, listSqlJobsOptions, request, &rawResponse)
 	if err != nil {
 		return
 	}
@@ -454,7 +536,11 @@ func (sql *SqlV2) GetSqlJobWithContext(ctx context.Context, getSqlJobOptions *Ge
 	}
 	builder.AddHeader("Accept", "application/json")
 
-	builder.AddQuery("instance_crn", fmt.Sprint(*sql.InstanceCrn))
+	instanceCrn, err := sql.instanceCrn(getSqlJobOptions.InstanceCrn)
+	if err != nil {
+		return
+	}
+	builder.AddQuery("instance_crn", fmt.Sprint(*instanceCrn))
 
 	request, err := builder.Build()
 	if err != nil {
@@ -462,7 +548,7 @@ func (sql *SqlV2) GetSqlJobWithContext(ctx context.Context, getSqlJobOptions *Ge
 	}
 
 	var rawResponse map[string]json.RawMessage
-	response, err = sql.Service.Request(request, &rawResponse)
+	response, err = sql.invoke(OperationID_GetSqlJob, getSqlJobOptions, request, &rawResponse)
 	if err != nil {
 		return
 	}
@@ -481,6 +567,10 @@ type GetSqlJobOptions struct {
 	// when information about recently submitted SQL jobs is requested.
 	JobID *string `validate:"required,ne="`
 
+	// The cloud resource name (CRN) of the SQL query service instance to use instead of the default instance of the
+	// client.
+	InstanceCrn *string
+
 	// Allows users to set headers on API requests
 	Headers map[string]string
 }
@@ -498,6 +588,12 @@ func (options *GetSqlJobOptions) SetJobID(jobID string) *GetSqlJobOptions {
 	return options
 }
 
+// SetInstanceCrn : Allow user to set InstanceCrn
+func (options *GetSqlJobOptions) SetInstanceCrn(instanceCrn string) *GetSqlJobOptions {
+	options.InstanceCrn = core.StringPtr(instanceCrn)
+	return options
+}
+
 // SetHeaders : Allow user to set Headers
 func (options *GetSqlJobOptions) SetHeaders(param map[string]string) *GetSqlJobOptions {
 	options.Headers = param
@@ -510,6 +606,10 @@ type GetTableOptions struct {
 	// contain alphabetic and numeral characters, and underscore (_).
 	TableName *string `validate:"required,ne="`
 
+	// The cloud resource name (CRN) of the SQL query service instance to use instead of the default instance of the
+	// client.
+	InstanceCrn *string
+
 	// Allows users to set headers on API requests
 	Headers map[string]string
 }
@@ -527,6 +627,12 @@ func (options *GetTableOptions) SetTableName(tableName string) *GetTableOptions
 	return options
 }
 
+// SetInstanceCrn : Allow user to set InstanceCrn
+func (options *GetTableOptions) SetInstanceCrn(instanceCrn string) *GetTableOptions {
+	options.InstanceCrn = core.StringPtr(instanceCrn)
+	return options
+}
+
 // SetHeaders : Allow user to set Headers
 func (options *GetTableOptions) SetHeaders(param map[string]string) *GetTableOptions {
 	options.Headers = param
@@ -535,6 +641,9 @@ func (options *GetTableOptions) SetHeaders(param map[string]string) *GetTableOpt
 
 // ListSqlJobsOptions : The ListSqlJobs options.
 type ListSqlJobsOptions struct {
+	// The cloud resource name (CRN) of the SQL query service instance to use instead of the default instance of the
+	// client.
+	InstanceCrn *string
 
 	// Allows users to set headers on API requests
 	Headers map[string]string
@@ -545,6 +654,12 @@ func (*SqlV2) NewListSqlJobsOptions() *ListSqlJobsOptions {
 	return &ListSqlJobsOptions{}
 }
 
+// SetInstanceCrn : Allow user to set InstanceCrn
+func (options *ListSqlJobsOptions) SetInstanceCrn(instanceCrn string) *ListSqlJobsOptions {
+	options.InstanceCrn = core.StringPtr(instanceCrn)
+	return options
+}
+
 // SetHeaders : Allow user to set Headers
 func (options *ListSqlJobsOptions) SetHeaders(param map[string]string) *ListSqlJobsOptions {
 	options.Headers = param
@@ -554,12 +669,16 @@ func (options *ListSqlJobsOptions) SetHeaders(param map[string]string) *ListSqlJ
 // ListTablesOptions : The ListTables options.
 type ListTablesOptions struct {
 	// A table name pattern for filtering the tables that should be listed. The pattern follows Hive syntax conventions and
-	// can include asterisks as wildcards and vertical bars to separate alternatives.
+	// can include asterisks as wildcards and vertical bars to separate alternatives, see ValidateNamePattern.
 	NamePattern *string
 
 	// A table type for filtering the tables that should be listed, can be "table" or "view".
 	Type *string
 
+	// The cloud resource name (CRN) of the SQL query service instance to use instead of the default instance of the
+	// client.
+	InstanceCrn *string
+
 	// Allows users to set headers on API requests
 	Headers map[string]string
 }
@@ -581,6 +700,12 @@ func (options *ListTablesOptions) SetType(typeVar string) *ListTablesOptions {
 	return options
 }
 
+// SetInstanceCrn : Allow user to set InstanceCrn
+func (options *ListTablesOptions) SetInstanceCrn(instanceCrn string) *ListTablesOptions {
+	options.InstanceCrn = core.StringPtr(instanceCrn)
+	return options
+}
+
 // SetHeaders : Allow user to set Headers
 func (options *ListTablesOptions) SetHeaders(param map[string]string) *ListTablesOptions {
 	options.Headers = param
@@ -601,6 +726,13 @@ type SubmitSqlJobOptions struct {
 	// target URI instead.
 	ResultsetTarget *string
 
+	// The cloud resource name (CRN) of the SQL query service instance to use instead of the default instance of the
+	// client.
+	InstanceCrn *string
+
+	// A label of the job, used to expand the default resultset target of the client. It isn't sent to the service.
+	JobLabel *string
+
 	// Allows users to set headers on API requests
 	Headers map[string]string
 }
@@ -624,6 +756,18 @@ func (options *SubmitSqlJobOptions) SetResultsetTarget(resultsetTarget string) *
 	return options
 }
 
+// SetInstanceCrn : Allow user to set InstanceCrn
+func (options *SubmitSqlJobOptions) SetInstanceCrn(instanceCrn string) *SubmitSqlJobOptions {
+	options.InstanceCrn = core.StringPtr(instanceCrn)
+	return options
+}
+
+// SetJobLabel : Allow user to set JobLabel
+func (options *SubmitSqlJobOptions) SetJobLabel(jobLabel string) *SubmitSqlJobOptions {
+	options.JobLabel = core.StringPtr(jobLabel)
+	return options
+}
+
 // SetHeaders : Allow user to set Headers
 func (options *SubmitSqlJobOptions) SetHeaders(param map[string]string) *SubmitSqlJobOptions {
 	options.Headers = param
@@ -37,7 +37,7 @@ import (
 var _ = Describe(`SqlV2`, func() {
 	var testServer *httptest.Server
 	Describe(`Service constructor tests`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		It(`Instantiate service client`, func() {
 			sqlService, serviceErr := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
 				Authenticator: &core.NoAuthAuthenticator{},
@@ -73,7 +73,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`Service constructor tests using external config`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		Context(`Using external config, construct service client instances`, func() {
 			// Map containing environment variables used in testing.
 			var testEnvironment = map[string]string{
@@ -180,7 +180,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`ListTables(listTablesOptions *ListTablesOptions) - Operation response error`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		listTablesPath := "/tables"
 		Context(`Using mock server endpoint`, func() {
 			BeforeEach(func() {
@@ -190,7 +190,7 @@ var _ = Describe(`SqlV2`, func() {
 					// Verify the contents of the request
 					Expect(req.URL.EscapedPath()).To(Equal(listTablesPath))
 					Expect(req.Method).To(Equal("GET"))
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					Expect(req.URL.Query()["name_pattern"]).To(Equal([]string{"testString"}))
 
@@ -235,7 +235,7 @@ var _ = Describe(`SqlV2`, func() {
 	})
 
 	Describe(`ListTables(listTablesOptions *ListTablesOptions)`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		listTablesPath := "/tables"
 		Context(`Using mock server endpoint with timeout`, func() {
 			BeforeEach(func() {
@@ -246,7 +246,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(listTablesPath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					Expect(req.URL.Query()["name_pattern"]).To(Equal([]string{"testString"}))
 
@@ -311,7 +311,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(listTablesPath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					Expect(req.URL.Query()["name_pattern"]).To(Equal([]string{"testString"}))
 
@@ -380,7 +380,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`GetTable(getTableOptions *GetTableOptions) - Operation response error`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		getTablePath := "/tables/testString"
 		Context(`Using mock server endpoint`, func() {
 			BeforeEach(func() {
@@ -390,7 +390,7 @@ var _ = Describe(`SqlV2`, func() {
 					// Verify the contents of the request
 					Expect(req.URL.EscapedPath()).To(Equal(getTablePath))
 					Expect(req.Method).To(Equal("GET"))
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					res.Header().Set("Content-type", "application/json")
 					res.WriteHeader(200)
@@ -430,7 +430,7 @@ var _ = Describe(`SqlV2`, func() {
 	})
 
 	Describe(`GetTable(getTableOptions *GetTableOptions)`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		getTablePath := "/tables/testString"
 		Context(`Using mock server endpoint with timeout`, func() {
 			BeforeEach(func() {
@@ -441,7 +441,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(getTablePath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Sleep a short time to support a timeout test
 					time.Sleep(100 * time.Millisecond)
@@ -501,7 +501,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(getTablePath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Set mock response
 					res.Header().Set("Content-type", "application/json")
@@ -571,7 +571,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`Service constructor tests`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		It(`Instantiate service client`, func() {
 			sqlService, serviceErr := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
 				Authenticator: &core.NoAuthAuthenticator{},
@@ -607,7 +607,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`Service constructor tests using external config`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		Context(`Using external config, construct service client instances`, func() {
 			// Map containing environment variables used in testing.
 			var testEnvironment = map[string]string{
@@ -714,7 +714,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`SubmitSqlJob(submitSqlJobOptions *SubmitSqlJobOptions) - Operation response error`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		submitSqlJobPath := "/sql_jobs"
 		Context(`Using mock server endpoint`, func() {
 			BeforeEach(func() {
@@ -724,7 +724,7 @@ var _ = Describe(`SqlV2`, func() {
 					// Verify the contents of the request
 					Expect(req.URL.EscapedPath()).To(Equal(submitSqlJobPath))
 					Expect(req.Method).To(Equal("POST"))
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					res.Header().Set("Content-type", "application/json")
 					res.WriteHeader(201)
@@ -765,7 +765,7 @@ var _ = Describe(`SqlV2`, func() {
 	})
 
 	Describe(`SubmitSqlJob(submitSqlJobOptions *SubmitSqlJobOptions)`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		submitSqlJobPath := "/sql_jobs"
 		Context(`Using mock server endpoint with timeout`, func() {
 			BeforeEach(func() {
@@ -792,7 +792,7 @@ var _ = Describe(`SqlV2`, func() {
 					}
 					fmt.Fprintf(GinkgoWriter, "  Request body: %s", bodyBuf.String())
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Sleep a short time to support a timeout test
 					time.Sleep(100 * time.Millisecond)
@@ -869,7 +869,7 @@ var _ = Describe(`SqlV2`, func() {
 					}
 					fmt.Fprintf(GinkgoWriter, "  Request body: %s", bodyBuf.String())
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Set mock response
 					res.Header().Set("Content-type", "application/json")
@@ -941,7 +941,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`ListSqlJobs(listSqlJobsOptions *ListSqlJobsOptions) - Operation response error`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		listSqlJobsPath := "/sql_jobs"
 		Context(`Using mock server endpoint`, func() {
 			BeforeEach(func() {
@@ -951,7 +951,7 @@ var _ = Describe(`SqlV2`, func() {
 					// Verify the contents of the request
 					Expect(req.URL.EscapedPath()).To(Equal(listSqlJobsPath))
 					Expect(req.Method).To(Equal("GET"))
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					res.Header().Set("Content-type", "application/json")
 					res.WriteHeader(200)
@@ -990,7 +990,7 @@ var _ = Describe(`SqlV2`, func() {
 	})
 
 	Describe(`ListSqlJobs(listSqlJobsOptions *ListSqlJobsOptions)`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		listSqlJobsPath := "/sql_jobs"
 		Context(`Using mock server endpoint with timeout`, func() {
 			BeforeEach(func() {
@@ -1001,7 +1001,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(listSqlJobsPath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Sleep a short time to support a timeout test
 					time.Sleep(100 * time.Millisecond)
@@ -1060,7 +1060,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(listSqlJobsPath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Set mock response
 					res.Header().Set("Content-type", "application/json")
@@ -1121,7 +1121,7 @@ var _ = Describe(`SqlV2`, func() {
 		})
 	})
 	Describe(`GetSqlJob(getSqlJobOptions *GetSqlJobOptions) - Operation response error`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		getSqlJobPath := "/sql_jobs/testString"
 		Context(`Using mock server endpoint`, func() {
 			BeforeEach(func() {
@@ -1131,7 +1131,7 @@ var _ = Describe(`SqlV2`, func() {
 					// Verify the contents of the request
 					Expect(req.URL.EscapedPath()).To(Equal(getSqlJobPath))
 					Expect(req.Method).To(Equal("GET"))
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					res.Header().Set("Content-type", "application/json")
 					res.WriteHeader(200)
@@ -1171,7 +1171,7 @@ var _ = Describe(`SqlV2`, func() {
 	})
 
 	Describe(`GetSqlJob(getSqlJobOptions *GetSqlJobOptions)`, func() {
-		instanceCrn := "testString"
+		instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 		getSqlJobPath := "/sql_jobs/testString"
 		Context(`Using mock server endpoint with timeout`, func() {
 			BeforeEach(func() {
@@ -1182,7 +1182,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(getSqlJobPath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Sleep a short time to support a timeout test
 					time.Sleep(100 * time.Millisecond)
@@ -1242,7 +1242,7 @@ var _ = Describe(`SqlV2`, func() {
 					Expect(req.URL.EscapedPath()).To(Equal(getSqlJobPath))
 					Expect(req.Method).To(Equal("GET"))
 
-					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{"testString"}))
+					Expect(req.URL.Query()["instance_crn"]).To(Equal([]string{instanceCrn}))
 
 					// Set mock response
 					res.Header().Set("Content-type", "application/json")
@@ -1313,7 +1313,7 @@ var _ = Describe(`SqlV2`, func() {
 	})
 	Describe(`Model constructor tests`, func() {
 		Context(`Using a service client instance`, func() {
-			instanceCrn := "testString"
+			instanceCrn := "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
 			sqlService, _ := sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
 				URL:           "http://sqlv2modelgenerator.com",
 				Authenticator: &core.NoAuthAuthenticator{},
@@ -0,0 +1,171 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+)
+
+// DefaultJobLabel is the JobLabel of the jobs submitted without one.
+const DefaultJobLabel = "unlabeled"
+
+// ResultsetTargetDateFormat is the layout of ResultsetTargetData.Date.
+const ResultsetTargetDateFormat = "2006-01-02"
+
+// ResultsetTargetData : The data a default resultset target template is expanded with, for example
+// cos://us-geo/bucket/results/{{.Date}}/{{.JobLabel}}/.
+type ResultsetTargetData struct {
+	// The submission date, formatted with ResultsetTargetDateFormat in UTC.
+	Date string
+
+	// The submission time in UTC, for custom layouts like {{.Time.Format "2006/01/02/15"}}.
+	Time time.Time
+
+	// The label of the job with the characters other than letters, digits, '.', '_' and '-' replaced with
+	// '-', or DefaultJobLabel.
+	JobLabel string
+
+	// The fingerprint of the statement, see fingerprint.Fingerprint.
+	Fingerprint string
+}
+
+// SubmittedSqlJob : A job returned by SubmitSqlJobWithTarget, with the resultset target it was submitted with.
+type SubmittedSqlJob struct {
+	SqlJobInfoShort
+
+	// The resultset target of the job, from the ResultsetTarget option or from the default resultset target of
+	// the client, or nil if the statement has an INTO clause or the client has no default.
+	ResultsetTarget *string
+}
+
+// unsafePathCharacters matches the characters of a label that are replaced in resultset targets.
+var unsafePathCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// SetDefaultResultsetTarget sets the template of the resultset target of the jobs submitted without a
+// ResultsetTarget or an INTO clause, see ResultsetTargetData. An empty template removes the default.
+func (sql *SqlV2) SetDefaultResultsetTarget(target string) error {
+	if target == "" {
+		sql.defaultResultsetTarget = nil
+		return nil
+	}
+	parsed, err := template.New(target).Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid default resultset target: %w", err)
+	}
+	// Expand the template once so that references to unknown fields fail now rather than on submission.
+	if err = parsed.Execute(&strings.Builder{}, newResultsetTargetData("", "", time.Time{})); err != nil {
+		return fmt.Errorf("invalid default resultset target: %w", err)
+	}
+	sql.defaultResultsetTarget = parsed
+	return nil
+}
+
+// GetDefaultResultsetTarget returns the template set with SetDefaultResultsetTarget.
+func (sql *SqlV2) GetDefaultResultsetTarget() string {
+	if sql.defaultResultsetTarget == nil {
+		return ""
+	}
+	return sql.defaultResultsetTarget.Name()
+}
+
+// SubmitSqlJobWithTarget is an alternate form of the SubmitSqlJob method which also returns the resultset
+// target the job was submitted with. The default resultset target of the client is expanded once, before
+// the submission.
+func (sql *SqlV2) SubmitSqlJobWithTarget(ctx context.Context, submitSqlJobOptions *SubmitSqlJobOptions) (result *SubmittedSqlJob, response *core.DetailedResponse, err error) {
+	err = core.ValidateNotNil(submitSqlJobOptions, "submitSqlJobOptions cannot be nil")
+	if err != nil {
+		return
+	}
+	err = core.ValidateStruct(submitSqlJobOptions, "submitSqlJobOptions")
+	if err != nil {
+		return
+	}
+	resolved := *submitSqlJobOptions
+	resolved.ResultsetTarget, err = sql.resultsetTarget(submitSqlJobOptions)
+	if err != nil {
+		return
+	}
+	job, response, err := sql.SubmitSqlJobWithContext(ctx, &resolved)
+	if err != nil {
+		return
+	}
+	result = &SubmittedSqlJob{SqlJobInfoShort: *job, ResultsetTarget: resolved.ResultsetTarget}
+	return
+}
+
+// resultsetTarget returns the resultset target of a submission: the one of the options, or else the default
+// target of the client expanded for the submission, unless the statement has an INTO clause.
+func (sql *SqlV2) resultsetTarget(submitSqlJobOptions *SubmitSqlJobOptions) (*string, error) {
+	if submitSqlJobOptions.ResultsetTarget != nil || sql.defaultResultsetTarget == nil {
+		return submitSqlJobOptions.ResultsetTarget, nil
+	}
+	statement := *submitSqlJobOptions.Statement
+	if hasIntoClause(statement) {
+		return nil, nil
+	}
+	label := ""
+	if submitSqlJobOptions.JobLabel != nil {
+		label = *submitSqlJobOptions.JobLabel
+	}
+	target := &strings.Builder{}
+	err := sql.defaultResultsetTarget.Execute(target, newResultsetTargetData(statement, label, time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("cannot expand the default resultset target: %w", err)
+	}
+	resolved := target.String()
+	return &resolved, nil
+}
+
+func newResultsetTargetData(statement string, label string, now time.Time) *ResultsetTargetData {
+	label = unsafePathCharacters.ReplaceAllString(label, "-")
+	if label == "" {
+		label = DefaultJobLabel
+	}
+	now = now.UTC()
+	return &ResultsetTargetData{
+		Date:        now.Format(ResultsetTargetDateFormat),
+		Time:        now,
+		JobLabel:    label,
+		Fingerprint: fingerprint.Fingerprint(statement),
+	}
+}
+
+// hasIntoClause returns whether "statement" names its target with an INTO clause: the INTO keyword
+// followed by a cos:// URI. Columns and aliases named "into" aren't followed by a URI.
+func hasIntoClause(statement string) bool {
+	into := false
+	for _, token := range fingerprint.Tokenize(statement) {
+		switch token.Kind {
+		case fingerprint.TokenKind_Whitespace, fingerprint.TokenKind_Comment:
+			continue
+		case fingerprint.TokenKind_URI:
+			if into {
+				return true
+			}
+		}
+		into = token.Kind == fingerprint.TokenKind_Word && strings.EqualFold(token.Text, "into")
+	}
+	return false
+}
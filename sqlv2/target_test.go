@@ -0,0 +1,148 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/fingerprint"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Default resultset target`, func() {
+	const target = "cos://us-geo/bucket/results/{{.Date}}/{{.JobLabel}}/{{.Fingerprint}}/"
+	const statement = "SELECT * FROM cos://us-geo/sql/employees.parquet STORED AS PARQUET"
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var targets []interface{}
+
+	BeforeEach(func() {
+		targets = nil
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			var body map[string]interface{}
+			Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+			targets = append(targets, body["resultset_target"])
+			res.Header().Set("Content-type", "application/json")
+			res.WriteHeader(201)
+			fmt.Fprintf(res, `{"job_id": "job1", "status": "queued"}`)
+		}))
+
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:                    testServer.URL,
+			Authenticator:          &core.NoAuthAuthenticator{},
+			InstanceCrn:            core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/123:instance::"),
+			DefaultResultsetTarget: target,
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Expand the template for every submission`, func() {
+		Expect(sqlService.GetDefaultResultsetTarget()).To(Equal(target))
+		result, _, err := sqlService.SubmitSqlJobWithTarget(context.Background(), sqlService.NewSubmitSqlJobOptions(statement).SetJobLabel("daily report"))
+		Expect(err).To(BeNil())
+		date := time.Now().UTC().Format(sqlv2.ResultsetTargetDateFormat)
+		expected := "cos://us-geo/bucket/results/" + date + "/daily-report/" + fingerprint.Fingerprint(statement) + "/"
+		Expect(*result.JobID).To(Equal("job1"))
+		Expect(*result.ResultsetTarget).To(Equal(expected))
+		Expect(targets).To(Equal([]interface{}{expected}))
+
+		result, _, err = sqlService.SubmitSqlJobWithTarget(context.Background(), sqlService.NewSubmitSqlJobOptions(statement))
+		Expect(err).To(BeNil())
+		Expect(*result.ResultsetTarget).To(ContainSubstring("/" + sqlv2.DefaultJobLabel + "/"))
+
+		// The model of the service doesn't carry the target.
+		job, _, err := sqlService.SubmitSqlJob(sqlService.NewSubmitSqlJobOptions(statement))
+		Expect(err).To(BeNil())
+		serialized, err := json.Marshal(job)
+		Expect(err).To(BeNil())
+		Expect(string(serialized)).ToNot(ContainSubstring("resultset_target"))
+		Expect(targets[2]).To(ContainSubstring("/" + sqlv2.DefaultJobLabel + "/"))
+	})
+
+	It(`Expose the expanded target to the middleware`, func() {
+		var submitted []*sqlv2.SubmitSqlJobOptions
+		sqlService.Use(func(next sqlv2.Handler) sqlv2.Handler {
+			return func(op *sqlv2.Operation) (*core.DetailedResponse, error) {
+				submitted = append(submitted, op.Options.(*sqlv2.SubmitSqlJobOptions))
+				return next(op)
+			}
+		})
+
+		options := sqlService.NewSubmitSqlJobOptions(statement)
+		_, _, err := sqlService.SubmitSqlJob(options)
+		Expect(err).To(BeNil())
+		Expect(options.ResultsetTarget).To(BeNil())
+		Expect(submitted).To(HaveLen(1))
+		Expect(*submitted[0].ResultsetTarget).To(Equal(targets[0]))
+		Expect(*submitted[0].Statement).To(Equal(statement))
+	})
+
+	It(`Keep explicit targets and INTO clauses`, func() {
+		result, _, err := sqlService.SubmitSqlJobWithTarget(context.Background(), sqlService.NewSubmitSqlJobOptions(statement).SetResultsetTarget("cos://us-geo/other/"))
+		Expect(err).To(BeNil())
+		Expect(*result.ResultsetTarget).To(Equal("cos://us-geo/other/"))
+
+		result, _, err = sqlService.SubmitSqlJobWithTarget(context.Background(), sqlService.NewSubmitSqlJobOptions(statement+" INTO cos://us-geo/into/ STORED AS CSV"))
+		Expect(err).To(BeNil())
+		Expect(result.ResultsetTarget).To(BeNil())
+
+		Expect(sqlService.SetDefaultResultsetTarget("")).To(Succeed())
+		result, _, err = sqlService.SubmitSqlJobWithTarget(context.Background(), sqlService.NewSubmitSqlJobOptions(statement))
+		Expect(err).To(BeNil())
+		Expect(result.ResultsetTarget).To(BeNil())
+		Expect(targets).To(Equal([]interface{}{"cos://us-geo/other/", nil, nil}))
+
+		_, _, err = sqlService.SubmitSqlJobWithTarget(context.Background(), nil)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It(`Expand the template for the columns named into`, func() {
+		for _, statement := range []string{
+			"SELECT into FROM cos://us-geo/sql/t.csv",
+			"SELECT a AS `into`, b AS into FROM cos://us-geo/sql/t.csv",
+			"SELECT 'INTO cos://us-geo/x/' AS a FROM cos://us-geo/sql/t.csv",
+		} {
+			result, _, err := sqlService.SubmitSqlJobWithTarget(context.Background(), sqlService.NewSubmitSqlJobOptions(statement))
+			Expect(err).To(BeNil())
+			Expect(result.ResultsetTarget).ToNot(BeNil(), statement)
+		}
+		result, _, err := sqlService.SubmitSqlJobWithTarget(context.Background(),
+			sqlService.NewSubmitSqlJobOptions("SELECT into FROM cos://us-geo/sql/t.csv INTO /* results */ cos://us-geo/into/"))
+		Expect(err).To(BeNil())
+		Expect(result.ResultsetTarget).To(BeNil())
+	})
+
+	It(`Reject invalid templates`, func() {
+		Expect(sqlService.SetDefaultResultsetTarget("cos://us-geo/bucket/{{.Date")).ToNot(Succeed())
+		Expect(sqlService.SetDefaultResultsetTarget("cos://us-geo/bucket/{{.User}}/")).ToNot(Succeed())
+		Expect(sqlService.GetDefaultResultsetTarget()).To(Equal(target))
+	})
+})
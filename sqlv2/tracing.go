@@ -0,0 +1,127 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/tracing"
+)
+
+// The attributes set on the spans created by TracingMiddleware.
+const (
+	TracingAttribute_Operation   = "sql.operation"
+	TracingAttribute_InstanceCrn = "sql.instance_crn"
+	TracingAttribute_JobID       = "sql.job_id"
+	TracingAttribute_StatusCode  = "http.status_code"
+	TracingAttribute_RetryCount  = "http.retry_count"
+)
+
+// TracingSpanPrefix is prepended to the operation ID to form the name of the spans created by
+// TracingMiddleware, such as "sql.GetSqlJob".
+const TracingSpanPrefix = "sql."
+
+// TracingMiddleware returns a Middleware creating a span for every operation. The span is a child of the
+// span of the context passed to the operation, so repeated calls such as the GetSqlJob calls polling a job
+// can be grouped by starting a parent span and passing its context to each call. The span context is sent
+// to the service in the traceparent header.
+func TracingMiddleware(tracer *tracing.Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(op *Operation) (*core.DetailedResponse, error) {
+			ctx, span := tracer.Start(op.Request.Context(), TracingSpanPrefix+op.ID)
+			defer span.End()
+			span.SetAttribute(TracingAttribute_Operation, op.ID)
+			span.SetAttribute(TracingAttribute_InstanceCrn, RedactCrn(op.Request.URL.Query().Get("instance_crn")))
+
+			request, attempts := countAttempts(op.Request.WithContext(ctx))
+			tracing.Inject(ctx, request.Header)
+			op.Request = request
+			response, err := next(op)
+
+			retries := atomic.LoadInt32(attempts) - 1
+			if retries < 0 {
+				retries = 0
+			}
+			span.SetAttribute(TracingAttribute_RetryCount, int(retries))
+			if response != nil {
+				span.SetAttribute(TracingAttribute_StatusCode, response.StatusCode)
+			}
+			if jobID := operationJobID(op); jobID != "" {
+				span.SetAttribute(TracingAttribute_JobID, jobID)
+			}
+			if err != nil {
+				span.RecordError(err)
+			} else {
+				span.SetStatus(tracing.StatusCode_Ok, "")
+			}
+			return response, err
+		}
+	}
+}
+
+// countAttempts returns a copy of "request" that counts the HTTP attempts made to send it, including the
+// automatic retries enabled with EnableRetries.
+func countAttempts(request *http.Request) (*http.Request, *int32) {
+	var attempts int32
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			atomic.AddInt32(&attempts, 1)
+		},
+	}
+	return request.WithContext(httptrace.WithClientTrace(request.Context(), trace)), &attempts
+}
+
+// operationJobID returns the ID of the job an operation is about, if any.
+func operationJobID(op *Operation) string {
+	if options, ok := op.Options.(*GetSqlJobOptions); ok && options.JobID != nil {
+		return *options.JobID
+	}
+	if rawResponse, ok := op.Result.(*map[string]json.RawMessage); ok && *rawResponse != nil {
+		var jobID string
+		if json.Unmarshal((*rawResponse)["job_id"], &jobID) == nil {
+			return jobID
+		}
+	}
+	return ""
+}
+
+// RedactCrn masks the account and most of the instance ID of a CRN so that it can be recorded in traces and
+// logs, such as "crn:v1:bluemix:public:sql-query:us-south:a/***:***1234::". Values that are not CRNs are
+// masked entirely.
+func RedactCrn(crn string) string {
+	if crn == "" {
+		return ""
+	}
+	segments := strings.Split(crn, ":")
+	if len(segments) != 10 || segments[0] != "crn" {
+		return "***"
+	}
+	if segments[6] != "" {
+		segments[6] = "a/***"
+	}
+	if instance := segments[7]; len(instance) > 4 {
+		segments[7] = "***" + instance[len(instance)-4:]
+	} else if instance != "" {
+		segments[7] = "***"
+	}
+	return strings.Join(segments, ":")
+}
@@ -0,0 +1,145 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	"github.com/IBM/sql-query-go-sdk/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Tracing`, func() {
+	const instanceCrn = "crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var exporter *tracing.InMemoryExporter
+	var mutex sync.Mutex
+	var traceparents []string
+	var failures int
+
+	BeforeEach(func() {
+		traceparents = nil
+		failures = 0
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			traceparents = append(traceparents, req.Header.Get(tracing.TraceparentHeader))
+			res.Header().Set("Content-type", "application/json")
+			if failures > 0 {
+				failures--
+				res.Header().Set("Retry-After", "0")
+				res.WriteHeader(503)
+				fmt.Fprintf(res, `{"errors": [{"code": "unavailable", "message": "try again"}]}`)
+				return
+			}
+			if req.Method == http.MethodPost {
+				res.WriteHeader(201)
+				fmt.Fprintf(res, `{"job_id": "job1", "status": "queued"}`)
+				return
+			}
+			if req.URL.EscapedPath() == "/sql_jobs/missing" {
+				res.WriteHeader(404)
+				fmt.Fprintf(res, `{"errors": [{"code": "not_found", "message": "job not found"}]}`)
+				return
+			}
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"job_id": "job1", "status": "running"}`)
+		}))
+
+		exporter = tracing.NewInMemoryExporter()
+		var err error
+		sqlService, err = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr(instanceCrn),
+			Tracer:        tracing.NewTracer(exporter),
+		})
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Record a span per operation`, func() {
+		_, _, err := sqlService.SubmitSqlJob(sqlService.NewSubmitSqlJobOptions("SELECT 1"))
+		Expect(err).To(BeNil())
+
+		spans := exporter.Spans()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name).To(Equal("sql.SubmitSqlJob"))
+		Expect(spans[0].Status).To(Equal(tracing.StatusCode_Ok))
+		Expect(spans[0].Attributes).To(Equal(map[string]interface{}{
+			sqlv2.TracingAttribute_Operation:   "SubmitSqlJob",
+			sqlv2.TracingAttribute_InstanceCrn: "crn:v1:bluemix:public:sql-query:us-south:a/***:***1234::",
+			sqlv2.TracingAttribute_StatusCode:  201,
+			sqlv2.TracingAttribute_RetryCount:  0,
+			sqlv2.TracingAttribute_JobID:       "job1",
+		}))
+		Expect(traceparents).To(Equal([]string{spans[0].SpanContext.Traceparent()}))
+	})
+
+	It(`Record errors and retries`, func() {
+		sqlService.EnableRetries(3, 0)
+		failures = 2
+		_, _, err := sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("job1"))
+		Expect(err).To(BeNil())
+		spans := exporter.Spans()
+		Expect(spans[0].Attributes[sqlv2.TracingAttribute_RetryCount]).To(Equal(2))
+		Expect(traceparents).To(HaveLen(3))
+
+		exporter.Reset()
+		_, _, err = sqlService.GetSqlJob(sqlService.NewGetSqlJobOptions("missing"))
+		Expect(err).ToNot(BeNil())
+		spans = exporter.Spans()
+		Expect(spans[0].Status).To(Equal(tracing.StatusCode_Error))
+		Expect(spans[0].Attributes[sqlv2.TracingAttribute_StatusCode]).To(Equal(404))
+		Expect(spans[0].Attributes[sqlv2.TracingAttribute_JobID]).To(Equal("missing"))
+	})
+
+	It(`Group polling calls under a parent span`, func() {
+		tracer := tracing.NewTracer(exporter)
+		ctx, parent := tracer.Start(context.Background(), "wait for job1")
+		for i := 0; i < 3; i++ {
+			_, _, err := sqlService.GetSqlJobWithContext(ctx, sqlService.NewGetSqlJobOptions("job1"))
+			Expect(err).To(BeNil())
+		}
+		parent.End()
+
+		spans := exporter.Spans()
+		Expect(spans).To(HaveLen(4))
+		for _, span := range spans[:3] {
+			Expect(span.Parent).To(Equal(parent.SpanContext()))
+			Expect(span.SpanContext.TraceID).To(Equal(parent.SpanContext().TraceID))
+		}
+	})
+
+	It(`Redact CRNs`, func() {
+		Expect(sqlv2.RedactCrn("")).To(Equal(""))
+		Expect(sqlv2.RedactCrn("testString")).To(Equal("***"))
+		Expect(sqlv2.RedactCrn("crn:v1:bluemix:public:sql-query:us-south:a/123:abc::")).To(Equal("crn:v1:bluemix:public:sql-query:us-south:a/***:***::"))
+	})
+})
@@ -0,0 +1,332 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval used by a Watcher when none is configured.
+const DefaultWatchInterval = 5 * time.Second
+
+// JobEventType : The kind of lifecycle transition reported by a JobEvent.
+type JobEventType string
+
+// Constants associated with the JobEvent.Type property.
+const (
+	JobEventType_Submitted = JobEventType("submitted")
+	JobEventType_Queued    = JobEventType("queued")
+	JobEventType_Running   = JobEventType("running")
+	JobEventType_Completed = JobEventType("completed")
+	JobEventType_Failed    = JobEventType("failed")
+)
+
+// JobEvent : A lifecycle event for an SQL job observed by a Watcher.
+type JobEvent struct {
+	// The kind of transition.
+	Type JobEventType
+
+	// Identifier of the SQL job.
+	JobID string
+
+	// Status of the job before the transition. Empty when the job is observed for the first time.
+	OldStatus string
+
+	// Status of the job after the transition.
+	NewStatus string
+
+	// Full information about the job at the time the transition was observed.
+	Job *SqlJobInfoFull
+
+	// Time at which the transition was observed.
+	Time time.Time
+}
+
+// IsTerminal returns true if the event reports that the job finished, either successfully or not.
+func (event JobEvent) IsTerminal() bool {
+	return IsTerminalJobStatus(event.NewStatus)
+}
+
+// IsTerminalJobStatus returns true if "status" is a final SQL job status.
+func IsTerminalJobStatus(status string) bool {
+	return status == SqlJobInfoFull_Status_Completed || status == SqlJobInfoFull_Status_Failed
+}
+
+// JobEventCallback : A function that is invoked for every event emitted by a Watcher.
+type JobEventCallback func(event JobEvent)
+
+// WatcherOptions : The Watcher options.
+type WatcherOptions struct {
+	// How often the service is polled. Defaults to DefaultWatchInterval.
+	Interval time.Duration
+
+	// Identifiers of the jobs to watch. If empty, every job returned by ListSqlJobs is watched.
+	// More jobs can be added later with Watcher.Watch.
+	JobIDs []string
+
+	// Only used when all jobs are watched. By default, the jobs that are already known to the service
+	// when the first poll happens are recorded silently; set this to emit events for them as well.
+	IncludeExisting bool
+
+	// Capacity of the channel returned by Watcher.Events. Defaults to 64.
+	BufferSize int
+}
+
+// Watcher periodically polls the SQL Query service and emits typed events whenever the status of a
+// watched job changes. Events are delivered in order through the channel returned by Events and to
+// every callback registered with OnEvent.
+type Watcher struct {
+	sql      *SqlV2
+	interval time.Duration
+	watchAll bool
+	existing bool
+
+	mutex     sync.Mutex
+	statuses  map[string]string
+	pending   map[string]bool
+	callbacks []JobEventCallback
+	seeded    bool
+
+	events     chan JobEvent
+	subscribed bool
+}
+
+// NewWatcher : constructs a Watcher that uses "sql" to poll for job status changes.
+func NewWatcher(sql *SqlV2, options *WatcherOptions) *Watcher {
+	if options == nil {
+		options = &WatcherOptions{}
+	}
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 64
+	}
+
+	watcher := &Watcher{
+		sql:      sql,
+		interval: interval,
+		watchAll: len(options.JobIDs) == 0,
+		existing: options.IncludeExisting,
+		statuses: make(map[string]string),
+		pending:  make(map[string]bool),
+		events:   make(chan JobEvent, bufferSize),
+	}
+	for _, jobID := range options.JobIDs {
+		watcher.pending[jobID] = true
+	}
+	return watcher
+}
+
+// Watch adds a job to the set of watched jobs. It has no effect on a Watcher that watches all jobs.
+func (watcher *Watcher) Watch(jobID string) {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	if _, known := watcher.statuses[jobID]; !known {
+		watcher.pending[jobID] = true
+	}
+}
+
+// OnEvent registers a callback that is invoked synchronously, in registration order, for every event.
+func (watcher *Watcher) OnEvent(callback JobEventCallback) {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	watcher.callbacks = append(watcher.callbacks, callback)
+}
+
+// Events returns the channel on which events are delivered. The channel is closed when Run returns.
+// Events are only published on the channel once Events has been called, and polling blocks while the
+// channel is full, so a consumer that subscribes must keep draining it.
+func (watcher *Watcher) Events() <-chan JobEvent {
+	watcher.mutex.Lock()
+	defer watcher.mutex.Unlock()
+	watcher.subscribed = true
+	return watcher.events
+}
+
+// Run polls the service every interval until "ctx" is done, then closes the events channel.
+// Errors returned by individual polls are passed to "onError" when it is not nil and do not stop the Watcher.
+func (watcher *Watcher) Run(ctx context.Context, onError func(error)) error {
+	defer close(watcher.events)
+
+	ticker := time.NewTicker(watcher.interval)
+	defer ticker.Stop()
+	for {
+		err := watcher.Poll(ctx)
+		if err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll performs a single polling round and emits the events for all the transitions it observes.
+// Poll must not be called concurrently with Run.
+func (watcher *Watcher) Poll(ctx context.Context) error {
+	var events []JobEvent
+	var err error
+	if watcher.watchAll {
+		events, err = watcher.pollAll(ctx)
+	} else {
+		events, err = watcher.pollWatched(ctx)
+	}
+	for _, event := range events {
+		if emitErr := watcher.emit(ctx, event); emitErr != nil {
+			return emitErr
+		}
+	}
+	return err
+}
+
+// pollAll lists the recent jobs of the instance and fetches full information for those whose status changed.
+func (watcher *Watcher) pollAll(ctx context.Context) (events []JobEvent, err error) {
+	list, _, err := watcher.sql.ListSqlJobsWithContext(ctx, &ListSqlJobsOptions{})
+	if err != nil {
+		return
+	}
+
+	watcher.mutex.Lock()
+	silent := !watcher.seeded && !watcher.existing
+	watcher.seeded = true
+	listed := make(map[string]bool, len(list.Jobs))
+	var changed []string
+	for _, job := range list.Jobs {
+		if job.JobID == nil || job.Status == nil {
+			continue
+		}
+		listed[*job.JobID] = true
+		oldStatus, known := watcher.statuses[*job.JobID]
+		if known && oldStatus == *job.Status {
+			continue
+		}
+		if silent {
+			watcher.statuses[*job.JobID] = *job.Status
+			continue
+		}
+		changed = append(changed, *job.JobID)
+	}
+	// Forget the jobs that dropped out of the list so that the state doesn't grow without bound.
+	for jobID := range watcher.statuses {
+		if !listed[jobID] {
+			delete(watcher.statuses, jobID)
+		}
+	}
+	watcher.mutex.Unlock()
+
+	for _, jobID := range changed {
+		jobEvents, getErr := watcher.refresh(ctx, jobID)
+		if getErr != nil {
+			err = getErr
+			continue
+		}
+		events = append(events, jobEvents...)
+	}
+	return
+}
+
+// pollWatched fetches full information for every watched job that has not finished yet.
+func (watcher *Watcher) pollWatched(ctx context.Context) (events []JobEvent, err error) {
+	watcher.mutex.Lock()
+	var jobIDs []string
+	for jobID := range watcher.pending {
+		jobIDs = append(jobIDs, jobID)
+	}
+	watcher.mutex.Unlock()
+
+	for _, jobID := range jobIDs {
+		jobEvents, getErr := watcher.refresh(ctx, jobID)
+		if getErr != nil {
+			err = getErr
+			continue
+		}
+		events = append(events, jobEvents...)
+	}
+	return
+}
+
+// refresh retrieves a job and records its status, returning the events for the observed transition.
+func (watcher *Watcher) refresh(ctx context.Context, jobID string) (events []JobEvent, err error) {
+	job, _, err := watcher.sql.GetSqlJobWithContext(ctx, &GetSqlJobOptions{JobID: &jobID})
+	if err != nil {
+		return
+	}
+	if job.Status == nil {
+		return
+	}
+	newStatus := *job.Status
+	now := time.Now()
+
+	watcher.mutex.Lock()
+	oldStatus, known := watcher.statuses[jobID]
+	watcher.statuses[jobID] = newStatus
+	if IsTerminalJobStatus(newStatus) {
+		delete(watcher.pending, jobID)
+	}
+	watcher.mutex.Unlock()
+
+	if known && oldStatus == newStatus {
+		return
+	}
+	if !known {
+		events = append(events, JobEvent{
+			Type:      JobEventType_Submitted,
+			JobID:     jobID,
+			NewStatus: newStatus,
+			Job:       job,
+			Time:      now,
+		})
+	}
+	events = append(events, JobEvent{
+		Type:      JobEventType(newStatus),
+		JobID:     jobID,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+		Job:       job,
+		Time:      now,
+	})
+	return
+}
+
+// emit invokes the registered callbacks and publishes the event on the events channel.
+func (watcher *Watcher) emit(ctx context.Context, event JobEvent) error {
+	watcher.mutex.Lock()
+	callbacks := make([]JobEventCallback, len(watcher.callbacks))
+	copy(callbacks, watcher.callbacks)
+	subscribed := watcher.subscribed
+	watcher.mutex.Unlock()
+
+	for _, callback := range callbacks {
+		callback(event)
+	}
+	if !subscribed {
+		return nil
+	}
+	select {
+	case watcher.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,178 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package sqlv2_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/go-sdk-core/v5/core"
+	"github.com/IBM/sql-query-go-sdk/sqlv2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe(`Watcher`, func() {
+	var testServer *httptest.Server
+	var sqlService *sqlv2.SqlV2
+	var mutex sync.Mutex
+	var statuses map[string][]string
+
+	// nextStatus pops the next scripted status of a job, repeating the last one once the script is exhausted.
+	nextStatus := func(jobID string) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		script := statuses[jobID]
+		status := script[0]
+		if len(script) > 1 {
+			statuses[jobID] = script[1:]
+		}
+		return status
+	}
+	peekStatus := func(jobID string) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return statuses[jobID][0]
+	}
+
+	BeforeEach(func() {
+		statuses = map[string][]string{}
+		testServer = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+
+			res.Header().Set("Content-type", "application/json")
+			if req.URL.EscapedPath() == "/sql_jobs" {
+				mutex.Lock()
+				var jobs []string
+				for jobID := range statuses {
+					jobs = append(jobs, jobID)
+				}
+				mutex.Unlock()
+				var entries []string
+				for _, jobID := range jobs {
+					entries = append(entries, fmt.Sprintf(`{"job_id": "%s", "status": "%s"}`, jobID, peekStatus(jobID)))
+				}
+				res.WriteHeader(200)
+				fmt.Fprintf(res, `{"jobs": [%s]}`, strings.Join(entries, ","))
+				return
+			}
+
+			jobID := strings.TrimPrefix(req.URL.EscapedPath(), "/sql_jobs/")
+			res.WriteHeader(200)
+			fmt.Fprintf(res, `{"job_id": "%s", "status": "%s", "user_id": "user", "submit_time": "2019-01-01T12:00:00.000Z", "statement": "SELECT 1"}`, jobID, nextStatus(jobID))
+		}))
+
+		var serviceErr error
+		sqlService, serviceErr = sqlv2.NewSqlV2(&sqlv2.SqlV2Options{
+			URL:           testServer.URL,
+			Authenticator: &core.NoAuthAuthenticator{},
+			InstanceCrn:   core.StringPtr("crn:v1:bluemix:public:sql-query:us-south:a/23a24a3e3fe7a115473f07be1c44bdb5:d4b9e6b6-1b3c-4e2f-9e8a-0c3f7a8b1234::"),
+		})
+		Expect(serviceErr).To(BeNil())
+	})
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	It(`Emits transitions of watched jobs to callbacks and the channel`, func() {
+		statuses["job1"] = []string{"queued", "running", "completed"}
+		watcher := sqlv2.NewWatcher(sqlService, &sqlv2.WatcherOptions{
+			JobIDs: []string{"job1"},
+		})
+		var received []sqlv2.JobEvent
+		watcher.OnEvent(func(event sqlv2.JobEvent) {
+			received = append(received, event)
+		})
+		events := watcher.Events()
+
+		for i := 0; i < 4; i++ {
+			Expect(watcher.Poll(context.Background())).To(Succeed())
+		}
+
+		Expect(received).To(HaveLen(4))
+		Expect(received[0].Type).To(Equal(sqlv2.JobEventType_Submitted))
+		Expect(received[0].OldStatus).To(BeEmpty())
+		Expect(received[0].NewStatus).To(Equal("queued"))
+		Expect(received[1].Type).To(Equal(sqlv2.JobEventType_Queued))
+		Expect(received[2].Type).To(Equal(sqlv2.JobEventType_Running))
+		Expect(received[2].OldStatus).To(Equal("queued"))
+		Expect(received[3].Type).To(Equal(sqlv2.JobEventType_Completed))
+		Expect(received[3].OldStatus).To(Equal("running"))
+		Expect(received[3].IsTerminal()).To(BeTrue())
+		Expect(*received[3].Job.Statement).To(Equal("SELECT 1"))
+
+		Expect(events).To(HaveLen(4))
+		Expect((<-events).Type).To(Equal(sqlv2.JobEventType_Submitted))
+	})
+	It(`Ignores existing jobs when watching all jobs`, func() {
+		statuses["old"] = []string{"completed"}
+		watcher := sqlv2.NewWatcher(sqlService, nil)
+		var received []sqlv2.JobEvent
+		watcher.OnEvent(func(event sqlv2.JobEvent) {
+			received = append(received, event)
+		})
+
+		Expect(watcher.Poll(context.Background())).To(Succeed())
+		Expect(received).To(BeEmpty())
+
+		mutex.Lock()
+		statuses["new"] = []string{"running", "failed"}
+		mutex.Unlock()
+		Expect(watcher.Poll(context.Background())).To(Succeed())
+		Expect(received).To(HaveLen(2))
+		Expect(received[0].Type).To(Equal(sqlv2.JobEventType_Submitted))
+		Expect(received[1].Type).To(Equal(sqlv2.JobEventType_Running))
+
+		Expect(watcher.Poll(context.Background())).To(Succeed())
+		Expect(received).To(HaveLen(3))
+		Expect(received[2].Type).To(Equal(sqlv2.JobEventType_Failed))
+		Expect(received[2].JobID).To(Equal("new"))
+	})
+	It(`Stops and closes the channel when the context is done`, func() {
+		statuses["job1"] = []string{"running", "completed"}
+		watcher := sqlv2.NewWatcher(sqlService, &sqlv2.WatcherOptions{
+			Interval: 10 * time.Millisecond,
+			JobIDs:   []string{"job1"},
+		})
+		events := watcher.Events()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		done := make(chan error)
+		go func() {
+			done <- watcher.Run(ctx, nil)
+		}()
+
+		var types []sqlv2.JobEventType
+		for event := range events {
+			types = append(types, event.Type)
+			if event.IsTerminal() {
+				cancel()
+			}
+		}
+		Expect(<-done).To(Equal(context.Canceled))
+		Expect(types).To(Equal([]sqlv2.JobEventType{
+			sqlv2.JobEventType_Submitted,
+			sqlv2.JobEventType_Running,
+			sqlv2.JobEventType_Completed,
+		}))
+	})
+})
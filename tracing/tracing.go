@@ -0,0 +1,319 @@
+/**
+ * (C) Copyright IBM Corp. 2022.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing : Lightweight, OpenTelemetry-style tracing for the SDK.
+//
+// A Tracer creates Spans that form traces through the context.Context passed to the SDK operations, and
+// hands every ended span to an Exporter. Span contexts are propagated to the service with the W3C Trace
+// Context "traceparent" header. The package has no dependency on the OpenTelemetry SDK: an Exporter can
+// forward spans to any tracing backend, and the InMemoryExporter records them for tests.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceparentHeader is the name of the W3C Trace Context header.
+const TraceparentHeader = "traceparent"
+
+// TraceID : The identifier of a trace.
+type TraceID [16]byte
+
+// String returns the hex encoding of the trace ID.
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanID : The identifier of a span.
+type SpanID [8]byte
+
+// String returns the hex encoding of the span ID.
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// SpanContext : The identifying part of a span, which is propagated across process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+// IsValid returns true if both the trace ID and the span ID are set.
+func (spanContext SpanContext) IsValid() bool {
+	return spanContext.TraceID != TraceID{} && spanContext.SpanID != SpanID{}
+}
+
+// Traceparent returns the value of the traceparent header for the span context.
+func (spanContext SpanContext) Traceparent() string {
+	flags := "00"
+	if spanContext.Sampled {
+		flags = "01"
+	}
+	return "00-" + spanContext.TraceID.String() + "-" + spanContext.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses the value of a traceparent header.
+func ParseTraceparent(traceparent string) (spanContext SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		err = fmt.Errorf("invalid traceparent %q", traceparent)
+		return
+	}
+	var flags [1]byte
+	if !decodeHex(spanContext.TraceID[:], parts[1]) || !decodeHex(spanContext.SpanID[:], parts[2]) ||
+		!decodeHex(flags[:], parts[3]) || !spanContext.IsValid() {
+		err = fmt.Errorf("invalid traceparent %q", traceparent)
+		return
+	}
+	spanContext.Sampled = flags[0]&1 == 1
+	return
+}
+
+func decodeHex(dst []byte, text string) bool {
+	if len(text) != 2*len(dst) || strings.ToLower(text) != text {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(text))
+	return err == nil
+}
+
+// StatusCode : The status of a span.
+type StatusCode int
+
+// The status codes of a span.
+const (
+	StatusCode_Unset StatusCode = iota
+	StatusCode_Ok
+	StatusCode_Error
+)
+
+// SpanData : A snapshot of an ended span, as handed to an Exporter.
+type SpanData struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanContext
+	StartTime     time.Time
+	EndTime       time.Time
+	Attributes    map[string]interface{}
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Exporter receives the spans of a Tracer when they end. ExportSpan must be safe for concurrent use.
+type Exporter interface {
+	ExportSpan(span SpanData)
+}
+
+// Span : A timed operation within a trace. The methods of a Span are safe for concurrent use and are no-ops
+// on a nil Span.
+type Span struct {
+	tracer *Tracer
+	mutex  sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+// SpanContext returns the span context of the span.
+func (span *Span) SpanContext() SpanContext {
+	if span == nil {
+		return SpanContext{}
+	}
+	return span.data.SpanContext
+}
+
+// SetAttribute sets an attribute of the span.
+func (span *Span) SetAttribute(key string, value interface{}) {
+	if span == nil {
+		return
+	}
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.Attributes[key] = value
+}
+
+// SetStatus sets the status of the span.
+func (span *Span) SetStatus(code StatusCode, message string) {
+	if span == nil {
+		return
+	}
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.Status = code
+	span.data.StatusMessage = message
+}
+
+// RecordError sets the status of the span to StatusCode_Error with the message of "err", if not nil.
+func (span *Span) RecordError(err error) {
+	if err != nil {
+		span.SetStatus(StatusCode_Error, err.Error())
+	}
+}
+
+// End ends the span and exports it. Calls after the first one are ignored.
+func (span *Span) End() {
+	if span == nil {
+		return
+	}
+	span.mutex.Lock()
+	if span.ended {
+		span.mutex.Unlock()
+		return
+	}
+	span.ended = true
+	span.data.EndTime = time.Now()
+	data := span.data
+	data.Attributes = make(map[string]interface{}, len(span.data.Attributes))
+	for key, value := range span.data.Attributes {
+		data.Attributes[key] = value
+	}
+	span.mutex.Unlock()
+
+	if span.tracer.exporter != nil {
+		span.tracer.exporter.ExportSpan(data)
+	}
+}
+
+// Tracer creates spans and exports them when they end.
+type Tracer struct {
+	exporter Exporter
+}
+
+// NewTracer : constructs a Tracer exporting to "exporter", which may be nil to discard the spans.
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+// Start starts a span named "name". The span is a child of the span of "ctx", or of the remote span context
+// of "ctx", if any; otherwise it starts a new trace. The returned context holds the new span.
+func (tracer *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	span := &Span{
+		tracer: tracer,
+		data: SpanData{
+			Name:       name,
+			Parent:     parent,
+			StartTime:  time.Now(),
+			Attributes: make(map[string]interface{}),
+		},
+	}
+	span.data.SpanContext.TraceID = parent.TraceID
+	span.data.SpanContext.Sampled = parent.Sampled || !parent.IsValid()
+	if !parent.IsValid() {
+		randomID(span.data.SpanContext.TraceID[:])
+	}
+	randomID(span.data.SpanContext.SpanID[:])
+	return ContextWithSpan(ctx, span), span
+}
+
+func randomID(id []byte) {
+	for {
+		if _, err := rand.Read(id); err != nil {
+			panic(fmt.Sprintf("failed to generate a random ID: %s", err.Error()))
+		}
+		for _, b := range id {
+			if b != 0 {
+				return
+			}
+		}
+	}
+}
+
+type spanKey struct{}
+
+type remoteSpanContextKey struct{}
+
+// ContextWithSpan returns a copy of "ctx" holding "span".
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// SpanFromContext returns the span held by "ctx", or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithRemoteSpanContext returns a copy of "ctx" holding a span context received from another
+// process, so that spans started with it continue the remote trace.
+func ContextWithRemoteSpanContext(ctx context.Context, spanContext SpanContext) context.Context {
+	return context.WithValue(ctx, remoteSpanContextKey{}, spanContext)
+}
+
+// SpanContextFromContext returns the span context of the span held by "ctx", or the remote span context
+// held by "ctx". The result is not valid if there is neither.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	spanContext, _ := ctx.Value(remoteSpanContextKey{}).(SpanContext)
+	return spanContext
+}
+
+// Inject sets the traceparent header for the span context of "ctx", if any.
+func Inject(ctx context.Context, header http.Header) {
+	if spanContext := SpanContextFromContext(ctx); spanContext.IsValid() {
+		header.Set(TraceparentHeader, spanContext.Traceparent())
+	}
+}
+
+// Extract returns a copy of "ctx" holding the remote span context of the traceparent header, if valid.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	spanContext, err := ParseTraceparent(header.Get(TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteSpanContext(ctx, spanContext)
+}
+
+// InMemoryExporter records the exported spans in memory, for tests.
+type InMemoryExporter struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter : constructs an empty InMemoryExporter.
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan records "span".
+func (exporter *InMemoryExporter) ExportSpan(span SpanData) {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = append(exporter.spans, span)
+}
+
+// Spans returns the recorded spans, in the order they ended.
+func (exporter *InMemoryExporter) Spans() []SpanData {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	return append([]SpanData(nil), exporter.spans...)
+}
+
+// Reset forgets the recorded spans.
+func (exporter *InMemoryExporter) Reset() {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = nil
+}